@@ -3,27 +3,72 @@ package main
 import (
 	"backend/internal/config"
 	"backend/internal/database"
+	"backend/internal/logging"
 	"backend/internal/server"
-	"log"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径（YAML或TOML），也可通过 CONFIG_FILE 指定")
+	printConfig := flag.Bool("print-config", false, "打印生效的配置（隐藏敏感信息）后退出")
+	flag.Parse()
+
+	if *printConfig {
+		os.Exit(runPrintConfig(*configPath))
+	}
+
 	// 加载配置
-	cfg := config.Load()
-	log.Println("Configuration loaded successfully")
+	cfg, err := config.LoadFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logging: %v\n", err)
+		os.Exit(1)
+	}
+	slog.Info("配置加载成功", "config_file", *configPath, "log_level", cfg.Log.Level)
 
 	// 初始化数据库
 	if err := database.Init(cfg.Database.Path); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		slog.Error("数据库初始化失败", "path", cfg.Database.Path, logging.Err(err))
+		os.Exit(1)
 	}
 	defer database.Close() // 确保程序退出时关闭数据库
-	log.Println("Database initialized successfully")
 
 	// 创建服务器实例
-	srv := server.New(cfg)
+	srv := server.New(cfg, *configPath)
 
 	// 启动服务器
 	if err := srv.Start(); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		slog.Error("服务启动失败", logging.Err(err))
+		database.Close()
+		os.Exit(1)
+	}
+}
+
+// runPrintConfig 打印合并后的配置，校验失败时输出错误并返回非零退出码
+func runPrintConfig(path string) int {
+	cfg, err := config.Resolve(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration:\n%v\n", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode configuration: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration is invalid:\n%v\n", err)
+		return 1
 	}
-}
\ No newline at end of file
+	return 0
+}
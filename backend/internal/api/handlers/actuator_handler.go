@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var actuator *service.Actuator
+
+// InitActuatorHandler 初始化执行器处理器
+func InitActuatorHandler(a *service.Actuator) {
+	actuator = a
+}
+
+// GetActuatorStatus 获取执行器的配置和操作统计
+// GET /api/actuator
+func GetActuatorStatus(c *gin.Context) {
+	status, err := actuator.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListActuations 列出执行器的操作记录（撤销日志）
+// GET /api/actuator/actions?active=true
+func ListActuations(c *gin.Context) {
+	actuations, err := actuator.List(c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"actions": actuations})
+}
+
+// GetActuation 获取操作记录
+// GET /api/actuator/actions/:id
+func GetActuation(c *gin.Context) {
+	actuation, err := actuator.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(actuationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, actuation)
+}
+
+// UndoActuation 手动还原一次操作
+// POST /api/actuator/actions/:id/undo
+func UndoActuation(c *gin.Context) {
+	actuation, err := actuator.Undo(c.Request.Context(), c.Param("id"), changeFromContext(c))
+	if err != nil {
+		c.JSON(actuationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, actuation)
+}
+
+// actuationErrorStatus 将执行器错误映射为HTTP状态码，修改面板失败时返回502
+func actuationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrActuationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrActuationNotActive):
+		return http.StatusConflict
+	default:
+		return http.StatusBadGateway
+	}
+}
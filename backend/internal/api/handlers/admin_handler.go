@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/pkg/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigReloader 配置重载器，由server实现
+type ConfigReloader interface {
+	Reload(trigger string) *models.ReloadResult
+	LastReload() *models.ReloadResult
+	CurrentConfig() *config.Config
+}
+
+var configReloader ConfigReloader
+
+// InitAdminHandler 初始化管理接口处理器
+func InitAdminHandler(reloader ConfigReloader) {
+	configReloader = reloader
+}
+
+// GetConfig 查看生效的配置（隐藏敏感信息）和最近一次重载结果
+// GET /api/admin/config
+func GetConfig(c *gin.Context) {
+	if configReloader == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "配置管理不可用"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":      configReloader.CurrentConfig().Redacted(),
+		"last_reload": configReloader.LastReload(),
+	})
+}
+
+// ReloadConfig 重新加载配置文件和环境变量
+// POST /api/admin/config/reload
+func ReloadConfig(c *gin.Context) {
+	if configReloader == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "配置管理不可用"})
+		return
+	}
+
+	result := configReloader.Reload("api")
+	if !result.Success {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -30,14 +30,19 @@ func SetSystemAlias(c *gin.Context) {
 	}
 
 	// 设置别名
-	err := aliasService.SetAlias(systemID, &request)
+	alias, err := aliasService.SetAlias(systemID, &request, changeFromContext(c))
 	if err != nil {
+		if respondVersionConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, alias.Version)
 	c.JSON(http.StatusOK, models.SystemAliasResponse{
 		Success: "别名设置成功",
+		Alias:   alias,
 	})
 }
 
@@ -49,19 +54,21 @@ func GetSystemAlias(c *gin.Context) {
 		return
 	}
 
-	alias, err := aliasService.GetAlias(systemID)
+	alias, err := aliasService.GetAlias(c.Request.Context(), systemID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	if alias == nil {
+		setETag(c, 0)
 		c.JSON(http.StatusOK, models.SystemAliasResponse{
 			Alias: nil,
 		})
 		return
 	}
 
+	setETag(c, alias.Version)
 	c.JSON(http.StatusOK, models.SystemAliasResponse{
 		Alias: alias,
 	})
@@ -75,8 +82,11 @@ func DeleteSystemAlias(c *gin.Context) {
 		return
 	}
 
-	err := aliasService.DeleteAlias(systemID)
+	err := aliasService.DeleteAlias(systemID, changeFromContext(c))
 	if err != nil {
+		if respondVersionConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAnomalies 获取偏离历史基线的系统及每个指标的比较结果
+// GET /api/systems/anomalies?all=true
+func GetAnomalies(c *gin.Context) {
+	anomalies, err := systemService.GetAnomalies(c.Request.Context(), c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"systems": anomalies})
+}
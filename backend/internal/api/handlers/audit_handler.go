@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"backend/pkg/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorKey 认证中间件在gin.Context中保存操作者的键
+const ActorKey = "actor"
+
+// 审计日志查询的默认和最大条数
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// changeFromContext 从请求中取出操作者和If-Match前置条件
+func changeFromContext(c *gin.Context) service.Change {
+	actor := c.GetString(ActorKey)
+	if actor == "" {
+		actor = c.GetHeader("X-Actor")
+	}
+	if actor == "" {
+		actor = "anonymous@" + c.ClientIP()
+	}
+	return service.Change{Actor: actor, IfMatch: c.GetHeader("If-Match")}
+}
+
+// setETag 以版本号作为ETag返回，客户端修改时通过If-Match带回
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// respondVersionConflict If-Match不匹配时返回412，是否已处理
+func respondVersionConflict(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrVersionConflict) {
+		return false
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	return true
+}
+
+// GetAuditLog 查询配置修改审计日志，按时间倒序
+// GET /api/audit?resource=threshold&resource_id=xxx&actor=xxx&since=...&until=...&limit=100
+func GetAuditLog(c *gin.Context) {
+	query := &models.AuditQuery{
+		Resource:   c.Query("resource"),
+		ResourceID: c.Query("resource_id"),
+		Actor:      c.Query("actor"),
+		Limit:      defaultAuditLimit,
+	}
+
+	var err error
+	if query.Since, err = parseTimeParam(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since 格式错误，应为RFC3339或Unix秒"})
+		return
+	}
+	if query.Until, err = parseTimeParam(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "until 格式错误，应为RFC3339或Unix秒"})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit 必须是正整数"})
+			return
+		}
+		if limit > maxAuditLimit {
+			limit = maxAuditLimit
+		}
+		query.Limit = limit
+	}
+
+	entries, err := service.ListAudit(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
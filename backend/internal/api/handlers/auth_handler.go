@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"backend/pkg/models"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalKey 认证中间件在gin.Context中保存当前调用方的键
+const PrincipalKey = "principal"
+
+// SessionCookie 浏览器登录会话的cookie名称
+const SessionCookie = "beszel_session"
+
+var authService *service.AuthService
+
+// InitAuthHandler 初始化认证处理器
+func InitAuthHandler(svc *service.AuthService) {
+	authService = svc
+}
+
+// TokenFromRequest 依次从 Authorization: Bearer、X-API-Key 头和会话cookie中取出凭据
+func TokenFromRequest(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if cookie, err := c.Cookie(SessionCookie); err == nil {
+		return cookie
+	}
+	return ""
+}
+
+// currentPrincipal 当前调用方，未启用认证时为nil
+func currentPrincipal(c *gin.Context) *service.Principal {
+	if value, ok := c.Get(PrincipalKey); ok {
+		if principal, ok := value.(*service.Principal); ok {
+			return principal
+		}
+	}
+	return nil
+}
+
+// setSessionCookie 设置或清除会话cookie。HttpOnly防止脚本读取，SameSite=Lax阻止跨站提交
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(SessionCookie, token, maxAge, "/", "", authService.CookieSecure(), true)
+}
+
+// Login 用户名密码登录，会话token同时写入cookie并在响应中返回
+// POST /api/auth/login
+func Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	token, session, user, err := authService.Login(req.Username, req.Password, c.ClientIP())
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+
+	setSessionCookie(c, token, int(time.Until(session.ExpiresAt).Seconds()))
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": session.ExpiresAt, "user": user})
+}
+
+// Logout 退出登录，删除当前会话
+// POST /api/auth/logout
+func Logout(c *gin.Context) {
+	if err := authService.Logout(currentPrincipal(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
+
+// GetCurrentUser 当前调用方信息，前端据此判断是否需要登录以及显示哪些操作
+// GET /api/auth/me
+func GetCurrentUser(c *gin.Context) {
+	principal := currentPrincipal(c)
+	if principal == nil {
+		c.JSON(http.StatusOK, gin.H{"auth_enabled": authService.Enabled()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"auth_enabled": true, "principal": principal})
+}
+
+// ChangePassword 修改自己的密码，成功后所有会话失效，需要重新登录
+// PUT /api/auth/password
+func ChangePassword(c *gin.Context) {
+	principal := currentPrincipal(c)
+	if principal == nil || principal.Kind != "user" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有登录用户可以修改密码"})
+		return
+	}
+
+	var req models.PasswordChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	if err := authService.ChangePassword(principal.Name, req.OldPassword, req.NewPassword, changeFromContext(c)); err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "密码已修改，请重新登录"})
+}
+
+// ListUsers 列出所有用户
+// GET /api/auth/users
+func ListUsers(c *gin.Context) {
+	users, err := authService.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// CreateUser 创建用户
+// POST /api/auth/users
+func CreateUser(c *gin.Context) {
+	var req models.UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	user, err := authService.CreateUser(&req, changeFromContext(c))
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser 修改用户角色、禁用状态或重置密码
+// PUT /api/auth/users/:username
+func UpdateUser(c *gin.Context) {
+	var req models.UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	user, err := authService.UpdateUser(c.Param("username"), &req, changeFromContext(c))
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser 删除用户
+// DELETE /api/auth/users/:username
+func DeleteUser(c *gin.Context) {
+	if err := authService.DeleteUser(c.Param("username"), changeFromContext(c)); err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "用户已删除"})
+}
+
+// ListAPIKeys 列出所有API密钥
+// GET /api/auth/api-keys
+func ListAPIKeys(c *gin.Context) {
+	keys, err := authService.ListAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey 创建API密钥，明文密钥只在本次响应中返回
+// POST /api/auth/api-keys
+func CreateAPIKey(c *gin.Context) {
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	secret, key, err := authService.CreateAPIKey(&req, changeFromContext(c))
+	if err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"key": secret, "api_key": key})
+}
+
+// DeleteAPIKey 吊销API密钥
+// DELETE /api/auth/api-keys/:id
+func DeleteAPIKey(c *gin.Context) {
+	if err := authService.DeleteAPIKey(c.Param("id"), changeFromContext(c)); err != nil {
+		respondAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API密钥已吊销"})
+}
+
+// respondAuthError 按错误类型返回状态码
+func respondAuthError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrUnauthenticated):
+		status = http.StatusUnauthorized
+	case errors.Is(err, service.ErrLoginLocked):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAPIKeyNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrUserExists), errors.Is(err, service.ErrLastAdmin):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInvalidAuthRequest):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
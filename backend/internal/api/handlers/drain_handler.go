@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"backend/pkg/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var drainService *service.DrainService
+
+// InitDrainHandler 初始化排空处理器
+func InitDrainHandler() {
+	drainService = service.NewDrainService()
+}
+
+// ListDrains 列出排空记录，默认只返回生效中的
+// GET /api/drains?all=true
+func ListDrains(c *gin.Context) {
+	drains, err := drainService.List(c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drains": drains})
+}
+
+// GetDrain 获取排空记录
+// GET /api/drains/:id
+func GetDrain(c *gin.Context) {
+	drain, err := drainService.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(drainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, drain)
+}
+
+// CreateDrain 排空服务器或单个节点
+// POST /api/drains
+func CreateDrain(c *gin.Context) {
+	var req models.DrainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	drain, err := drainService.Create(&req, changeFromContext(c))
+	if err != nil {
+		c.JSON(drainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, drain)
+}
+
+// DeleteDrain 取消排空，目标立即恢复按指标计算负载状态
+// DELETE /api/drains/:id
+func DeleteDrain(c *gin.Context) {
+	if err := drainService.Delete(c.Param("id"), changeFromContext(c)); err != nil {
+		c.JSON(drainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消排空"})
+}
+
+// drainErrorStatus 将排空错误映射为HTTP状态码
+func drainErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDrainNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDrainExists):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidDrain):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var forecaster *service.Forecaster
+
+// InitForecastHandler 初始化容量预测处理器
+func InitForecastHandler(f *service.Forecaster) {
+	forecaster = f
+}
+
+// GetSystemForecast 预测单个系统的在线人数和带宽，以及预计达到阈值的时间
+// GET /api/systems/:id/forecast?days=30
+func GetSystemForecast(c *gin.Context) {
+	days, err := parseForecastDays(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	forecast, err := forecaster.SystemForecast(c.Request.Context(), c.Param("id"), days)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrSystemNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// GetCapacityReport 全部系统的容量报告，按预计达到阈值的时间排序
+// GET /api/systems/capacity?days=30
+func GetCapacityReport(c *gin.Context) {
+	days, err := parseForecastDays(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := forecaster.CapacityReport(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseForecastDays 解析预测天数，未指定时返回0（使用配置的默认值）
+func parseForecastDays(c *gin.Context) (int, error) {
+	value := c.Query("days")
+	if value == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 || days > config.MaxForecastDays {
+		return 0, fmt.Errorf("days 必须是1-%d之间的整数", config.MaxForecastDays)
+	}
+	return days, nil
+}
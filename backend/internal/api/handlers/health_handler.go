@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var healthService *service.HealthService
+var startedAt = time.Now()
+
+// InitHealthHandler 初始化健康检查处理器
+func InitHealthHandler(svc *service.HealthService) {
+	healthService = svc
+}
+
+// Health 存活检查：进程能处理请求即返回200，不检查任何依赖，
+// 避免上游故障时编排系统反复重启实例
+// GET /health
+func Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "ok",
+		"message":        "Server is running",
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// Ready 就绪检查：逐项检查依赖，关键依赖不可用时返回503
+// GET /ready
+func Ready(c *gin.Context) {
+	if healthService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": service.ReadyNotReady, "error": "服务尚未初始化"})
+		return
+	}
+
+	report := healthService.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status == service.ReadyNotReady {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"backend/pkg/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var maintenanceService *service.MaintenanceService
+
+// InitMaintenanceHandler 初始化维护窗口处理器
+func InitMaintenanceHandler() {
+	maintenanceService = service.NewMaintenanceService()
+}
+
+// ListMaintenance 列出维护窗口和静默
+// GET /api/maintenance?system_id=xxx&active=true
+func ListMaintenance(c *gin.Context) {
+	windows, err := maintenanceService.List(c.Query("system_id"), c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"maintenance": windows})
+}
+
+// GetMaintenance 获取维护窗口
+// GET /api/maintenance/:id
+func GetMaintenance(c *gin.Context) {
+	window, err := maintenanceService.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(maintenanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+// CreateMaintenance 创建计划维护窗口或临时静默
+// POST /api/maintenance
+func CreateMaintenance(c *gin.Context) {
+	var req models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	window, err := maintenanceService.Create(&req, changeFromContext(c))
+	if err != nil {
+		c.JSON(maintenanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+// UpdateMaintenance 修改维护窗口，如延长或提前结束
+// PUT /api/maintenance/:id
+func UpdateMaintenance(c *gin.Context) {
+	var req models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	window, err := maintenanceService.Update(c.Param("id"), &req, changeFromContext(c))
+	if err != nil {
+		c.JSON(maintenanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+// DeleteMaintenance 删除维护窗口，生效中的窗口立即结束
+// DELETE /api/maintenance/:id
+func DeleteMaintenance(c *gin.Context) {
+	if err := maintenanceService.Delete(c.Param("id"), changeFromContext(c)); err != nil {
+		c.JSON(maintenanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "维护窗口删除成功"})
+}
+
+// maintenanceErrorStatus 将维护窗口错误映射为HTTP状态码
+func maintenanceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrMaintenanceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidMaintenance):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -17,7 +17,7 @@ func InitNodeHandler(ns *service.NodeService) {
 // GetSystemNodes 获取系统的节点信息
 func GetSystemNodes(c *gin.Context) {
 	// 检查nodeService是否初始化
-	if nodeService == nil {
+	if nodeService == nil || !nodeService.Available() {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "节点服务不可用，Redis连接失败"})
 		return
 	}
@@ -29,7 +29,7 @@ func GetSystemNodes(c *gin.Context) {
 	}
 
 	// 获取系统基本信息
-	systems, err := systemService.GetSystems()
+	systems, err := systemService.GetSystems(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统信息失败"})
 		return
@@ -49,7 +49,7 @@ func GetSystemNodes(c *gin.Context) {
 	}
 
 	// 获取节点信息
-	nodeInfo, err := nodeService.GetSystemNodeInfo(systemID, systemName)
+	nodeInfo, err := nodeService.GetSystemNodeInfo(c.Request.Context(), systemID, systemName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -61,20 +61,20 @@ func GetSystemNodes(c *gin.Context) {
 // GetAllSystemsNodes 获取所有系统的节点信息
 func GetAllSystemsNodes(c *gin.Context) {
 	// 检查nodeService是否初始化
-	if nodeService == nil {
+	if nodeService == nil || !nodeService.Available() {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "节点服务不可用，Redis连接失败"})
 		return
 	}
 
 	// 获取所有系统
-	systems, err := systemService.GetSystems()
+	systems, err := systemService.GetSystems(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统列表失败"})
 		return
 	}
 
 	// 获取所有系统的节点信息
-	allNodeInfo, err := nodeService.GetAllSystemsNodeInfo(systems)
+	allNodeInfo, err := nodeService.GetAllSystemsNodeInfo(c.Request.Context(), systems)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -88,7 +88,7 @@ func GetAllSystemsNodes(c *gin.Context) {
 // SearchNodes 搜索节点
 func SearchNodes(c *gin.Context) {
 	// 检查nodeService是否初始化
-	if nodeService == nil {
+	if nodeService == nil || !nodeService.Available() {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "节点服务不可用，Redis连接失败"})
 		return
 	}
@@ -99,7 +99,7 @@ func SearchNodes(c *gin.Context) {
 		return
 	}
 
-	nodes, err := nodeService.SearchNodesByKeyword(keyword)
+	nodes, err := nodeService.SearchNodesByKeyword(c.Request.Context(), keyword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -114,7 +114,7 @@ func SearchNodes(c *gin.Context) {
 // GetHighLoadNodes 获取所有高负载节点
 func GetHighLoadNodes(c *gin.Context) {
 	// 检查nodeService是否初始化
-	if nodeService == nil {
+	if nodeService == nil || !nodeService.Available() {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "节点服务不可用，Redis连接失败",
 			"data":  []map[string]interface{}{},
@@ -123,35 +123,16 @@ func GetHighLoadNodes(c *gin.Context) {
 	}
 
 	// 获取带负载状态的系统列表
-	systems, err := systemService.GetSystemsWithLoadStatus()
+	systems, err := systemService.GetSystemsWithLoadStatus(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统负载状态失败"})
 		return
 	}
 
-	// 初始化为空数组而不是nil slice，确保JSON返回[]而不是null
-	highLoadNodes := make([]map[string]interface{}, 0)
-
-	// 遍历所有系统，找出高负载的
-	for _, system := range systems {
-		// 只处理高负载或离线的服务器
-		if system.LoadStatus == "high" || system.Status != "up" {
-			// 获取该系统的节点信息
-			nodeInfo, err := nodeService.GetSystemNodeInfo(system.ID, system.Name)
-			if err != nil {
-				continue // 跳过获取失败的系统
-			}
-
-			// 将该系统的所有节点添加到高负载节点列表
-			for _, node := range nodeInfo.Nodes {
-				highLoadNodes = append(highLoadNodes, map[string]interface{}{
-					"name":   node.Name,
-					"type":   node.Type,
-					"id":     node.ID,
-					"online": node.Online,
-				})
-			}
-		}
+	highLoadNodes, err := nodeService.GetHighLoadNodes(c.Request.Context(), systems)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, highLoadNodes)
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"backend/pkg/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var nodeTagService *service.NodeTagService
+
+// InitNodeTagHandler 初始化节点标签处理器
+func InitNodeTagHandler() {
+	nodeTagService = service.NewNodeTagService()
+}
+
+// GetSystemTags 获取服务器的节点标签
+// GET /api/systems/:id/tags
+func GetSystemTags(c *gin.Context) {
+	tags, err := nodeTagService.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// AddSystemTag 为服务器添加节点标签
+// POST /api/systems/:id/tags {"type":"trojan","id":12}
+func AddSystemTag(c *gin.Context) {
+	var request models.NodeTagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据", "details": err.Error()})
+		return
+	}
+
+	tag, err := nodeTagService.Add(c.Param("id"), &request, changeFromContext(c))
+	if err != nil {
+		c.JSON(nodeTagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "节点标签添加成功", "tag": tag})
+}
+
+// RemoveSystemTag 删除服务器的节点标签
+// DELETE /api/systems/:id/tags {"type":"trojan","id":12}
+func RemoveSystemTag(c *gin.Context) {
+	var request models.NodeTagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据", "details": err.Error()})
+		return
+	}
+
+	if err := nodeTagService.Remove(c.Param("id"), &request, changeFromContext(c)); err != nil {
+		c.JSON(nodeTagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "节点标签删除成功"})
+}
+
+// nodeTagErrorStatus 将节点标签错误映射为HTTP状态码
+func nodeTagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNodeTagNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidNodeTag):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
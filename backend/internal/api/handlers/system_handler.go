@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"backend/internal/service"
+	"backend/pkg/models"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,11 +19,23 @@ func InitHandlers(svc *service.SystemService) {
 	systemService = svc
 	thresholdHandler = NewThresholdHandler()
 	InitAliasHandler()
+	InitNodeTagHandler()
+	InitMaintenanceHandler()
+	InitDrainHandler()
+	InitTransferHandler(svc)
 }
 
 // GetSystems 获取所有系统列表
+// 支持查询参数：q（模糊匹配名称、主机名、内核、CPU型号等）、status、agent_version、os
 func GetSystems(c *gin.Context) {
-	systems, err := systemService.GetSystems()
+	filter := &models.SystemFilter{
+		Keyword:      c.Query("q"),
+		Status:       c.Query("status"),
+		AgentVersion: c.Query("agent_version"),
+		OS:           c.Query("os"),
+	}
+
+	systems, err := systemService.SearchSystems(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统列表失败", "details": err.Error()})
 		return
@@ -31,7 +46,7 @@ func GetSystems(c *gin.Context) {
 
 // GetSystemSummary 获取系统摘要
 func GetSystemSummary(c *gin.Context) {
-	summary, err := systemService.GetSystemSummary()
+	summary, err := systemService.GetSystemSummary(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统摘要失败", "details": err.Error()})
 		return
@@ -40,13 +55,33 @@ func GetSystemSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, summary)
 }
 
+// GetFleetInventory 获取服务器资产清单（按agent版本、操作系统、CPU型号分组）
+func GetFleetInventory(c *gin.Context) {
+	inventory, err := systemService.GetFleetInventory(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取资产清单失败", "details": err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, inventory)
+}
+
 // GetSystemStats 获取指定系统的统计数据
+// 未提供时间范围参数时返回最近limit条1m记录；
+// 提供from、to、type、fields、max_points任一参数时按时间范围查询：
+// from/to 支持RFC3339或Unix秒，type为空时按跨度自动选择粒度，
+// fields为逗号分隔的字段名，max_points为降采样后的最大点数
 func GetSystemStats(c *gin.Context) {
 	systemID := c.Param("id")
 	if systemID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "系统ID不能为空"})
 		return
 	}
+
+	if isStatsRangeRequest(c) {
+		getSystemStatsRange(c, systemID)
+		return
+	}
 	
 	// 获取条数参数
 	limitStr := c.DefaultQuery("limit", "5")
@@ -55,7 +90,7 @@ func GetSystemStats(c *gin.Context) {
 		limit = 5
 	}
 	
-	stats, err := systemService.GetSystemStats(systemID, limit)
+	stats, err := systemService.GetSystemStats(c.Request.Context(), systemID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统统计数据失败", "details": err.Error()})
 		return
@@ -64,9 +99,102 @@ func GetSystemStats(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"stats": stats, "total": len(stats)})
 }
 
+// isStatsRangeRequest 判断是否为时间范围查询
+func isStatsRangeRequest(c *gin.Context) bool {
+	for _, key := range []string{"from", "to", "type", "fields", "max_points"} {
+		if _, ok := c.GetQuery(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getSystemStatsRange 处理时间范围查询
+func getSystemStatsRange(c *gin.Context, systemID string) {
+	query := &models.StatsRangeQuery{
+		SystemID: systemID,
+		Type:     c.Query("type"),
+	}
+
+	var err error
+	if query.From, err = parseTimeParam(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from参数格式错误", "details": err.Error()})
+		return
+	}
+	if query.To, err = parseTimeParam(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to参数格式错误", "details": err.Error()})
+		return
+	}
+	if query.Type != "" && !service.ValidStatsType(query.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type参数无效，可选值: 1m, 10m, 20m, 120m, 480m"})
+		return
+	}
+	if maxPoints := c.Query("max_points"); maxPoints != "" {
+		query.MaxPoints, err = strconv.Atoi(maxPoints)
+		if err != nil || query.MaxPoints <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_points必须为正整数"})
+			return
+		}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from不能晚于to"})
+		return
+	}
+
+	var fields []string
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	if err := service.ValidateStatFields(fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fields参数无效", "details": err.Error()})
+		return
+	}
+	query.Fields = fields
+
+	result, err := systemService.GetSystemStatsRange(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统统计数据失败", "details": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"type":      result.Type,
+		"from":      result.From,
+		"to":        result.To,
+		"raw_count": result.RawCount,
+		"truncated": result.Truncated,
+		"total":     len(result.Stats),
+	}
+	if len(fields) > 0 {
+		selected, err := service.SelectStatFields(result.Stats, fields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fields参数无效", "details": err.Error()})
+			return
+		}
+		response["stats"] = selected
+	} else {
+		response["stats"] = result.Stats
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseTimeParam 解析RFC3339或Unix秒格式的时间参数，空字符串返回零值
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 // GetSystemsWithAvgStats 获取所有系统及其平均统计数据（包含负载状态）
 func GetSystemsWithAvgStats(c *gin.Context) {
-	systems, err := systemService.GetSystemsWithLoadStatus()
+	systems, err := systemService.GetSystemsWithLoadStatus(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系统统计数据失败", "details": err.Error()})
 		return
@@ -84,6 +212,10 @@ func UpdateThreshold(c *gin.Context) {
 	thresholdHandler.UpdateThreshold(c)
 }
 
+func PatchThreshold(c *gin.Context) {
+	thresholdHandler.PatchThreshold(c)
+}
+
 func DeleteThreshold(c *gin.Context) {
 	thresholdHandler.DeleteThreshold(c)
 }
@@ -92,3 +224,24 @@ func GetAllThresholds(c *gin.Context) {
 	thresholdHandler.GetAllThresholds(c)
 }
 
+// 阈值模板相关的全局函数包装器
+func ListThresholdProfiles(c *gin.Context) {
+	thresholdHandler.ListThresholdProfiles(c)
+}
+
+func GetThresholdProfile(c *gin.Context) {
+	thresholdHandler.GetThresholdProfile(c)
+}
+
+func CreateThresholdProfile(c *gin.Context) {
+	thresholdHandler.CreateThresholdProfile(c)
+}
+
+func UpdateThresholdProfile(c *gin.Context) {
+	thresholdHandler.UpdateThresholdProfile(c)
+}
+
+func DeleteThresholdProfile(c *gin.Context) {
+	thresholdHandler.DeleteThresholdProfile(c)
+}
+
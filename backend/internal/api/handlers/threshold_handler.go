@@ -3,6 +3,8 @@ package handlers
 import (
 	"backend/internal/service"
 	"backend/pkg/models"
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -29,12 +31,13 @@ func (h *ThresholdHandler) GetThreshold(c *gin.Context) {
 		return
 	}
 
-	threshold, err := h.thresholdService.GetThreshold(systemID)
+	threshold, err := h.thresholdService.GetThreshold(c.Request.Context(), systemID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, threshold.Version)
 	c.JSON(http.StatusOK, threshold)
 }
 
@@ -47,44 +50,60 @@ func (h *ThresholdHandler) UpdateThreshold(c *gin.Context) {
 		return
 	}
 
-	var threshold models.SystemThreshold
-	if err := c.ShouldBindJSON(&threshold); err != nil {
+	var body map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
 		return
 	}
 
-	// 验证阈值范围
-	if threshold.CPUAlertLimit < 0 || threshold.CPUAlertLimit > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "CPU阈值必须在0-100之间"})
-		return
-	}
-	if threshold.MemAlertLimit < 0 || threshold.MemAlertLimit > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "内存阈值必须在0-100之间"})
+	threshold, err := h.thresholdService.UpdateThreshold(systemID, body, changeFromContext(c))
+	if err != nil {
+		respondThresholdError(c, err)
 		return
 	}
-	if threshold.NetUpAlert < 0 || threshold.NetUpAlert > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "上行告警阈值必须在0-100之间"})
+
+	setETag(c, threshold.Version)
+	c.JSON(http.StatusOK, threshold)
+}
+
+// PatchThreshold 部分更新系统阈值配置，只修改请求中出现的字段，字段为null时恢复继承值
+// PATCH /api/systems/:id/threshold
+func (h *ThresholdHandler) PatchThreshold(c *gin.Context) {
+	systemID := c.Param("id")
+	if systemID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "系统ID不能为空"})
 		return
 	}
-	if threshold.NetDownAlert < 0 || threshold.NetDownAlert > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "下行告警阈值必须在0-100之间"})
+
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
 		return
 	}
 
-	err := h.thresholdService.UpdateThreshold(systemID, &threshold)
+	threshold, err := h.thresholdService.PatchThreshold(systemID, patch, changeFromContext(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondThresholdError(c, err)
 		return
 	}
 
-	// 返回更新后的配置
-	updatedThreshold, err := h.thresholdService.GetThreshold(systemID)
-	if err != nil {
+	setETag(c, threshold.Version)
+	c.JSON(http.StatusOK, threshold)
+}
+
+// respondThresholdError 校验错误返回400并列出每个字段的错误
+func respondThresholdError(c *gin.Context, err error) {
+	var fieldErrs service.FieldErrors
+	switch {
+	case errors.Is(err, service.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.As(err, &fieldErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "阈值参数校验失败", "fields": fieldErrs})
+	case errors.Is(err, service.ErrProfileNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": gin.H{"profile_id": err.Error()}})
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
 	}
-
-	c.JSON(http.StatusOK, updatedThreshold)
 }
 
 // GetAllThresholds 获取所有系统的阈值配置
@@ -108,11 +127,102 @@ func (h *ThresholdHandler) DeleteThreshold(c *gin.Context) {
 		return
 	}
 
-	err := h.thresholdService.DeleteThreshold(systemID)
+	err := h.thresholdService.DeleteThreshold(systemID, changeFromContext(c))
 	if err != nil {
+		if respondVersionConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "阈值配置删除成功"})
-}
\ No newline at end of file
+}
+
+// ListThresholdProfiles 获取所有阈值模板
+// GET /api/threshold-profiles
+func (h *ThresholdHandler) ListThresholdProfiles(c *gin.Context) {
+	profiles, err := h.thresholdService.ListThresholdProfiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// GetThresholdProfile 获取阈值模板
+// GET /api/threshold-profiles/:id
+func (h *ThresholdHandler) GetThresholdProfile(c *gin.Context) {
+	profile, err := h.thresholdService.GetThresholdProfile(c.Param("id"))
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// CreateThresholdProfile 创建阈值模板
+// POST /api/threshold-profiles
+func (h *ThresholdHandler) CreateThresholdProfile(c *gin.Context) {
+	var profile models.ThresholdProfile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	if err := h.thresholdService.CreateThresholdProfile(&profile, profileChange(c)); err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+// UpdateThresholdProfile 更新阈值模板，id为default时更新全局默认阈值
+// PUT /api/threshold-profiles/:id
+func (h *ThresholdHandler) UpdateThresholdProfile(c *gin.Context) {
+	var profile models.ThresholdProfile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误: " + err.Error()})
+		return
+	}
+
+	if err := h.thresholdService.UpdateThresholdProfile(c.Param("id"), &profile, profileChange(c)); err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteThresholdProfile 删除阈值模板
+// DELETE /api/threshold-profiles/:id
+func (h *ThresholdHandler) DeleteThresholdProfile(c *gin.Context) {
+	if err := h.thresholdService.DeleteThresholdProfile(c.Param("id"), profileChange(c)); err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "阈值模板删除成功"})
+}
+
+// profileChange 模板没有版本号，只记录操作者
+func profileChange(c *gin.Context) service.Change {
+	return service.Change{Actor: changeFromContext(c).Actor}
+}
+
+// profileErrorStatus 将阈值模板错误映射为HTTP状态码
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrProfileExists), errors.Is(err, service.ErrProfileInUse):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidProfile):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
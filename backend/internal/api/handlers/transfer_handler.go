@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"backend/internal/service"
+	"backend/pkg/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize 导入文件大小上限
+const maxImportSize = 16 << 20
+
+var transferService *service.TransferService
+
+// InitTransferHandler 初始化导入导出处理器
+func InitTransferHandler(svc *service.SystemService) {
+	transferService = service.NewTransferService(svc)
+}
+
+// ExportConfig 导出阈值模板、阈值、别名和节点标签
+// GET /api/export?format=json|csv
+func ExportConfig(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 json 或 csv"})
+		return
+	}
+
+	bundle, err := transferService.Export(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败", "details": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("beszel-sideloading-%s.%s", bundle.ExportedAt.Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "json" {
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := service.EncodeCSV(c.Writer, bundle); err != nil {
+		c.Error(err)
+	}
+}
+
+// ImportConfig 导入配置
+// POST /api/import?format=json|csv&mode=merge|replace&match=auto|id|name&dry_run=true
+// 请求体为导出的文件内容，也可以用multipart表单的file字段上传
+func ImportConfig(c *gin.Context) {
+	opts := models.ImportOptions{
+		Mode:  c.DefaultQuery("mode", service.ImportMerge),
+		Match: c.DefaultQuery("match", service.MatchAuto),
+		Actor: changeFromContext(c).Actor,
+	}
+	if raw := c.Query("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run 必须是布尔值"})
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	body, name, err := readImportBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取导入文件失败", "details": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	var bundle *models.ExportBundle
+	switch format := importFormat(c, name); format {
+	case "json":
+		bundle = &models.ExportBundle{}
+		decoder := json.NewDecoder(body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(bundle); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "JSON格式错误", "details": err.Error()})
+			return
+		}
+	case "csv":
+		bundle, err = service.DecodeCSV(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 json 或 csv"})
+		return
+	}
+
+	result, err := transferService.Import(c.Request.Context(), bundle, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImport) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入失败", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// readImportBody 读取导入内容，返回上传的文件名（如有）用于识别格式
+func readImportBody(c *gin.Context) (io.ReadCloser, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		return file, header.Filename, nil
+	}
+	return c.Request.Body, "", nil
+}
+
+// importFormat 依次按format参数、上传文件扩展名、Content-Type识别导入格式
+func importFormat(c *gin.Context, filename string) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), "."); ext != "" {
+		return ext
+	}
+	if strings.Contains(c.ContentType(), "csv") {
+		return "csv"
+	}
+	return "json"
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"backend/internal/api/handlers"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routePolicy 接口的访问要求
+type routePolicy struct {
+	role  string // 最低角色
+	scope string // 带作用域的API密钥只能访问声明了相同作用域的接口
+}
+
+// routePolicies 不按默认规则授权的接口，键为 "方法 路由模板"。
+// 未列出的接口：GET/HEAD 需要viewer，其它方法需要operator；adminPrefixes下的接口需要admin
+var routePolicies = map[string]routePolicy{
+	// 面板轮询的负载状态接口，可以使用只带load-status作用域的密钥
+	"GET /api/nodes/load-status": {role: service.RoleViewer, scope: service.ScopeLoadStatus},
+	"GET /api/systems/stats":     {role: service.RoleViewer, scope: service.ScopeLoadStatus},
+
+	// Prometheus抓取，可以使用只带metrics作用域的密钥
+	"GET /metrics": {role: service.RoleViewer, scope: service.ScopeMetrics},
+
+	// 任何已登录用户都可以退出和修改自己的密码
+	"POST /api/auth/logout":  {role: service.RoleViewer},
+	"PUT /api/auth/password": {role: service.RoleViewer},
+
+	// 导出的配置和审计日志包含全部修改记录，只开放给operator
+	"GET /api/export": {role: service.RoleOperator},
+	"GET /api/audit":  {role: service.RoleOperator},
+}
+
+// adminPrefixes 只允许admin访问的路由前缀
+var adminPrefixes = []string{"/api/admin/", "/api/auth/users", "/api/auth/api-keys"}
+
+// policyFor 查找接口的访问要求
+func policyFor(method, path string) routePolicy {
+	if policy, ok := routePolicies[method+" "+path]; ok {
+		return policy
+	}
+	for _, prefix := range adminPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return routePolicy{role: service.RoleAdmin}
+		}
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return routePolicy{role: service.RoleViewer}
+	}
+	return routePolicy{role: service.RoleOperator}
+}
+
+// AuthMiddleware 认证并按角色和作用域授权，调用方写入gin.Context供处理器和审计日志使用。
+// 未启用认证时所有请求直接放行
+func AuthMiddleware(auth *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.Enabled() {
+			c.Next()
+			return
+		}
+
+		principal, err := auth.Authenticate(handlers.TokenFromRequest(c))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, service.ErrUnauthenticated) {
+				status = http.StatusUnauthorized
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		policy := policyFor(c.Request.Method, c.FullPath())
+		if !principal.HasScope(policy.scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API密钥的作用域不包含该接口"})
+			return
+		}
+		if !service.RoleAllows(principal.Role, policy.role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足，需要 " + policy.role + " 角色"})
+			return
+		}
+
+		c.Set(handlers.PrincipalKey, principal)
+		c.Set(handlers.ActorKey, principal.Actor())
+		c.Next()
+	}
+}
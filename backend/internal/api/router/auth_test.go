@@ -0,0 +1,131 @@
+package router
+
+import (
+	"backend/internal/api/handlers"
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/service"
+	"backend/pkg/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAuthTestRouter 只挂载认证中间件和几个代表性接口
+func newAuthTestRouter(t *testing.T) (*gin.Engine, *service.AuthService) {
+	t.Helper()
+	if err := database.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	auth := service.NewAuthService(config.AuthConfig{
+		Enabled:           true,
+		SessionTTLMinutes: 60,
+		AdminUsername:     "admin",
+		AdminPassword:     "admin-password",
+	})
+	if err := auth.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api", AuthMiddleware(auth))
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"actor": c.GetString(handlers.ActorKey)}) }
+	api.GET("/nodes/load-status", ok)
+	api.GET("/systems", ok)
+	api.PUT("/systems/:id/threshold", ok)
+	api.GET("/auth/users", ok)
+	return r, auth
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	r, auth := newAuthTestRouter(t)
+
+	token := func(role string, scopes ...string) string {
+		key, _, err := auth.CreateAPIKey(&models.APIKeyRequest{Name: role, Role: role, Scopes: scopes}, service.Change{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	viewer := token(service.RoleViewer)
+	operator := token(service.RoleOperator)
+	panel := token(service.RoleViewer, service.ScopeLoadStatus)
+	session, _, _, err := auth.Login("admin", "admin-password", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"无凭据", http.MethodGet, "/api/systems", "", "", http.StatusUnauthorized},
+		{"无效密钥", http.MethodGet, "/api/systems", "X-API-Key", "bsk_nope_nope", http.StatusUnauthorized},
+		{"viewer读取", http.MethodGet, "/api/systems", "X-API-Key", viewer, http.StatusOK},
+		{"viewer修改", http.MethodPut, "/api/systems/a/threshold", "X-API-Key", viewer, http.StatusForbidden},
+		{"operator修改", http.MethodPut, "/api/systems/a/threshold", "Authorization", "Bearer " + operator, http.StatusOK},
+		{"operator管理用户", http.MethodGet, "/api/auth/users", "X-API-Key", operator, http.StatusForbidden},
+		{"面板密钥读取负载状态", http.MethodGet, "/api/nodes/load-status", "X-API-Key", panel, http.StatusOK},
+		{"面板密钥读取其他接口", http.MethodGet, "/api/systems", "X-API-Key", panel, http.StatusForbidden},
+		{"会话cookie", http.MethodGet, "/api/auth/users", "Cookie", handlers.SessionCookie + "=" + session, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, 期望 %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLoginForwardedFor(t *testing.T) {
+	// httptest请求的对端地址为 192.0.2.1
+	for _, tt := range []struct {
+		name    string
+		proxies []string
+		want    int
+	}{
+		{"默认不信任代理", nil, http.StatusTooManyRequests},
+		{"来自可信代理", []string{"192.0.2.0/24"}, http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, auth := newAuthTestRouter(t)
+			cfg := config.Default()
+			r := SetupRouter(NewCORSMiddleware(cfg.CORS), service.NewSystemService(cfg), auth, tt.proxies)
+
+			login := func(password, forwardedFor string) int {
+				body := fmt.Sprintf(`{"username":"admin","password":%q}`, password)
+				req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				return w.Code
+			}
+			// 每次伪造不同的 X-Forwarded-For
+			for i := 0; i < 5; i++ {
+				login("wrong-password", fmt.Sprintf("10.0.0.%d", i))
+			}
+			if got := login("admin-password", "10.0.0.99"); got != tt.want {
+				t.Errorf("status = %d, 期望 %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/tracing"
+	"slices"
+	"sync/atomic"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware 可在运行时替换配置的CORS中间件
+type CORSMiddleware struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewCORSMiddleware 按配置创建CORS中间件
+func NewCORSMiddleware(cfg config.CORSConfig) *CORSMiddleware {
+	m := &CORSMiddleware{}
+	m.Update(cfg)
+	return m
+}
+
+// Update 替换CORS配置，之后的请求立即使用新配置。
+// 没有配置允许的来源时不输出任何CORS头，浏览器只允许同源访问；
+// 明确列出来源时允许携带会话cookie，"*" 时只能使用Authorization头认证。
+func (m *CORSMiddleware) Update(cfg config.CORSConfig) {
+	var handler gin.HandlerFunc
+	if len(cfg.AllowOrigins) == 0 {
+		handler = func(c *gin.Context) { c.Next() }
+	} else {
+		corsConfig := cors.DefaultConfig()
+		corsConfig.AllowOrigins = cfg.AllowOrigins
+		corsConfig.AllowMethods = cfg.AllowMethods
+		corsConfig.AllowHeaders = cfg.AllowHeaders
+		corsConfig.ExposeHeaders = []string{"ETag", tracing.TraceIDHeader, logging.RequestIDHeader}
+		corsConfig.AllowCredentials = !slices.Contains(cfg.AllowOrigins, "*")
+		handler = cors.New(corsConfig)
+	}
+	m.handler.Store(&handler)
+}
+
+// Handler 返回gin中间件
+func (m *CORSMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		(*m.handler.Load())(c)
+	}
+}
@@ -2,34 +2,47 @@ package router
 
 import (
 	"backend/internal/api/handlers"
-	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/metrics"
 	"backend/internal/service"
+	"backend/internal/tracing"
+	"log/slog"
 	"path/filepath"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRouter 设置路由
-func SetupRouter(cfg *config.Config, systemService *service.SystemService) *gin.Engine {
+// SetupRouter 设置路由，CORS中间件由调用方持有以便配置重载时更新。
+// 只信任 trustedProxies 转发的客户端IP，为空时不信任任何 X-Forwarded-For，登录失败限制按连接的对端地址计数
+func SetupRouter(corsMiddleware *CORSMiddleware, systemService *service.SystemService, authService *service.AuthService, trustedProxies []string) *gin.Engine {
 	// 初始化处理器
 	handlers.InitHandlers(systemService)
-	r := gin.Default()
+	handlers.InitAuthHandler(authService)
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		// 配置已经过校验，不应发生
+		slog.Error("设置可信代理失败，不信任任何代理", "op", "router.setup", logging.Err(err))
+		r.SetTrustedProxies(nil)
+	}
+
+	// 链路追踪在最外层，span覆盖认证和处理器的全部耗时；
+	// 请求ID和访问日志在panic恢复之外，发生panic时同样记录500
+	r.Use(tracing.Middleware())
+	r.Use(logging.Middleware())
+	r.Use(logging.Recovery())
 
 	// 配置CORS中间件
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = cfg.CORS.AllowOrigins
-	corsConfig.AllowMethods = cfg.CORS.AllowMethods
-	corsConfig.AllowHeaders = cfg.CORS.AllowHeaders
-	r.Use(cors.New(corsConfig))
+	r.Use(corsMiddleware.Handler())
 
-	// 健康检查路由
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok", "message": "Server is running"})
-	})
+	// 存活检查和就绪检查，供编排系统探测，无需认证
+	r.GET("/health", handlers.Health)
+	r.GET("/ready", handlers.Ready)
+
+	// Prometheus指标，与API使用相同的认证
+	r.GET("/metrics", AuthMiddleware(authService), metrics.Handler())
 
 	// API路由组
-	setupAPIRoutes(r)
+	setupAPIRoutes(r, authService)
 
 	// 静态文件服务 - 使用NoRoute来处理所有未匹配的路由
 	setupStaticRoutes(r)
@@ -37,35 +50,115 @@ func SetupRouter(cfg *config.Config, systemService *service.SystemService) *gin.
 	return r
 }
 
-// setupAPIRoutes 设置API路由
-func setupAPIRoutes(r *gin.Engine) {
-	api := r.Group("/api")
+// setupAPIRoutes 设置API路由，除登录外都需要认证
+func setupAPIRoutes(r *gin.Engine, authService *service.AuthService) {
+	r.POST("/api/auth/login", handlers.Login)
+
+	api := r.Group("/api", AuthMiddleware(authService))
 	{
+		// 认证和账号管理路由，用户和API密钥管理需要admin角色
+		auth := api.Group("/auth")
+		{
+			auth.POST("/logout", handlers.Logout)
+			auth.GET("/me", handlers.GetCurrentUser)
+			auth.PUT("/password", handlers.ChangePassword)
+			auth.GET("/users", handlers.ListUsers)
+			auth.POST("/users", handlers.CreateUser)
+			auth.PUT("/users/:username", handlers.UpdateUser)
+			auth.DELETE("/users/:username", handlers.DeleteUser)
+			auth.GET("/api-keys", handlers.ListAPIKeys)
+			auth.POST("/api-keys", handlers.CreateAPIKey)
+			auth.DELETE("/api-keys/:id", handlers.DeleteAPIKey)
+		}
+		
+
 		// 系统相关路由
 		systems := api.Group("/systems")
 		{
 			systems.GET("", handlers.GetSystems)
 			systems.GET("/summary", handlers.GetSystemSummary)
 			systems.GET("/stats", handlers.GetSystemsWithAvgStats)
+			systems.GET("/inventory", handlers.GetFleetInventory)
+			systems.GET("/capacity", handlers.GetCapacityReport)
+			systems.GET("/anomalies", handlers.GetAnomalies)
 			systems.GET("/:id/stats", handlers.GetSystemStats)
+			systems.GET("/:id/forecast", handlers.GetSystemForecast)
 			
 			// 阈值配置路由
 			systems.GET("/:id/threshold", handlers.GetThreshold)
 			systems.PUT("/:id/threshold", handlers.UpdateThreshold)
+			systems.PATCH("/:id/threshold", handlers.PatchThreshold)
 			systems.DELETE("/:id/threshold", handlers.DeleteThreshold)
 		}
 		
 		// 全局阈值配置路由
 		api.GET("/thresholds", handlers.GetAllThresholds)
 		
+		// 阈值模板路由（id为default的模板即全局默认阈值）
+		profiles := api.Group("/threshold-profiles")
+		{
+			profiles.GET("", handlers.ListThresholdProfiles)
+			profiles.POST("", handlers.CreateThresholdProfile)
+			profiles.GET("/:id", handlers.GetThresholdProfile)
+			profiles.PUT("/:id", handlers.UpdateThresholdProfile)
+			profiles.DELETE("/:id", handlers.DeleteThresholdProfile)
+		}
+		
 		// 服务器别名路由
 		systems.PUT("/:id/alias", handlers.SetSystemAlias)      // 设置服务器别名
 		systems.GET("/:id/alias", handlers.GetSystemAlias)      // 获取服务器别名
 		systems.DELETE("/:id/alias", handlers.DeleteSystemAlias) // 删除服务器别名
 		
+		// 服务器节点标签路由
+		systems.GET("/:id/tags", handlers.GetSystemTags)       // 获取服务器节点标签
+		systems.POST("/:id/tags", handlers.AddSystemTag)       // 添加节点标签
+		systems.DELETE("/:id/tags", handlers.RemoveSystemTag)  // 删除节点标签
+		
 		// 所有别名
 		api.GET("/aliases", handlers.GetAllAliases)             // 获取所有别名
 		
+		// 维护窗口和静默
+		maintenance := api.Group("/maintenance")
+		{
+			maintenance.GET("", handlers.ListMaintenance)
+			maintenance.POST("", handlers.CreateMaintenance)
+			maintenance.GET("/:id", handlers.GetMaintenance)
+			maintenance.PUT("/:id", handlers.UpdateMaintenance)
+			maintenance.DELETE("/:id", handlers.DeleteMaintenance)
+		}
+		
+		// 手动排空服务器或节点
+		drains := api.Group("/drains")
+		{
+			drains.GET("", handlers.ListDrains)
+			drains.POST("", handlers.CreateDrain)
+			drains.GET("/:id", handlers.GetDrain)
+			drains.DELETE("/:id", handlers.DeleteDrain)
+		}
+		
+		// 面板节点自动操作和撤销
+		actuator := api.Group("/actuator")
+		{
+			actuator.GET("", handlers.GetActuatorStatus)
+			actuator.GET("/actions", handlers.ListActuations)
+			actuator.GET("/actions/:id", handlers.GetActuation)
+			actuator.POST("/actions/:id/undo", handlers.UndoActuation)
+		}
+		
+		// 配置导入导出
+		api.GET("/export", handlers.ExportConfig)
+		api.POST("/import", handlers.ImportConfig)
+		
+		// 配置修改审计日志
+		api.GET("/audit", handlers.GetAuditLog)
+		
+		// 管理接口
+		admin := api.Group("/admin")
+		{
+			admin.GET("/config", handlers.GetConfig)                // 查看生效配置（已隐藏敏感信息）和最近一次重载结果
+			admin.POST("/config/reload", handlers.ReloadConfig)     // 重新加载配置
+		}
+		
 		// 节点信息路由
 		systems.GET("/:id/nodes", handlers.GetSystemNodes)      // 获取系统节点信息
 		api.GET("/nodes", handlers.GetAllSystemsNodes)          // 获取所有系统节点信息
@@ -94,8 +187,8 @@ func setupStaticRoutes(r *gin.Engine) {
 			return
 		}
 		
-		// 如果是健康检查，跳过（已经被处理了）
-		if path == "/health" {
+		// 如果是健康检查或指标，跳过（已经被处理了）
+		if path == "/health" || path == "/ready" || path == "/metrics" {
 			return
 		}
 		
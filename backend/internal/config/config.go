@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config 应用配置
@@ -12,12 +15,34 @@ type Config struct {
 	CORS       CORSConfig       `json:"cors"`
 	PocketBase PocketBaseConfig `json:"pocketbase"`
 	Redis      RedisConfig      `json:"redis"`
+	Publish    PublishConfig    `json:"publish"`
+	Actuator   ActuatorConfig   `json:"actuator"`
+	History    HistoryConfig    `json:"history"`
+	Forecast   ForecastConfig   `json:"forecast"`
+	Anomaly    AnomalyConfig    `json:"anomaly"`
+	Thresholds ThresholdConfig  `json:"thresholds"`
+	Reload     ReloadConfig     `json:"reload"`
+	Auth       AuthConfig       `json:"auth"`
+	Tracing    TracingConfig    `json:"tracing"`
+	Log        LogConfig        `json:"log"`
+
+	// plaintextSecrets 没有通过 *_FILE 读取的凭据（来自配置文件或明文环境变量），严格模式下校验时拒绝
+	plaintextSecrets []secretSource
+}
+
+// secretSource 严格模式下必须通过 *_FILE 读取的凭据字段及其环境变量名
+type secretSource struct {
+	field string
+	env   string
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Host string `json:"host"`
 	Port string `json:"port"`
+	// TrustedProxies 可信反向代理的IP或CIDR，只有来自这些地址的请求才读取 X-Forwarded-For/X-Real-IP 作为客户端IP。
+	// 默认为空，直接使用连接的对端地址
+	TrustedProxies []string `json:"trusted_proxies"`
 }
 
 // DatabaseConfig 数据库配置
@@ -34,9 +59,22 @@ type CORSConfig struct {
 
 // PocketBaseConfig PocketBase配置
 type PocketBaseConfig struct {
-	BaseURL  string `json:"base_url"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	BaseURL        string `json:"base_url"`
+	Email          string `json:"email"`
+	Password       string `json:"password"`
+	AuthCollection string `json:"auth_collection"` // users 或 _superusers
+	// Token 预签发的认证token（API token或impersonate token），设置后不再需要登录
+	Token string `json:"token"`
+	// StrictSecrets 严格模式：PocketBase密码和token、Redis密码、面板token只允许通过 *_FILE 读取，
+	// 配置文件或明文环境变量中的凭据在校验时被拒绝
+	StrictSecrets bool `json:"strict_secrets"`
+
+	TimeoutSeconds         int `json:"timeout_seconds"`          // 单次请求超时
+	RetryAttempts          int `json:"retry_attempts"`           // GET请求最大尝试次数（含第一次）
+	RetryBaseDelayMs       int `json:"retry_base_delay_ms"`      // 重试基础退避时间
+	RetryMaxDelayMs        int `json:"retry_max_delay_ms"`       // 重试最大退避时间
+	BreakerThreshold       int `json:"breaker_threshold"`        // 连续失败多少次后熔断，0表示禁用
+	BreakerCooldownSeconds int `json:"breaker_cooldown_seconds"` // 熔断后多久允许探测请求
 }
 
 // RedisConfig Redis配置
@@ -47,33 +85,406 @@ type RedisConfig struct {
 	Password string `json:"password"`
 }
 
-// Load 加载配置
-func Load() *Config {
+// PublishConfig 把每个节点的负载状态写回Redis并通过pub/sub发布状态变化，
+// 供v2board/Xboard插件直接读取，不必轮询 /api/nodes/load-status
+type PublishConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"interval_seconds"` // 发布间隔
+	// TTLSeconds 状态key的有效期，应大于发布间隔，本服务停止后状态自动过期
+	TTLSeconds int `json:"ttl_seconds"`
+	// KeyTemplate 状态key的格式，{type} 和 {id} 替换为节点类型和节点ID
+	KeyTemplate string `json:"key_template"`
+	// Channel 发布状态变化的pub/sub频道，为空表示不发布
+	Channel string `json:"channel"`
+}
+
+// HistoryConfig 在本地按小时汇总保留每个系统的负载历史，用于容量预测
+type HistoryConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"interval_seconds"` // 采样间隔
+	RetentionDays   int  `json:"retention_days"`   // 保留天数，超过后由Badger自动删除
+}
+
+// MaxForecastDays 预测天数的上限
+const MaxForecastDays = 180
+
+// ForecastConfig 根据负载历史预测在线人数和带宽何时达到阈值
+type ForecastConfig struct {
+	HorizonDays int `json:"horizon_days"` // 未指定 days 参数时预测的天数
+	// MinHistoryHours 有数据的小时数少于该值时不预测
+	MinHistoryHours int `json:"min_history_hours"`
+}
+
+// AnomalyConfig 按每周同一时段的负载历史建立基线，检测偏离基线的CPU、内存、带宽和在线人数
+type AnomalyConfig struct {
+	Enabled bool   `json:"enabled"`
+	Method  string `json:"method"` // mad（中位数绝对偏差）或 zscore（平均值和标准差）
+	// Threshold 偏离基线的分数（偏差除以基线标准差的绝对值）达到该值时判定为异常
+	Threshold float64 `json:"threshold"`
+	// MinSamples 基线的小时数少于该值时不判定
+	MinSamples int `json:"min_samples"`
+}
+
+// ActuatorConfig 根据节点负载状态通过v2board/Xboard管理接口自动隐藏节点或调整倍率
+type ActuatorConfig struct {
+	Enabled bool `json:"enabled"`
+	// DryRun 只记录将要执行的操作，不修改面板
+	DryRun     bool   `json:"dry_run"`
+	Flavor     string `json:"flavor"`      // v2board 或 xboard
+	BaseURL    string `json:"base_url"`    // 面板地址
+	SecurePath string `json:"secure_path"` // 管理后台路径
+	// Token v2board为管理员登录返回的 auth_data，Xboard为管理员的API token
+	Token string `json:"token"`
+	// Action 高负载时的操作：hide（隐藏节点）或 rate（把倍率调整为 high_rate）
+	Action   string  `json:"action"`
+	HighRate float64 `json:"high_rate"`
+
+	IntervalSeconds int `json:"interval_seconds"` // 检查间隔
+	TimeoutSeconds  int `json:"timeout_seconds"`  // 单次请求超时
+	// MaxActionsPerHour 每小时最多隐藏或调整多少次节点，恢复节点不受限制
+	MaxActionsPerHour int `json:"max_actions_per_hour"`
+	// NodeCooldownSeconds 同一节点两次操作之间的最短间隔，避免负载在阈值附近波动时反复切换
+	NodeCooldownSeconds int `json:"node_cooldown_seconds"`
+}
+
+// ThresholdConfig 默认负载阈值，系统没有单独配置时使用
+type ThresholdConfig struct {
+	CPUAlertLimit    float64 `json:"cpu_alert_limit"`    // CPU告警阈值（%）
+	MemAlertLimit    float64 `json:"mem_alert_limit"`    // 内存告警阈值（%）
+	NetUpAlert       float64 `json:"net_up_alert"`       // 上行告警阈值（历史极限值的百分比）
+	NetDownAlert     float64 `json:"net_down_alert"`     // 下行告警阈值（历史极限值的百分比）
+	DiskAlertLimit   float64 `json:"disk_alert_limit"`   // 根分区使用率告警阈值（%），0表示不检查
+	SwapAlertLimit   float64 `json:"swap_alert_limit"`   // 交换分区使用率告警阈值（%），0表示不检查
+	OnlineUsersLimit int     `json:"online_users_limit"` // 在线人数告警阈值，0表示不检查
+}
+
+// ReloadConfig 配置热加载
+type ReloadConfig struct {
+	// WatchIntervalSeconds 检查配置文件变化的间隔，0表示不监听文件（仍可通过SIGHUP或接口重载）
+	WatchIntervalSeconds int `json:"watch_interval_seconds"`
+}
+
+// AuthConfig HTTP接口认证配置
+type AuthConfig struct {
+	// Enabled 是否启用认证，关闭后所有接口匿名可访问（仅用于内网调试）
+	Enabled bool `json:"enabled"`
+	// SessionTTLMinutes 登录会话有效期
+	SessionTTLMinutes int `json:"session_ttl_minutes"`
+	// CookieSecure 会话cookie只通过HTTPS发送，前面有TLS反向代理时应开启
+	CookieSecure bool `json:"cookie_secure"`
+	// AdminUsername/AdminPassword 没有任何用户时创建的初始管理员，
+	// 未配置密码时生成随机密码并打印到标准错误输出
+	AdminUsername string `json:"admin_username"`
+	AdminPassword string `json:"admin_password"`
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	// Exporter 导出方式：none（默认，不记录span）、otlp（OTLP/HTTP）或 stdout（本地调试）
+	Exporter string `json:"exporter"`
+	// Endpoint OTLP/HTTP地址，如 http://otel-collector:4318；为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string `json:"endpoint"`
+	// ServiceName 上报的 service.name
+	ServiceName string `json:"service_name"`
+	// SampleRatio 没有上游采样决定时的采样比例，0-1
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	// Level 日志级别：debug、info、warn、error，可以热加载
+	Level string `json:"level"`
+	// Format 输出格式：json 或 text
+	Format string `json:"format"`
+}
+
+// Load 加载并校验配置。配置文件路径取自环境变量 CONFIG_FILE，为空时只使用默认值和环境变量
+func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile 加载并校验配置，优先级：环境变量 > 配置文件 > 默认值
+func LoadFile(path string) (*Config, error) {
+	cfg, err := Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Resolve 合并默认值、配置文件和环境变量，但不做校验（供 --print-config 使用）
+func Resolve(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.PocketBase.loadSecrets(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Redis.loadSecrets(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Auth.loadSecrets(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Actuator.loadSecrets(); err != nil {
+		return nil, err
+	}
+	cfg.plaintextSecrets = cfg.findPlaintextSecrets()
+	return cfg, nil
+}
+
+// findPlaintextSecrets 返回已设置但不是通过 *_FILE 读取的凭据。
+// *_FILE 优先于配置文件和环境变量，设置了 *_FILE 的凭据一定来自文件
+func (c *Config) findPlaintextSecrets() []secretSource {
+	secrets := []struct {
+		secretSource
+		value string
+	}{
+		{secretSource{"pocketbase.password", "POCKETBASE_PASSWORD"}, c.PocketBase.Password},
+		{secretSource{"pocketbase.token", "POCKETBASE_TOKEN"}, c.PocketBase.Token},
+		{secretSource{"redis.password", "REDIS_PASSWORD"}, c.Redis.Password},
+		{secretSource{"actuator.token", "ACTUATOR_TOKEN"}, c.Actuator.Token},
+	}
+	var found []secretSource
+	for _, secret := range secrets {
+		if secret.value != "" && os.Getenv(secret.env+"_FILE") == "" {
+			found = append(found, secret.secretSource)
+		}
+	}
+	return found
+}
+
+// Default 返回默认配置，不包含任何特定部署的地址和凭据
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", ""),
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port: "8080",
 		},
 		Database: DatabaseConfig{
-			Path: getEnv("DATABASE_PATH", "badger_data"),
+			Path: "badger_data",
 		},
 		CORS: CORSConfig{
-			AllowOrigins: []string{"*"},
-			AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders: []string{"*"},
+			// 默认只允许同源访问，前后端分开部署时再配置允许的来源
+			AllowOrigins: []string{},
+			AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "If-Match"},
 		},
 		PocketBase: PocketBaseConfig{
-			BaseURL:  getEnv("POCKETBASE_URL", "https://bz.baidua.top"),
-			Email:    getEnv("POCKETBASE_EMAIL", ""),
-			Password: getEnv("POCKETBASE_PASSWORD", ""),
+			AuthCollection: "users",
+
+			TimeoutSeconds:         30,
+			RetryAttempts:          3,
+			RetryBaseDelayMs:       200,
+			RetryMaxDelayMs:        2000,
+			BreakerThreshold:       5,
+			BreakerCooldownSeconds: 30,
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "192.168.0.32"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			DB:       getEnvInt("REDIS_DB", 0),
-			Password: getEnv("REDIS_PASSWORD", ""),
+			Host: "localhost",
+			Port: "6379",
+		},
+		Publish: PublishConfig{
+			IntervalSeconds: 30,
+			TTLSeconds:      90,
+			KeyTemplate:     "beszel:node_load:{type}:{id}",
+			Channel:         "beszel:node_load:events",
+		},
+		Actuator: ActuatorConfig{
+			DryRun:              true,
+			Flavor:              "v2board",
+			Action:              "hide",
+			IntervalSeconds:     60,
+			TimeoutSeconds:      10,
+			MaxActionsPerHour:   20,
+			NodeCooldownSeconds: 600,
+		},
+		History: HistoryConfig{
+			Enabled:         true,
+			IntervalSeconds: 300,
+			RetentionDays:   56,
+		},
+		Forecast: ForecastConfig{
+			HorizonDays:     30,
+			MinHistoryHours: 48,
+		},
+		Anomaly: AnomalyConfig{
+			Enabled:    true,
+			Method:     "mad",
+			Threshold:  3.5,
+			MinSamples: 6,
+		},
+		Thresholds: ThresholdConfig{
+			CPUAlertLimit:  90,
+			MemAlertLimit:  90,
+			NetUpAlert:     80,
+			NetDownAlert:   80,
+			DiskAlertLimit: 90,
+		},
+		Reload: ReloadConfig{
+			WatchIntervalSeconds: 5,
 		},
+		Auth: AuthConfig{
+			Enabled:           true,
+			SessionTTLMinutes: 720,
+			AdminUsername:     "admin",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "beszel-sideloading",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// applyEnv 用环境变量覆盖配置，未设置的环境变量不影响已有值
+func (c *Config) applyEnv() error {
+	setEnvString(&c.Server.Host, "SERVER_HOST")
+	setEnvString(&c.Server.Port, "SERVER_PORT")
+	setEnvList(&c.Server.TrustedProxies, "SERVER_TRUSTED_PROXIES")
+	setEnvString(&c.Database.Path, "DATABASE_PATH")
+
+	setEnvList(&c.CORS.AllowOrigins, "CORS_ALLOW_ORIGINS")
+	setEnvList(&c.CORS.AllowMethods, "CORS_ALLOW_METHODS")
+	setEnvList(&c.CORS.AllowHeaders, "CORS_ALLOW_HEADERS")
+
+	setEnvString(&c.PocketBase.BaseURL, "POCKETBASE_URL")
+	setEnvString(&c.PocketBase.AuthCollection, "POCKETBASE_AUTH_COLLECTION")
+
+	setEnvString(&c.Redis.Host, "REDIS_HOST")
+	setEnvString(&c.Redis.Port, "REDIS_PORT")
+
+	setEnvString(&c.Publish.KeyTemplate, "PUBLISH_KEY_TEMPLATE")
+	setEnvString(&c.Publish.Channel, "PUBLISH_CHANNEL")
+
+	setEnvString(&c.Actuator.Flavor, "ACTUATOR_FLAVOR")
+	setEnvString(&c.Actuator.BaseURL, "ACTUATOR_BASE_URL")
+	setEnvString(&c.Actuator.SecurePath, "ACTUATOR_SECURE_PATH")
+	setEnvString(&c.Actuator.Action, "ACTUATOR_ACTION")
+
+	setEnvString(&c.Anomaly.Method, "ANOMALY_METHOD")
+
+	setEnvString(&c.Auth.AdminUsername, "AUTH_ADMIN_USERNAME")
+
+	setEnvString(&c.Tracing.Exporter, "TRACING_EXPORTER")
+	setEnvString(&c.Tracing.Endpoint, "TRACING_OTLP_ENDPOINT")
+	setEnvString(&c.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+
+	setEnvString(&c.Log.Level, "LOG_LEVEL")
+	setEnvString(&c.Log.Format, "LOG_FORMAT")
+
+	return errors.Join(
+		setEnvBool(&c.PocketBase.StrictSecrets, "POCKETBASE_STRICT_SECRETS"),
+		setEnvInt(&c.PocketBase.TimeoutSeconds, "POCKETBASE_TIMEOUT_SECONDS"),
+		setEnvInt(&c.PocketBase.RetryAttempts, "POCKETBASE_RETRY_ATTEMPTS"),
+		setEnvInt(&c.PocketBase.RetryBaseDelayMs, "POCKETBASE_RETRY_BASE_DELAY_MS"),
+		setEnvInt(&c.PocketBase.RetryMaxDelayMs, "POCKETBASE_RETRY_MAX_DELAY_MS"),
+		setEnvInt(&c.PocketBase.BreakerThreshold, "POCKETBASE_BREAKER_THRESHOLD"),
+		setEnvInt(&c.PocketBase.BreakerCooldownSeconds, "POCKETBASE_BREAKER_COOLDOWN_SECONDS"),
+		setEnvInt(&c.Redis.DB, "REDIS_DB"),
+		setEnvBool(&c.Publish.Enabled, "PUBLISH_ENABLED"),
+		setEnvInt(&c.Publish.IntervalSeconds, "PUBLISH_INTERVAL_SECONDS"),
+		setEnvInt(&c.Publish.TTLSeconds, "PUBLISH_TTL_SECONDS"),
+		setEnvBool(&c.Actuator.Enabled, "ACTUATOR_ENABLED"),
+		setEnvBool(&c.Actuator.DryRun, "ACTUATOR_DRY_RUN"),
+		setEnvFloat(&c.Actuator.HighRate, "ACTUATOR_HIGH_RATE"),
+		setEnvInt(&c.Actuator.IntervalSeconds, "ACTUATOR_INTERVAL_SECONDS"),
+		setEnvInt(&c.Actuator.TimeoutSeconds, "ACTUATOR_TIMEOUT_SECONDS"),
+		setEnvInt(&c.Actuator.MaxActionsPerHour, "ACTUATOR_MAX_ACTIONS_PER_HOUR"),
+		setEnvInt(&c.Actuator.NodeCooldownSeconds, "ACTUATOR_NODE_COOLDOWN_SECONDS"),
+		setEnvBool(&c.History.Enabled, "HISTORY_ENABLED"),
+		setEnvInt(&c.History.IntervalSeconds, "HISTORY_INTERVAL_SECONDS"),
+		setEnvInt(&c.History.RetentionDays, "HISTORY_RETENTION_DAYS"),
+		setEnvInt(&c.Forecast.HorizonDays, "FORECAST_HORIZON_DAYS"),
+		setEnvInt(&c.Forecast.MinHistoryHours, "FORECAST_MIN_HISTORY_HOURS"),
+		setEnvBool(&c.Anomaly.Enabled, "ANOMALY_ENABLED"),
+		setEnvFloat(&c.Anomaly.Threshold, "ANOMALY_THRESHOLD"),
+		setEnvInt(&c.Anomaly.MinSamples, "ANOMALY_MIN_SAMPLES"),
+		setEnvInt(&c.Reload.WatchIntervalSeconds, "CONFIG_WATCH_INTERVAL_SECONDS"),
+		setEnvBool(&c.Auth.Enabled, "AUTH_ENABLED"),
+		setEnvInt(&c.Auth.SessionTTLMinutes, "AUTH_SESSION_TTL_MINUTES"),
+		setEnvBool(&c.Auth.CookieSecure, "AUTH_COOKIE_SECURE"),
+		setEnvFloat(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO"),
+	)
+}
+
+// loadSecrets 加载PocketBase认证信息，支持 KEY_FILE 形式的文件/Docker secret
+func (p *PocketBaseConfig) loadSecrets() error {
+	email, err := getSecret("POCKETBASE_EMAIL")
+	if err != nil {
+		return err
+	}
+	if email != "" {
+		p.Email = email
+	}
+
+	// 严格模式下密码和token的来源在 Validate 中检查
+	secrets := []struct {
+		key    string
+		target *string
+	}{
+		{"POCKETBASE_PASSWORD", &p.Password},
+		{"POCKETBASE_TOKEN", &p.Token},
 	}
+	for _, secret := range secrets {
+		value, err := getSecret(secret.key)
+		if err != nil {
+			return err
+		}
+		if value != "" {
+			*secret.target = value
+		}
+	}
+
+	return nil
+}
+
+// loadSecrets 加载Redis密码，同样支持 REDIS_PASSWORD_FILE
+func (r *RedisConfig) loadSecrets() error {
+	password, err := getSecret("REDIS_PASSWORD")
+	if err != nil {
+		return err
+	}
+	if password != "" {
+		r.Password = password
+	}
+	return nil
+}
+
+// loadSecrets 加载初始管理员密码，支持 AUTH_ADMIN_PASSWORD_FILE
+func (a *AuthConfig) loadSecrets() error {
+	password, err := getSecret("AUTH_ADMIN_PASSWORD")
+	if err != nil {
+		return err
+	}
+	if password != "" {
+		a.AdminPassword = password
+	}
+	return nil
+}
+
+// loadSecrets 加载面板管理员token，支持 ACTUATOR_TOKEN_FILE
+func (a *ActuatorConfig) loadSecrets() error {
+	token, err := getSecret("ACTUATOR_TOKEN")
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		a.Token = token
+	}
+	return nil
 }
 
 // GetAddress 获取服务器地址
@@ -81,20 +492,79 @@ func (c *Config) GetAddress() string {
 	return c.Server.Host + ":" + c.Server.Port
 }
 
-// getEnv 获取环境变量，如果不存在则返回默认值
-func getEnv(key, defaultValue string) string {
+// setEnvString 环境变量非空时覆盖目标值
+func setEnvString(target *string, key string) {
 	if value := os.Getenv(key); value != "" {
-		return value
+		*target = value
 	}
-	return defaultValue
 }
 
-// getEnvInt 获取整数环境变量，如果不存在或解析失败则返回默认值
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// setEnvList 环境变量非空时按逗号拆分覆盖目标列表
+func setEnvList(target *[]string, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
-	return defaultValue
+	*target = items
+}
+
+// getSecret 读取敏感配置：优先读取 KEY_FILE 指向的文件（如 /run/secrets/xxx），
+// 否则读取环境变量 KEY。
+func getSecret(key string) (string, error) {
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取 %s_FILE 失败: %w", key, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return os.Getenv(key), nil
+}
+
+// setEnvBool 环境变量非空时解析为布尔值覆盖目标值
+func setEnvBool(target *bool, key string) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s=%q 不是合法的布尔值", key, value)
+	}
+	*target = boolValue
+	return nil
+}
+
+// setEnvInt 环境变量非空时解析为整数覆盖目标值
+func setEnvInt(target *int, key string) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s=%q 不是合法的整数", key, value)
+	}
+	*target = intValue
+	return nil
+}
+
+// setEnvFloat 环境变量非空时解析为浮点数覆盖目标值
+func setEnvFloat(target *float64, key string) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s=%q 不是合法的数字", key, value)
+	}
+	*target = floatValue
+	return nil
 }
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSecret 在临时目录写入一个secret文件并返回路径
+func writeSecret(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSecretsFromFiles(t *testing.T) {
+	t.Setenv("POCKETBASE_URL", "https://hub.example.com")
+	t.Setenv("POCKETBASE_EMAIL_FILE", writeSecret(t, "email", "ops@example.com\n"))
+	t.Setenv("POCKETBASE_PASSWORD_FILE", writeSecret(t, "password", "  s3cret \n"))
+	t.Setenv("POCKETBASE_TOKEN_FILE", writeSecret(t, "token", "header.payload.sig\n"))
+	t.Setenv("POCKETBASE_STRICT_SECRETS", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.PocketBase.Email != "ops@example.com" {
+		t.Errorf("unexpected email %q", cfg.PocketBase.Email)
+	}
+	if cfg.PocketBase.Password != "s3cret" {
+		t.Errorf("expected trimmed password, got %q", cfg.PocketBase.Password)
+	}
+	if cfg.PocketBase.Token != "header.payload.sig" {
+		t.Errorf("unexpected token %q", cfg.PocketBase.Token)
+	}
+}
+
+func TestFileTakesPrecedenceOverEnv(t *testing.T) {
+	t.Setenv("POCKETBASE_URL", "https://hub.example.com")
+	t.Setenv("POCKETBASE_EMAIL", "ops@example.com")
+	t.Setenv("POCKETBASE_PASSWORD", "from-env")
+	t.Setenv("POCKETBASE_PASSWORD_FILE", writeSecret(t, "password", "from-file"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.PocketBase.Password != "from-file" {
+		t.Errorf("expected password from file, got %q", cfg.PocketBase.Password)
+	}
+}
+
+func TestStrictModeRejectsPlaintextSecrets(t *testing.T) {
+	for _, key := range []string{"POCKETBASE_PASSWORD", "POCKETBASE_TOKEN", "REDIS_PASSWORD", "ACTUATOR_TOKEN"} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv("POCKETBASE_STRICT_SECRETS", "true")
+			t.Setenv(key, "plaintext")
+
+			_, err := Load()
+			if err == nil {
+				t.Fatal("expected strict mode to reject plaintext secret")
+			}
+			if !strings.Contains(err.Error(), key+"_FILE") {
+				t.Errorf("error should point to %s_FILE, got: %v", key, err)
+			}
+		})
+	}
+}
+
+func TestMissingSecretFile(t *testing.T) {
+	t.Setenv("POCKETBASE_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for unreadable secret file")
+	}
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// redactedValue 打印配置时替换敏感字段的占位符
+const redactedValue = "******"
+
+// Validate 校验配置，返回所有问题而不是只返回第一个
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if err := validatePort(c.Server.Port); err != nil {
+		add("server.port: %v", err)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if err := validateIPOrCIDR(proxy); err != nil {
+			add("server.trusted_proxies: %q %v", proxy, err)
+		}
+	}
+	if c.Database.Path == "" {
+		add("database.path: 不能为空")
+	}
+
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+		if err := validateURL(origin); err != nil {
+			add("cors.allow_origins: %q %v", origin, err)
+		}
+	}
+	if len(c.CORS.AllowMethods) == 0 {
+		add("cors.allow_methods: 不能为空")
+	}
+
+	pb := &c.PocketBase
+	if pb.BaseURL == "" {
+		add("pocketbase.base_url: 未配置（POCKETBASE_URL）")
+	} else if err := validateURL(pb.BaseURL); err != nil {
+		add("pocketbase.base_url: %q %v", pb.BaseURL, err)
+	}
+	if pb.Token == "" && (pb.Email == "" || pb.Password == "") {
+		add("pocketbase: 缺少认证信息，请配置 token（POCKETBASE_TOKEN）或 email + password（POCKETBASE_EMAIL / POCKETBASE_PASSWORD）")
+	}
+	if pb.StrictSecrets {
+		for _, secret := range c.plaintextSecrets {
+			add("%s: 严格模式下不允许明文凭据（配置文件或环境变量 %s），请改用 %s_FILE", secret.field, secret.env, secret.env)
+		}
+	}
+	if pb.AuthCollection == "" {
+		add("pocketbase.auth_collection: 不能为空")
+	}
+	if pb.TimeoutSeconds <= 0 {
+		add("pocketbase.timeout_seconds: 必须大于0")
+	}
+	if pb.RetryAttempts < 1 {
+		add("pocketbase.retry_attempts: 至少为1")
+	}
+	if pb.RetryBaseDelayMs < 0 || pb.RetryMaxDelayMs < pb.RetryBaseDelayMs {
+		add("pocketbase.retry_base_delay_ms/retry_max_delay_ms: 必须满足 0 <= base <= max")
+	}
+	if pb.BreakerThreshold < 0 {
+		add("pocketbase.breaker_threshold: 不能为负数")
+	}
+	if pb.BreakerThreshold > 0 && pb.BreakerCooldownSeconds <= 0 {
+		add("pocketbase.breaker_cooldown_seconds: 启用熔断时必须大于0")
+	}
+
+	if c.Redis.Host != "" {
+		if err := validatePort(c.Redis.Port); err != nil {
+			add("redis.port: %v", err)
+		}
+	}
+	if c.Redis.DB < 0 {
+		add("redis.db: 不能为负数")
+	}
+
+	if pub := &c.Publish; pub.Enabled {
+		if c.Redis.Host == "" {
+			add("publish.enabled: 需要配置Redis")
+		}
+		if pub.IntervalSeconds <= 0 {
+			add("publish.interval_seconds: 必须大于0")
+		}
+		if pub.TTLSeconds <= pub.IntervalSeconds {
+			add("publish.ttl_seconds: 必须大于 interval_seconds，否则两次发布之间状态会过期")
+		}
+		if !strings.Contains(pub.KeyTemplate, "{type}") || !strings.Contains(pub.KeyTemplate, "{id}") {
+			add("publish.key_template: %q 必须包含 {type} 和 {id}", pub.KeyTemplate)
+		}
+	}
+
+	if act := &c.Actuator; act.Enabled {
+		if act.Flavor != "v2board" && act.Flavor != "xboard" {
+			add("actuator.flavor: %q 必须是 v2board 或 xboard", act.Flavor)
+		}
+		if act.BaseURL == "" {
+			add("actuator.base_url: 未配置（ACTUATOR_BASE_URL）")
+		} else if err := validateURL(act.BaseURL); err != nil {
+			add("actuator.base_url: %q %v", act.BaseURL, err)
+		}
+		if strings.Trim(act.SecurePath, "/") == "" {
+			add("actuator.secure_path: 不能为空")
+		}
+		if act.Token == "" {
+			add("actuator.token: 未配置（ACTUATOR_TOKEN）")
+		}
+		switch act.Action {
+		case "hide":
+		case "rate":
+			if act.HighRate <= 0 {
+				add("actuator.high_rate: action 为 rate 时必须大于0")
+			}
+		default:
+			add("actuator.action: %q 必须是 hide 或 rate", act.Action)
+		}
+		if act.IntervalSeconds <= 0 {
+			add("actuator.interval_seconds: 必须大于0")
+		}
+		if act.TimeoutSeconds <= 0 {
+			add("actuator.timeout_seconds: 必须大于0")
+		}
+		if act.MaxActionsPerHour <= 0 {
+			add("actuator.max_actions_per_hour: 必须大于0")
+		}
+		if act.NodeCooldownSeconds < 0 {
+			add("actuator.node_cooldown_seconds: 不能为负数")
+		}
+	}
+
+	if c.History.Enabled {
+		if c.History.IntervalSeconds <= 0 || c.History.IntervalSeconds > 3600 {
+			add("history.interval_seconds: 必须在1-3600之间")
+		}
+		if c.History.RetentionDays < 1 {
+			add("history.retention_days: 必须大于0")
+		}
+	}
+	if c.Forecast.HorizonDays < 1 || c.Forecast.HorizonDays > MaxForecastDays {
+		add("forecast.horizon_days: 必须在1-%d之间", MaxForecastDays)
+	}
+	if c.Forecast.MinHistoryHours < 24 {
+		add("forecast.min_history_hours: 不能小于24")
+	}
+
+	if an := &c.Anomaly; an.Enabled {
+		if !c.History.Enabled {
+			add("anomaly.enabled: 需要开启 history.enabled 记录负载历史")
+		}
+		if an.Method != "mad" && an.Method != "zscore" {
+			add("anomaly.method: %q 必须是 mad 或 zscore", an.Method)
+		}
+		if an.Threshold <= 0 {
+			add("anomaly.threshold: 必须大于0")
+		}
+		if an.MinSamples < 3 {
+			add("anomaly.min_samples: 不能小于3")
+		}
+	}
+
+	t := &c.Thresholds
+	percents := []struct {
+		name  string
+		value float64
+	}{
+		{"cpu_alert_limit", t.CPUAlertLimit},
+		{"mem_alert_limit", t.MemAlertLimit},
+		{"net_up_alert", t.NetUpAlert},
+		{"net_down_alert", t.NetDownAlert},
+		{"disk_alert_limit", t.DiskAlertLimit},
+		{"swap_alert_limit", t.SwapAlertLimit},
+	}
+	for _, p := range percents {
+		if p.value < 0 || p.value > 100 {
+			add("thresholds.%s: 必须在0-100之间", p.name)
+		}
+	}
+	if t.OnlineUsersLimit < 0 {
+		add("thresholds.online_users_limit: 不能为负数")
+	}
+	if c.Reload.WatchIntervalSeconds < 0 {
+		add("reload.watch_interval_seconds: 不能为负数")
+	}
+
+	if c.Auth.Enabled {
+		if c.Auth.SessionTTLMinutes <= 0 {
+			add("auth.session_ttl_minutes: 必须大于0")
+		}
+		if c.Auth.AdminUsername == "" {
+			add("auth.admin_username: 不能为空")
+		}
+		if c.Auth.AdminPassword != "" && len(c.Auth.AdminPassword) < 8 {
+			add("auth.admin_password: 至少8个字符")
+		}
+	}
+
+	tr := &c.Tracing
+	switch tr.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if tr.Endpoint != "" {
+			if err := validateURL(tr.Endpoint); err != nil {
+				add("tracing.endpoint: %q %v", tr.Endpoint, err)
+			}
+		}
+	default:
+		add("tracing.exporter: %q 必须是 none、otlp 或 stdout", tr.Exporter)
+	}
+	if tr.Exporter != "none" && tr.ServiceName == "" {
+		add("tracing.service_name: 不能为空")
+	}
+	if tr.SampleRatio < 0 || tr.SampleRatio > 1 {
+		add("tracing.sample_ratio: 必须在0-1之间")
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		add("log.level: %q 必须是 debug、info、warn 或 error", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		add("log.format: %q 必须是 json 或 text", c.Log.Format)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePort 检查端口号是否在 1-65535 之间
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%q 不是合法的端口号", port)
+	}
+	return nil
+}
+
+// validateIPOrCIDR 检查是否为IP地址或CIDR
+func validateIPOrCIDR(value string) error {
+	if net.ParseIP(value) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return fmt.Errorf("不是合法的IP地址或CIDR")
+	}
+	return nil
+}
+
+// validateURL 检查是否为带主机名的 http/https 地址
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("不是合法的URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("必须以 http:// 或 https:// 开头")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("缺少主机名")
+	}
+	return nil
+}
+
+// Redacted 返回隐藏了密码、token等敏感信息的配置副本，用于打印
+func (c *Config) Redacted() *Config {
+	copied := *c
+	copied.CORS.AllowOrigins = append([]string(nil), c.CORS.AllowOrigins...)
+	copied.CORS.AllowMethods = append([]string(nil), c.CORS.AllowMethods...)
+	copied.CORS.AllowHeaders = append([]string(nil), c.CORS.AllowHeaders...)
+
+	for _, secret := range []*string{&copied.PocketBase.Password, &copied.PocketBase.Token, &copied.Redis.Password, &copied.Auth.AdminPassword, &copied.Actuator.Token} {
+		if *secret != "" {
+			*secret = redactedValue
+		}
+	}
+	// URL中可能带有basic auth信息
+	if u, err := url.Parse(copied.PocketBase.BaseURL); err == nil && u.User != nil {
+		u.User = url.User(redactedValue)
+		copied.PocketBase.BaseURL = u.String()
+	}
+	return &copied
+}
@@ -1,10 +1,13 @@
 package database
 
 import (
+	"backend/internal/logging"
 	"backend/pkg/models"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	badger "github.com/dgraph-io/badger/v4"
@@ -25,7 +28,7 @@ func NewBadgerStorage(dbPath string) (*BadgerStorage, error) {
 		return nil, fmt.Errorf("failed to open badger db: %w", err)
 	}
 
-	log.Println("BadgerDB 初始化成功")
+	slog.Info("BadgerDB 初始化成功", "op", "badger.open", "path", dbPath)
 	return &BadgerStorage{db: db}, nil
 }
 
@@ -34,11 +37,29 @@ func (s *BadgerStorage) Close() error {
 	return s.db.Close()
 }
 
+// Ping 检查数据库未关闭且可以完成一次读事务
+func (s *BadgerStorage) Ping() error {
+	if s.db.IsClosed() {
+		return errors.New("badger db is closed")
+	}
+	return s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte("health:ping"))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
 // 生成键前缀
 func (s *BadgerStorage) thresholdKey(systemID string) []byte {
 	return []byte(fmt.Sprintf("threshold:%s", systemID))
 }
 
+func (s *BadgerStorage) thresholdProfileKey(id string) []byte {
+	return []byte(fmt.Sprintf("thresholdprofile:%s", id))
+}
+
 func (s *BadgerStorage) aliasKey(systemID string) []byte {
 	return []byte(fmt.Sprintf("alias:%s", systemID))
 }
@@ -47,44 +68,65 @@ func (s *BadgerStorage) aliasPrefix() []byte {
 	return []byte("alias:")
 }
 
-// CreateOrUpdateThreshold 创建或更新系统阈值
-func (s *BadgerStorage) CreateOrUpdateThreshold(threshold *models.SystemThreshold) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		// 如果是更新，先获取旧数据保留ID和创建时间
-		key := s.thresholdKey(threshold.SystemID)
-		item, err := txn.Get(key)
-		if err == nil {
-			var existing models.SystemThreshold
-			err = item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &existing)
-			})
-			if err == nil {
-				if threshold.ID == 0 {
-					threshold.ID = existing.ID
-				}
-				if threshold.CreatedAt.IsZero() {
-					threshold.CreatedAt = existing.CreatedAt
-				}
-			}
-		} else {
-			// 新建记录，生成ID和创建时间
-			if threshold.ID == 0 {
-				threshold.ID = uint(time.Now().UnixNano())
-			}
-			if threshold.CreatedAt.IsZero() {
-				threshold.CreatedAt = time.Now()
-			}
-		}
+// deletedVersionKey 已删除记录的最后版本，resource 为 threshold 或 alias
+func (s *BadgerStorage) deletedVersionKey(resource, id string) []byte {
+	return []byte(fmt.Sprintf("deletedversion:%s:%s", resource, id))
+}
 
-		threshold.UpdatedAt = time.Now()
+func (s *BadgerStorage) auditKey(entry *models.AuditEntry) []byte {
+	return []byte(fmt.Sprintf("audit:%s", entry.ID))
+}
 
-		data, err := json.Marshal(threshold)
-		if err != nil {
-			return err
-		}
+func (s *BadgerStorage) auditPrefix() []byte {
+	return []byte("audit:")
+}
 
-		return txn.Set(key, data)
-	})
+func (s *BadgerStorage) userKey(username string) []byte {
+	return []byte(fmt.Sprintf("user:%s", username))
+}
+
+func (s *BadgerStorage) sessionKey(tokenHash string) []byte {
+	return []byte(fmt.Sprintf("session:%s", tokenHash))
+}
+
+func (s *BadgerStorage) apiKeyKey(id string) []byte {
+	return []byte(fmt.Sprintf("apikey:%s", id))
+}
+
+func (s *BadgerStorage) maintenanceKey(id string) []byte {
+	return []byte(fmt.Sprintf("maintenance:%s", id))
+}
+
+func (s *BadgerStorage) drainKey(id string) []byte {
+	return []byte(fmt.Sprintf("drain:%s", id))
+}
+
+func (s *BadgerStorage) actuationKey(id string) []byte {
+	return []byte(fmt.Sprintf("actuation:%s", id))
+}
+
+func (s *BadgerStorage) historyKey(systemID string, hour time.Time) []byte {
+	return []byte(fmt.Sprintf("history:%s:%012d", systemID, hour.Unix()))
+}
+
+func (s *BadgerStorage) historyPrefix(systemID string) []byte {
+	return []byte("history:" + systemID + ":")
+}
+
+func (s *BadgerStorage) nodeTagKey(systemID, tagType string, tagID int) []byte {
+	return []byte(fmt.Sprintf("nodetag:%s:%s:%d", systemID, tagType, tagID))
+}
+
+func (s *BadgerStorage) nodeTagPrefix(systemID string) []byte {
+	if systemID == "" {
+		return []byte("nodetag:")
+	}
+	return []byte(fmt.Sprintf("nodetag:%s:", systemID))
+}
+
+// CreateOrUpdateThreshold 创建或更新系统阈值
+func (s *BadgerStorage) CreateOrUpdateThreshold(threshold *models.SystemThreshold) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.CreateOrUpdateThreshold(threshold) })
 }
 
 // GetThreshold 获取系统阈值
@@ -127,7 +169,7 @@ func (s *BadgerStorage) ListThresholds() ([]*models.SystemThreshold, error) {
 				return json.Unmarshal(val, &threshold)
 			})
 			if err != nil {
-				log.Printf("Failed to unmarshal threshold: %v", err)
+				slog.Error("Failed to unmarshal threshold", "op", "badger.list", "key", string(item.Key()), logging.Err(err))
 				continue
 			}
 
@@ -141,50 +183,71 @@ func (s *BadgerStorage) ListThresholds() ([]*models.SystemThreshold, error) {
 
 // DeleteThreshold 删除系统阈值
 func (s *BadgerStorage) DeleteThreshold(systemID string) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete(s.thresholdKey(systemID))
-	})
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.DeleteThreshold(systemID) })
 }
 
-// SetSystemAlias 设置系统别名（创建或更新）
-func (s *BadgerStorage) SetSystemAlias(alias *models.SystemAlias) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		// 先检查是否已存在
-		key := s.aliasKey(alias.SystemID)
-		item, err := txn.Get(key)
-		if err == nil {
-			// 已存在，保留ID和创建时间
-			var existing models.SystemAlias
-			err = item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &existing)
-			})
-			if err == nil {
-				if alias.ID == 0 {
-					alias.ID = existing.ID
-				}
-				if alias.CreatedAt.IsZero() {
-					alias.CreatedAt = existing.CreatedAt
-				}
-			}
-		} else {
-			// 新建记录
-			if alias.ID == 0 {
-				alias.ID = uint(time.Now().UnixNano())
-			}
-			if alias.CreatedAt.IsZero() {
-				alias.CreatedAt = time.Now()
-			}
-		}
+// CreateOrUpdateThresholdProfile 创建或更新阈值模板
+func (s *BadgerStorage) CreateOrUpdateThresholdProfile(profile *models.ThresholdProfile) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.CreateOrUpdateThresholdProfile(profile) })
+}
 
-		alias.UpdatedAt = time.Now()
+// GetThresholdProfile 获取阈值模板，不存在时返回nil
+func (s *BadgerStorage) GetThresholdProfile(id string) (*models.ThresholdProfile, error) {
+	var profile models.ThresholdProfile
 
-		data, err := json.Marshal(alias)
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.thresholdProfileKey(id))
 		if err != nil {
 			return err
 		}
 
-		return txn.Set(key, data)
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &profile)
+		})
+	})
+
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+
+	return &profile, err
+}
+
+// ListThresholdProfiles 列出所有阈值模板
+func (s *BadgerStorage) ListThresholdProfiles() ([]*models.ThresholdProfile, error) {
+	var profiles []*models.ThresholdProfile
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("thresholdprofile:")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var profile models.ThresholdProfile
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &profile)
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal threshold profile", "op", "badger.list", "key", string(it.Item().Key()), logging.Err(err))
+				continue
+			}
+			profiles = append(profiles, &profile)
+		}
+		return nil
 	})
+
+	return profiles, err
+}
+
+// DeleteThresholdProfile 删除阈值模板
+func (s *BadgerStorage) DeleteThresholdProfile(id string) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.DeleteThresholdProfile(id) })
+}
+
+// SetSystemAlias 设置系统别名（创建或更新）
+func (s *BadgerStorage) SetSystemAlias(alias *models.SystemAlias) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.SetSystemAlias(alias) })
 }
 
 // GetSystemAlias 获取系统别名
@@ -227,7 +290,7 @@ func (s *BadgerStorage) GetAllSystemAliases() ([]*models.SystemAlias, error) {
 				return json.Unmarshal(val, &alias)
 			})
 			if err != nil {
-				log.Printf("Failed to unmarshal alias: %v", err)
+				slog.Error("Failed to unmarshal alias", "op", "badger.list", "key", string(item.Key()), logging.Err(err))
 				continue
 			}
 
@@ -241,7 +304,694 @@ func (s *BadgerStorage) GetAllSystemAliases() ([]*models.SystemAlias, error) {
 
 // DeleteSystemAlias 删除系统别名
 func (s *BadgerStorage) DeleteSystemAlias(systemID string) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.DeleteSystemAlias(systemID) })
+}
+
+// CreateNodeTag 创建节点标签（已存在则保留原ID和创建时间）
+func (s *BadgerStorage) CreateNodeTag(tag *models.NodeTag) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.CreateNodeTag(tag) })
+}
+
+// GetNodeTags 获取系统的所有节点标签
+func (s *BadgerStorage) GetNodeTags(systemID string) ([]*models.NodeTag, error) {
+	return s.listNodeTags(s.nodeTagPrefix(systemID), func(*models.NodeTag) bool { return true })
+}
+
+// ListNodeTags 获取所有系统的节点标签
+func (s *BadgerStorage) ListNodeTags() ([]*models.NodeTag, error) {
+	return s.listNodeTags(s.nodeTagPrefix(""), func(*models.NodeTag) bool { return true })
+}
+
+// GetNodeTagsByTypeAndID 根据节点类型和ID查找标签
+func (s *BadgerStorage) GetNodeTagsByTypeAndID(tagType string, tagID int) ([]*models.NodeTag, error) {
+	return s.listNodeTags(s.nodeTagPrefix(""), func(tag *models.NodeTag) bool {
+		return tag.TagType == tagType && tag.TagID == tagID
+	})
+}
+
+// DeleteNodeTag 删除节点标签
+func (s *BadgerStorage) DeleteNodeTag(systemID, tagType string, tagID int) error {
+	return s.UpdateConfig(func(tx ConfigTx) error { return tx.DeleteNodeTag(systemID, tagType, tagID) })
+}
+
+// listNodeTags 按前缀遍历节点标签并用match过滤
+func (s *BadgerStorage) listNodeTags(prefix []byte, match func(*models.NodeTag) bool) ([]*models.NodeTag, error) {
+	var tags []*models.NodeTag
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var tag models.NodeTag
+
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &tag)
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal node tag", "op", "badger.list", "key", string(it.Item().Key()), logging.Err(err))
+				continue
+			}
+
+			if match(&tag) {
+				tags = append(tags, &tag)
+			}
+		}
+		return nil
+	})
+
+	return tags, err
+}
+
+// auditSeq 同一纳秒内写入多条审计记录时区分ID
+var auditSeq atomic.Uint32
+
+// AppendAuditEntry 追加审计记录。ID由时间戳生成，按字典序即按时间排序
+func (s *BadgerStorage) AppendAuditEntry(entry *models.AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.ID == "" {
+		entry.ID = fmt.Sprintf("%020d-%05d", entry.Time.UnixNano(), auditSeq.Add(1)%100000)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(s.auditKey(entry), data)
+	})
+}
+
+// ListAuditEntries 按时间倒序查询审计记录
+func (s *BadgerStorage) ListAuditEntries(query *models.AuditQuery) ([]*models.AuditEntry, error) {
+	var entries []*models.AuditEntry
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = s.auditPrefix()
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// 反向遍历需要从前缀范围的末尾开始
+		for it.Seek(append(s.auditPrefix(), 0xff)); it.Valid(); it.Next() {
+			var entry models.AuditEntry
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal audit entry", "op", "badger.list", "key", string(it.Item().Key()), logging.Err(err))
+				continue
+			}
+
+			if !query.Since.IsZero() && entry.Time.Before(query.Since) {
+				break
+			}
+			if !query.Until.IsZero() && entry.Time.After(query.Until) {
+				continue
+			}
+			if (query.Resource != "" && entry.Resource != query.Resource) ||
+				(query.ResourceID != "" && entry.ResourceID != query.ResourceID) ||
+				(query.Actor != "" && entry.Actor != query.Actor) {
+				continue
+			}
+
+			entries = append(entries, &entry)
+			if query.Limit > 0 && len(entries) >= query.Limit {
+				break
+			}
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// CreateOrUpdateUser 创建或更新用户，保留原创建时间
+func (s *BadgerStorage) CreateOrUpdateUser(user *models.User) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		key := s.userKey(user.Username)
+		if existing, err := getJSON[models.User](txn, key); err == nil && existing != nil && user.CreatedAt.IsZero() {
+			user.CreatedAt = existing.CreatedAt
+		}
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = time.Now()
+		}
+		user.UpdatedAt = time.Now()
+		return setJSON(txn, key, user)
+	})
+}
+
+// GetUser 获取用户，不存在时返回nil
+func (s *BadgerStorage) GetUser(username string) (*models.User, error) {
+	var user *models.User
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		user, err = getJSON[models.User](txn, s.userKey(username))
+		return err
+	})
+	return user, err
+}
+
+// ListUsers 列出所有用户
+func (s *BadgerStorage) ListUsers() ([]*models.User, error) {
+	return listJSON[models.User](s.db, []byte("user:"))
+}
+
+// DeleteUser 删除用户
+func (s *BadgerStorage) DeleteUser(username string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(s.userKey(username))
+	})
+}
+
+// CreateSession 保存登录会话，到期后Badger自动删除
+func (s *BadgerStorage) CreateSession(session *models.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	entry := badger.NewEntry(s.sessionKey(session.TokenHash), data).WithTTL(time.Until(session.ExpiresAt))
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(entry)
+	})
+}
+
+// GetSession 获取登录会话，不存在或已过期时返回nil
+func (s *BadgerStorage) GetSession(tokenHash string) (*models.Session, error) {
+	var session *models.Session
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		session, err = getJSON[models.Session](txn, s.sessionKey(tokenHash))
+		return err
+	})
+	return session, err
+}
+
+// DeleteSession 删除登录会话
+func (s *BadgerStorage) DeleteSession(tokenHash string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(s.sessionKey(tokenHash))
+	})
+}
+
+// DeleteUserSessions 删除用户的所有登录会话
+func (s *BadgerStorage) DeleteUserSessions(username string) error {
+	sessions, err := listJSON[models.Session](s.db, []byte("session:"))
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		for _, session := range sessions {
+			if session.Username != username {
+				continue
+			}
+			if err := txn.Delete(s.sessionKey(session.TokenHash)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateOrUpdateAPIKey 创建或更新API密钥
+func (s *BadgerStorage) CreateOrUpdateAPIKey(key *models.APIKey) error {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return setJSON(txn, s.apiKeyKey(key.ID), key)
+	})
+}
+
+// GetAPIKey 获取API密钥，不存在时返回nil
+func (s *BadgerStorage) GetAPIKey(id string) (*models.APIKey, error) {
+	var key *models.APIKey
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		key, err = getJSON[models.APIKey](txn, s.apiKeyKey(id))
+		return err
+	})
+	return key, err
+}
+
+// ListAPIKeys 列出所有API密钥
+func (s *BadgerStorage) ListAPIKeys() ([]*models.APIKey, error) {
+	return listJSON[models.APIKey](s.db, []byte("apikey:"))
+}
+
+// DeleteAPIKey 删除API密钥
+func (s *BadgerStorage) DeleteAPIKey(id string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(s.apiKeyKey(id))
+	})
+}
+
+// expiredRetention 维护窗口、排空记录过期后保留的时间，之后由Badger自动删除
+const expiredRetention = 7 * 24 * time.Hour
+
+// CreateOrUpdateMaintenance 创建或更新维护窗口，结束后保留 expiredRetention 再自动删除
+func (s *BadgerStorage) CreateOrUpdateMaintenance(window *models.MaintenanceWindow) error {
+	if window.CreatedAt.IsZero() {
+		window.CreatedAt = time.Now()
+	}
+	window.UpdatedAt = time.Now()
+	data, err := json.Marshal(window)
+	if err != nil {
+		return err
+	}
+	entry := badger.NewEntry(s.maintenanceKey(window.ID), data).WithTTL(time.Until(window.EndsAt) + expiredRetention)
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(entry)
+	})
+}
+
+// GetMaintenance 获取维护窗口，不存在时返回nil
+func (s *BadgerStorage) GetMaintenance(id string) (*models.MaintenanceWindow, error) {
+	var window *models.MaintenanceWindow
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		window, err = getJSON[models.MaintenanceWindow](txn, s.maintenanceKey(id))
+		return err
+	})
+	return window, err
+}
+
+// ListMaintenance 列出所有维护窗口（包括已结束但仍在保留期内的）
+func (s *BadgerStorage) ListMaintenance() ([]*models.MaintenanceWindow, error) {
+	return listJSON[models.MaintenanceWindow](s.db, []byte("maintenance:"))
+}
+
+// DeleteMaintenance 删除维护窗口
+func (s *BadgerStorage) DeleteMaintenance(id string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(s.maintenanceKey(id))
+	})
+}
+
+// CreateDrain 保存排空记录，设置了过期时间的记录过期后保留 expiredRetention 再自动删除
+func (s *BadgerStorage) CreateDrain(drain *models.Drain) error {
+	if drain.CreatedAt.IsZero() {
+		drain.CreatedAt = time.Now()
+	}
+	data, err := json.Marshal(drain)
+	if err != nil {
+		return err
+	}
+	entry := badger.NewEntry(s.drainKey(drain.ID), data)
+	if drain.ExpiresAt != nil {
+		entry = entry.WithTTL(time.Until(*drain.ExpiresAt) + expiredRetention)
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(entry)
+	})
+}
+
+// GetDrain 获取排空记录，不存在时返回nil
+func (s *BadgerStorage) GetDrain(id string) (*models.Drain, error) {
+	var drain *models.Drain
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		drain, err = getJSON[models.Drain](txn, s.drainKey(id))
+		return err
+	})
+	return drain, err
+}
+
+// ListDrains 列出所有排空记录（包括已过期但仍在保留期内的）
+func (s *BadgerStorage) ListDrains() ([]*models.Drain, error) {
+	return listJSON[models.Drain](s.db, []byte("drain:"))
+}
+
+// DeleteDrain 删除排空记录
+func (s *BadgerStorage) DeleteDrain(id string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(s.drainKey(id))
+	})
+}
+
+// actuationRetention 已恢复或失败的执行器操作记录保留的时间
+const actuationRetention = 30 * 24 * time.Hour
+
+// SaveActuation 保存执行器操作记录。生效中的记录一直保留，恢复或失败后保留 actuationRetention 再自动删除
+func (s *BadgerStorage) SaveActuation(actuation *models.Actuation) error {
+	if actuation.CreatedAt.IsZero() {
+		actuation.CreatedAt = time.Now()
+	}
+	data, err := json.Marshal(actuation)
+	if err != nil {
+		return err
+	}
+	entry := badger.NewEntry(s.actuationKey(actuation.ID), data)
+	if actuation.Status != "active" {
+		entry = entry.WithTTL(actuationRetention)
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(entry)
+	})
+}
+
+// GetActuation 获取执行器操作记录，不存在时返回nil
+func (s *BadgerStorage) GetActuation(id string) (*models.Actuation, error) {
+	var actuation *models.Actuation
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		actuation, err = getJSON[models.Actuation](txn, s.actuationKey(id))
+		return err
+	})
+	return actuation, err
+}
+
+// ListActuations 列出所有执行器操作记录
+func (s *BadgerStorage) ListActuations() ([]*models.Actuation, error) {
+	return listJSON[models.Actuation](s.db, []byte("actuation:"))
+}
+
+// AddHistorySample 把一次采样合并到所在小时的汇总中，该小时结束 retention 后由Badger自动删除
+func (s *BadgerStorage) AddHistorySample(systemID string, at time.Time, values map[string]float64, retention time.Duration) error {
+	hour := at.UTC().Truncate(time.Hour)
+	key := s.historyKey(systemID, hour)
+	return s.db.Update(func(txn *badger.Txn) error {
+		bucket, err := getJSON[models.HistoryBucket](txn, key)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			bucket = &models.HistoryBucket{SystemID: systemID, Hour: hour, Metrics: make(map[string]*models.HistoryValue, len(values))}
+		}
+		for name, value := range values {
+			metric := bucket.Metrics[name]
+			if metric == nil {
+				metric = &models.HistoryValue{Max: value}
+				bucket.Metrics[name] = metric
+			}
+			metric.Count++
+			metric.Sum += value
+			if value > metric.Max {
+				metric.Max = value
+			}
+		}
+
+		data, err := json.Marshal(bucket)
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry(key, data).WithTTL(time.Until(hour.Add(time.Hour + retention))))
+	})
+}
+
+// ListHistory 按时间顺序列出系统从since所在小时开始的负载历史
+func (s *BadgerStorage) ListHistory(systemID string, since time.Time) ([]*models.HistoryBucket, error) {
+	var buckets []*models.HistoryBucket
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = s.historyPrefix(systemID)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// 键中的时间戳定长补零，按字典序即按时间排序
+		for it.Seek(s.historyKey(systemID, since.UTC().Truncate(time.Hour))); it.Valid(); it.Next() {
+			var bucket models.HistoryBucket
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &bucket)
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal history bucket", "op", "badger.list", "key", string(it.Item().Key()), logging.Err(err))
+				continue
+			}
+			buckets = append(buckets, &bucket)
+		}
+		return nil
+	})
+
+	return buckets, err
+}
+
+// getJSON 在事务中读取并解析一个值，不存在时返回nil
+func getJSON[T any](txn *badger.Txn, key []byte) (*T, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &v)
+	}); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// setJSON 在事务中序列化并写入一个值
+func setJSON(txn *badger.Txn, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, data)
+}
+
+// listJSON 按前缀遍历并解析所有值，无法解析的记录跳过
+func listJSON[T any](db *badger.DB, prefix []byte) ([]*T, error) {
+	var values []*T
+
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var v T
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &v)
+			})
+			if err != nil {
+				slog.Error("Failed to unmarshal record", "op", "badger.list", "key", string(it.Item().Key()), logging.Err(err))
+				continue
+			}
+			values = append(values, &v)
+		}
+		return nil
+	})
+
+	return values, err
+}
+
+// UpdateConfig 在一个Badger事务中执行fn，fn返回错误时不写入任何修改
+func (s *BadgerStorage) UpdateConfig(fn func(tx ConfigTx) error) error {
 	return s.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete(s.aliasKey(systemID))
+		return fn(&badgerConfigTx{s: s, txn: txn})
 	})
-}
\ No newline at end of file
+}
+
+// badgerConfigTx 在同一个Badger事务中读写本地配置，读取可以看到事务中尚未提交的写入
+type badgerConfigTx struct {
+	s   *BadgerStorage
+	txn *badger.Txn
+}
+
+func (t *badgerConfigTx) GetThresholdProfile(id string) (*models.ThresholdProfile, error) {
+	return getJSON[models.ThresholdProfile](t.txn, t.s.thresholdProfileKey(id))
+}
+
+func (t *badgerConfigTx) CreateOrUpdateThreshold(threshold *models.SystemThreshold) error {
+	// 如果是更新，先获取旧数据保留ID和创建时间
+	key := t.s.thresholdKey(threshold.SystemID)
+	item, err := t.txn.Get(key)
+	if err == nil {
+		var existing models.SystemThreshold
+		err = item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &existing)
+		})
+		if err == nil {
+			if threshold.ID == 0 {
+				threshold.ID = existing.ID
+			}
+			if threshold.CreatedAt.IsZero() {
+				threshold.CreatedAt = existing.CreatedAt
+			}
+		}
+	} else {
+		// 新建记录，生成ID和创建时间
+		if threshold.ID == 0 {
+			threshold.ID = uint(time.Now().UnixNano())
+		}
+		if threshold.CreatedAt.IsZero() {
+			threshold.CreatedAt = time.Now()
+		}
+		if threshold.Version, err = t.continueVersion("threshold", threshold.SystemID, threshold.Version); err != nil {
+			return err
+		}
+	}
+
+	threshold.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(threshold)
+	if err != nil {
+		return err
+	}
+
+	return t.txn.Set(key, data)
+}
+
+func (t *badgerConfigTx) DeleteThreshold(systemID string) error {
+	existing, err := getJSON[models.SystemThreshold](t.txn, t.s.thresholdKey(systemID))
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := t.keepDeletedVersion("threshold", systemID, existing.Version); err != nil {
+			return err
+		}
+	}
+	return t.txn.Delete(t.s.thresholdKey(systemID))
+}
+
+// keepDeletedVersion 删除记录时保存其版本，重新创建的记录从这里继续递增，删除前的ETag不会匹配新记录
+func (t *badgerConfigTx) keepDeletedVersion(resource, id string, version int64) error {
+	last, err := getJSON[int64](t.txn, t.s.deletedVersionKey(resource, id))
+	if err != nil {
+		return err
+	}
+	if last != nil && *last > version {
+		version = *last
+	}
+	return setJSON(t.txn, t.s.deletedVersionKey(resource, id), version)
+}
+
+// continueVersion 新建记录时的版本：不低于同一记录删除前的版本加一
+func (t *badgerConfigTx) continueVersion(resource, id string, version int64) (int64, error) {
+	last, err := getJSON[int64](t.txn, t.s.deletedVersionKey(resource, id))
+	if err != nil {
+		return 0, err
+	}
+	if last != nil && version <= *last {
+		version = *last + 1
+	}
+	return version, nil
+}
+
+func (t *badgerConfigTx) CreateOrUpdateThresholdProfile(profile *models.ThresholdProfile) error {
+	key := t.s.thresholdProfileKey(profile.ID)
+	item, err := t.txn.Get(key)
+	if err == nil {
+		var existing models.ThresholdProfile
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &existing)
+		}); err == nil && profile.CreatedAt.IsZero() {
+			profile.CreatedAt = existing.CreatedAt
+		}
+	}
+	if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = time.Now()
+	}
+	profile.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return t.txn.Set(key, data)
+}
+
+func (t *badgerConfigTx) DeleteThresholdProfile(id string) error {
+	return t.txn.Delete(t.s.thresholdProfileKey(id))
+}
+
+func (t *badgerConfigTx) SetSystemAlias(alias *models.SystemAlias) error {
+	// 先检查是否已存在
+	key := t.s.aliasKey(alias.SystemID)
+	item, err := t.txn.Get(key)
+	if err == nil {
+		// 已存在，保留ID和创建时间
+		var existing models.SystemAlias
+		err = item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &existing)
+		})
+		if err == nil {
+			if alias.ID == 0 {
+				alias.ID = existing.ID
+			}
+			if alias.CreatedAt.IsZero() {
+				alias.CreatedAt = existing.CreatedAt
+			}
+		}
+	} else {
+		// 新建记录
+		if alias.ID == 0 {
+			alias.ID = uint(time.Now().UnixNano())
+		}
+		if alias.CreatedAt.IsZero() {
+			alias.CreatedAt = time.Now()
+		}
+		if alias.Version, err = t.continueVersion("alias", alias.SystemID, alias.Version); err != nil {
+			return err
+		}
+	}
+
+	alias.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(alias)
+	if err != nil {
+		return err
+	}
+
+	return t.txn.Set(key, data)
+}
+
+func (t *badgerConfigTx) DeleteSystemAlias(systemID string) error {
+	existing, err := getJSON[models.SystemAlias](t.txn, t.s.aliasKey(systemID))
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := t.keepDeletedVersion("alias", systemID, existing.Version); err != nil {
+			return err
+		}
+	}
+	return t.txn.Delete(t.s.aliasKey(systemID))
+}
+
+func (t *badgerConfigTx) CreateNodeTag(tag *models.NodeTag) error {
+	key := t.s.nodeTagKey(tag.SystemID, tag.TagType, tag.TagID)
+	item, err := t.txn.Get(key)
+	if err == nil {
+		var existing models.NodeTag
+		err = item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &existing)
+		})
+		if err == nil {
+			tag.ID = existing.ID
+			tag.CreatedAt = existing.CreatedAt
+		}
+	} else {
+		if tag.ID == 0 {
+			tag.ID = uint(time.Now().UnixNano())
+		}
+		if tag.CreatedAt.IsZero() {
+			tag.CreatedAt = time.Now()
+		}
+	}
+
+	tag.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return err
+	}
+
+	return t.txn.Set(key, data)
+}
+
+func (t *badgerConfigTx) DeleteNodeTag(systemID, tagType string, tagID int) error {
+	return t.txn.Delete(t.s.nodeTagKey(systemID, tagType, tagID))
+}
@@ -2,8 +2,10 @@ package database
 
 import (
 	"backend/pkg/models"
+	"errors"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestBadgerStorage(t *testing.T) {
@@ -117,4 +119,129 @@ func TestBadgerStorage(t *testing.T) {
 			t.Errorf("Expected 0 tags after delete, got %d", len(tags))
 		}
 	})
-}
\ No newline at end of file
+
+	// 测试阈值模板操作
+	t.Run("ThresholdProfile", func(t *testing.T) {
+		cpu := 70.0
+		profile := &models.ThresholdProfile{
+			ID:     "iepl-relay",
+			Name:   "IEPL relay",
+			Values: models.ThresholdValues{CPUAlertLimit: &cpu},
+		}
+
+		err := storage.CreateOrUpdateThresholdProfile(profile)
+		if err != nil {
+			t.Errorf("Failed to create profile: %v", err)
+		}
+
+		got, err := storage.GetThresholdProfile("iepl-relay")
+		if err != nil {
+			t.Errorf("Failed to get profile: %v", err)
+		}
+		if got == nil || got.Values.CPUAlertLimit == nil || *got.Values.CPUAlertLimit != 70.0 {
+			t.Errorf("Unexpected profile: %+v", got)
+		}
+		if got != nil && got.Values.MemAlertLimit != nil {
+			t.Errorf("Expected unset MemAlertLimit to stay nil")
+		}
+
+		// 模板与系统阈值使用不同的键前缀，互不影响
+		profiles, err := storage.ListThresholdProfiles()
+		if err != nil {
+			t.Errorf("Failed to list profiles: %v", err)
+		}
+		if len(profiles) != 1 {
+			t.Errorf("Expected 1 profile, got %d", len(profiles))
+		}
+		thresholds, err := storage.ListThresholds()
+		if err != nil {
+			t.Errorf("Failed to list thresholds: %v", err)
+		}
+		for _, threshold := range thresholds {
+			if threshold.SystemID == "" {
+				t.Errorf("Profile leaked into threshold list: %+v", threshold)
+			}
+		}
+
+		err = storage.DeleteThresholdProfile("iepl-relay")
+		if err != nil {
+			t.Errorf("Failed to delete profile: %v", err)
+		}
+		got, err = storage.GetThresholdProfile("iepl-relay")
+		if err != nil || got != nil {
+			t.Errorf("Expected profile to be deleted, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("History", func(t *testing.T) {
+		hour := time.Now().UTC().Truncate(time.Hour)
+		samples := []struct {
+			systemID string
+			at       time.Time
+			users    float64
+		}{
+			{"sys-a", hour.Add(5 * time.Minute), 100},
+			{"sys-a", hour.Add(10 * time.Minute), 140},
+			{"sys-a", hour.Add(-time.Hour), 80},
+			{"sys-a", hour.Add(time.Hour), 60},
+			{"sys-ab", hour, 999},
+		}
+		for _, sample := range samples {
+			if err := storage.AddHistorySample(sample.systemID, sample.at, map[string]float64{"online_users": sample.users}, 24*time.Hour); err != nil {
+				t.Fatalf("Failed to add history sample: %v", err)
+			}
+		}
+
+		// 从since所在小时开始，按时间排序，同一小时合并，不包含ID前缀相同的其他系统
+		buckets, err := storage.ListHistory("sys-a", hour.Add(30*time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to list history: %v", err)
+		}
+		if len(buckets) != 2 || !buckets[0].Hour.Equal(hour) || !buckets[1].Hour.Equal(hour.Add(time.Hour)) {
+			t.Fatalf("Unexpected history buckets: %+v", buckets)
+		}
+		users := buckets[0].Metrics["online_users"]
+		if users.Count != 2 || users.Sum != 240 || users.Max != 140 {
+			t.Errorf("Unexpected merged bucket: %+v", users)
+		}
+	})
+	t.Run("UpdateConfig", func(t *testing.T) {
+		if err := storage.SetSystemAlias(&models.SystemAlias{SystemID: "tx-system", Alias: "old"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// 事务中途失败时，之前的写入和删除都不生效
+		boom := errors.New("boom")
+		err := storage.UpdateConfig(func(tx ConfigTx) error {
+			if err := tx.CreateOrUpdateThresholdProfile(&models.ThresholdProfile{ID: "tx-profile"}); err != nil {
+				return err
+			}
+			// 事务中可以读到尚未提交的写入
+			if profile, err := tx.GetThresholdProfile("tx-profile"); err != nil || profile == nil {
+				t.Errorf("Pending profile not visible in transaction: %v", err)
+			}
+			if err := tx.DeleteSystemAlias("tx-system"); err != nil {
+				return err
+			}
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("Expected transaction error, got %v", err)
+		}
+		if profile, _ := storage.GetThresholdProfile("tx-profile"); profile != nil {
+			t.Error("Profile should not be written after a failed transaction")
+		}
+		if alias, _ := storage.GetSystemAlias("tx-system"); alias == nil || alias.Alias != "old" {
+			t.Errorf("Alias should be kept after a failed transaction, got %+v", alias)
+		}
+
+		if err := storage.UpdateConfig(func(tx ConfigTx) error {
+			return tx.CreateOrUpdateThresholdProfile(&models.ThresholdProfile{ID: "tx-profile"})
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if profile, _ := storage.GetThresholdProfile("tx-profile"); profile == nil || profile.CreatedAt.IsZero() {
+			t.Errorf("Committed profile not found: %+v", profile)
+		}
+	})
+}
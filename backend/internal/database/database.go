@@ -1,9 +1,5 @@
 package database
 
-import (
-	"log"
-)
-
 var storage Storage
 
 // Init 初始化数据库存储
@@ -15,7 +11,6 @@ func Init(dbPath string) error {
 	}
 	
 	storage = badgerStorage
-	log.Println("数据库存储初始化成功")
 	return nil
 }
 
@@ -2,6 +2,7 @@ package database
 
 import (
 	"backend/pkg/models"
+	"time"
 )
 
 // Storage 定义存储接口
@@ -12,12 +13,84 @@ type Storage interface {
 	ListThresholds() ([]*models.SystemThreshold, error)
 	DeleteThreshold(systemID string) error
 
+	// 阈值模板相关
+	CreateOrUpdateThresholdProfile(profile *models.ThresholdProfile) error
+	GetThresholdProfile(id string) (*models.ThresholdProfile, error)
+	ListThresholdProfiles() ([]*models.ThresholdProfile, error)
+	DeleteThresholdProfile(id string) error
+
 	// 系统别名相关
 	SetSystemAlias(alias *models.SystemAlias) error
 	GetSystemAlias(systemID string) (*models.SystemAlias, error)
 	GetAllSystemAliases() ([]*models.SystemAlias, error)
 	DeleteSystemAlias(systemID string) error
 
+	// 节点标签相关
+	CreateNodeTag(tag *models.NodeTag) error
+	GetNodeTags(systemID string) ([]*models.NodeTag, error)
+	ListNodeTags() ([]*models.NodeTag, error)
+	GetNodeTagsByTypeAndID(tagType string, tagID int) ([]*models.NodeTag, error)
+	DeleteNodeTag(systemID, tagType string, tagID int) error
+
+	// 维护窗口相关
+	CreateOrUpdateMaintenance(window *models.MaintenanceWindow) error
+	GetMaintenance(id string) (*models.MaintenanceWindow, error)
+	ListMaintenance() ([]*models.MaintenanceWindow, error)
+	DeleteMaintenance(id string) error
+
+	// 排空记录相关
+	CreateDrain(drain *models.Drain) error
+	GetDrain(id string) (*models.Drain, error)
+	ListDrains() ([]*models.Drain, error)
+	DeleteDrain(id string) error
+
+	// 执行器操作记录相关
+	SaveActuation(actuation *models.Actuation) error
+	GetActuation(id string) (*models.Actuation, error)
+	ListActuations() ([]*models.Actuation, error)
+
+	// 负载历史相关
+	AddHistorySample(systemID string, at time.Time, values map[string]float64, retention time.Duration) error
+	ListHistory(systemID string, since time.Time) ([]*models.HistoryBucket, error)
+
+	// 审计日志相关
+	AppendAuditEntry(entry *models.AuditEntry) error
+	ListAuditEntries(query *models.AuditQuery) ([]*models.AuditEntry, error)
+
+	// 用户、会话和API密钥相关
+	CreateOrUpdateUser(user *models.User) error
+	GetUser(username string) (*models.User, error)
+	ListUsers() ([]*models.User, error)
+	DeleteUser(username string) error
+	CreateSession(session *models.Session) error
+	GetSession(tokenHash string) (*models.Session, error)
+	DeleteSession(tokenHash string) error
+	DeleteUserSessions(username string) error
+	CreateOrUpdateAPIKey(key *models.APIKey) error
+	GetAPIKey(id string) (*models.APIKey, error)
+	ListAPIKeys() ([]*models.APIKey, error)
+	DeleteAPIKey(id string) error
+
+	// UpdateConfig 在一个事务中执行fn，fn返回错误时不写入任何修改。
+	// 用于导入等需要整体生效的本地配置修改
+	UpdateConfig(fn func(tx ConfigTx) error) error
+
+	// Ping 检查存储是否可读，用于就绪检查
+	Ping() error
+
 	// 关闭存储
 	Close() error
-}
\ No newline at end of file
+}
+
+// ConfigTx 事务中可用的本地配置读写操作，读取可以看到同一事务中尚未提交的写入
+type ConfigTx interface {
+	GetThresholdProfile(id string) (*models.ThresholdProfile, error)
+	CreateOrUpdateThresholdProfile(profile *models.ThresholdProfile) error
+	DeleteThresholdProfile(id string) error
+	CreateOrUpdateThreshold(threshold *models.SystemThreshold) error
+	DeleteThreshold(systemID string) error
+	SetSystemAlias(alias *models.SystemAlias) error
+	DeleteSystemAlias(systemID string) error
+	CreateNodeTag(tag *models.NodeTag) error
+	DeleteNodeTag(systemID, tagType string, tagID int) error
+}
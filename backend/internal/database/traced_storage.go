@@ -0,0 +1,223 @@
+package database
+
+import (
+	"backend/internal/tracing"
+	"backend/pkg/models"
+	"context"
+	"time"
+)
+
+// GetStorageContext 获取存储实例，每次操作都会作为ctx中span的子span记录，
+// 用于排查请求路径上的存储耗时
+func GetStorageContext(ctx context.Context) Storage {
+	if storage == nil {
+		return nil
+	}
+	return &tracedStorage{Storage: storage, ctx: ctx}
+}
+
+// tracedStorage 为每次存储操作创建span的Storage包装
+type tracedStorage struct {
+	Storage
+	ctx context.Context
+}
+
+func (s *tracedStorage) trace(op string, fn func() error) error {
+	_, span := tracing.Start(s.ctx, "badger."+op)
+	err := fn()
+	tracing.End(span, err)
+	return err
+}
+
+func traced[T any](s *tracedStorage, op string, fn func() (T, error)) (T, error) {
+	var result T
+	err := s.trace(op, func() (err error) {
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
+
+func (s *tracedStorage) CreateOrUpdateThreshold(threshold *models.SystemThreshold) error {
+	return s.trace("CreateOrUpdateThreshold", func() error { return s.Storage.CreateOrUpdateThreshold(threshold) })
+}
+
+func (s *tracedStorage) GetThreshold(systemID string) (*models.SystemThreshold, error) {
+	return traced(s, "GetThreshold", func() (*models.SystemThreshold, error) { return s.Storage.GetThreshold(systemID) })
+}
+
+func (s *tracedStorage) ListThresholds() ([]*models.SystemThreshold, error) {
+	return traced(s, "ListThresholds", func() ([]*models.SystemThreshold, error) { return s.Storage.ListThresholds() })
+}
+
+func (s *tracedStorage) DeleteThreshold(systemID string) error {
+	return s.trace("DeleteThreshold", func() error { return s.Storage.DeleteThreshold(systemID) })
+}
+
+func (s *tracedStorage) CreateOrUpdateThresholdProfile(profile *models.ThresholdProfile) error {
+	return s.trace("CreateOrUpdateThresholdProfile", func() error { return s.Storage.CreateOrUpdateThresholdProfile(profile) })
+}
+
+func (s *tracedStorage) GetThresholdProfile(id string) (*models.ThresholdProfile, error) {
+	return traced(s, "GetThresholdProfile", func() (*models.ThresholdProfile, error) { return s.Storage.GetThresholdProfile(id) })
+}
+
+func (s *tracedStorage) ListThresholdProfiles() ([]*models.ThresholdProfile, error) {
+	return traced(s, "ListThresholdProfiles", func() ([]*models.ThresholdProfile, error) { return s.Storage.ListThresholdProfiles() })
+}
+
+func (s *tracedStorage) DeleteThresholdProfile(id string) error {
+	return s.trace("DeleteThresholdProfile", func() error { return s.Storage.DeleteThresholdProfile(id) })
+}
+
+func (s *tracedStorage) SetSystemAlias(alias *models.SystemAlias) error {
+	return s.trace("SetSystemAlias", func() error { return s.Storage.SetSystemAlias(alias) })
+}
+
+func (s *tracedStorage) GetSystemAlias(systemID string) (*models.SystemAlias, error) {
+	return traced(s, "GetSystemAlias", func() (*models.SystemAlias, error) { return s.Storage.GetSystemAlias(systemID) })
+}
+
+func (s *tracedStorage) GetAllSystemAliases() ([]*models.SystemAlias, error) {
+	return traced(s, "GetAllSystemAliases", func() ([]*models.SystemAlias, error) { return s.Storage.GetAllSystemAliases() })
+}
+
+func (s *tracedStorage) DeleteSystemAlias(systemID string) error {
+	return s.trace("DeleteSystemAlias", func() error { return s.Storage.DeleteSystemAlias(systemID) })
+}
+
+func (s *tracedStorage) CreateNodeTag(tag *models.NodeTag) error {
+	return s.trace("CreateNodeTag", func() error { return s.Storage.CreateNodeTag(tag) })
+}
+
+func (s *tracedStorage) GetNodeTags(systemID string) ([]*models.NodeTag, error) {
+	return traced(s, "GetNodeTags", func() ([]*models.NodeTag, error) { return s.Storage.GetNodeTags(systemID) })
+}
+
+func (s *tracedStorage) ListNodeTags() ([]*models.NodeTag, error) {
+	return traced(s, "ListNodeTags", func() ([]*models.NodeTag, error) { return s.Storage.ListNodeTags() })
+}
+
+func (s *tracedStorage) GetNodeTagsByTypeAndID(tagType string, tagID int) ([]*models.NodeTag, error) {
+	return traced(s, "GetNodeTagsByTypeAndID", func() ([]*models.NodeTag, error) { return s.Storage.GetNodeTagsByTypeAndID(tagType, tagID) })
+}
+
+func (s *tracedStorage) DeleteNodeTag(systemID, tagType string, tagID int) error {
+	return s.trace("DeleteNodeTag", func() error { return s.Storage.DeleteNodeTag(systemID, tagType, tagID) })
+}
+
+func (s *tracedStorage) AppendAuditEntry(entry *models.AuditEntry) error {
+	return s.trace("AppendAuditEntry", func() error { return s.Storage.AppendAuditEntry(entry) })
+}
+
+func (s *tracedStorage) ListAuditEntries(query *models.AuditQuery) ([]*models.AuditEntry, error) {
+	return traced(s, "ListAuditEntries", func() ([]*models.AuditEntry, error) { return s.Storage.ListAuditEntries(query) })
+}
+
+func (s *tracedStorage) CreateOrUpdateUser(user *models.User) error {
+	return s.trace("CreateOrUpdateUser", func() error { return s.Storage.CreateOrUpdateUser(user) })
+}
+
+func (s *tracedStorage) GetUser(username string) (*models.User, error) {
+	return traced(s, "GetUser", func() (*models.User, error) { return s.Storage.GetUser(username) })
+}
+
+func (s *tracedStorage) ListUsers() ([]*models.User, error) {
+	return traced(s, "ListUsers", func() ([]*models.User, error) { return s.Storage.ListUsers() })
+}
+
+func (s *tracedStorage) DeleteUser(username string) error {
+	return s.trace("DeleteUser", func() error { return s.Storage.DeleteUser(username) })
+}
+
+func (s *tracedStorage) CreateSession(session *models.Session) error {
+	return s.trace("CreateSession", func() error { return s.Storage.CreateSession(session) })
+}
+
+func (s *tracedStorage) GetSession(tokenHash string) (*models.Session, error) {
+	return traced(s, "GetSession", func() (*models.Session, error) { return s.Storage.GetSession(tokenHash) })
+}
+
+func (s *tracedStorage) DeleteSession(tokenHash string) error {
+	return s.trace("DeleteSession", func() error { return s.Storage.DeleteSession(tokenHash) })
+}
+
+func (s *tracedStorage) DeleteUserSessions(username string) error {
+	return s.trace("DeleteUserSessions", func() error { return s.Storage.DeleteUserSessions(username) })
+}
+
+func (s *tracedStorage) CreateOrUpdateAPIKey(key *models.APIKey) error {
+	return s.trace("CreateOrUpdateAPIKey", func() error { return s.Storage.CreateOrUpdateAPIKey(key) })
+}
+
+func (s *tracedStorage) GetAPIKey(id string) (*models.APIKey, error) {
+	return traced(s, "GetAPIKey", func() (*models.APIKey, error) { return s.Storage.GetAPIKey(id) })
+}
+
+func (s *tracedStorage) ListAPIKeys() ([]*models.APIKey, error) {
+	return traced(s, "ListAPIKeys", func() ([]*models.APIKey, error) { return s.Storage.ListAPIKeys() })
+}
+
+func (s *tracedStorage) DeleteAPIKey(id string) error {
+	return s.trace("DeleteAPIKey", func() error { return s.Storage.DeleteAPIKey(id) })
+}
+
+func (s *tracedStorage) Ping() error {
+	return s.trace("Ping", s.Storage.Ping)
+}
+
+func (s *tracedStorage) CreateOrUpdateMaintenance(window *models.MaintenanceWindow) error {
+	return s.trace("CreateOrUpdateMaintenance", func() error { return s.Storage.CreateOrUpdateMaintenance(window) })
+}
+
+func (s *tracedStorage) GetMaintenance(id string) (*models.MaintenanceWindow, error) {
+	return traced(s, "GetMaintenance", func() (*models.MaintenanceWindow, error) { return s.Storage.GetMaintenance(id) })
+}
+
+func (s *tracedStorage) ListMaintenance() ([]*models.MaintenanceWindow, error) {
+	return traced(s, "ListMaintenance", s.Storage.ListMaintenance)
+}
+
+func (s *tracedStorage) DeleteMaintenance(id string) error {
+	return s.trace("DeleteMaintenance", func() error { return s.Storage.DeleteMaintenance(id) })
+}
+
+func (s *tracedStorage) CreateDrain(drain *models.Drain) error {
+	return s.trace("CreateDrain", func() error { return s.Storage.CreateDrain(drain) })
+}
+
+func (s *tracedStorage) GetDrain(id string) (*models.Drain, error) {
+	return traced(s, "GetDrain", func() (*models.Drain, error) { return s.Storage.GetDrain(id) })
+}
+
+func (s *tracedStorage) ListDrains() ([]*models.Drain, error) {
+	return traced(s, "ListDrains", s.Storage.ListDrains)
+}
+
+func (s *tracedStorage) DeleteDrain(id string) error {
+	return s.trace("DeleteDrain", func() error { return s.Storage.DeleteDrain(id) })
+}
+
+func (s *tracedStorage) SaveActuation(actuation *models.Actuation) error {
+	return s.trace("SaveActuation", func() error { return s.Storage.SaveActuation(actuation) })
+}
+
+func (s *tracedStorage) GetActuation(id string) (*models.Actuation, error) {
+	return traced(s, "GetActuation", func() (*models.Actuation, error) { return s.Storage.GetActuation(id) })
+}
+
+func (s *tracedStorage) ListActuations() ([]*models.Actuation, error) {
+	return traced(s, "ListActuations", s.Storage.ListActuations)
+}
+
+func (s *tracedStorage) AddHistorySample(systemID string, at time.Time, values map[string]float64, retention time.Duration) error {
+	return s.trace("AddHistorySample", func() error { return s.Storage.AddHistorySample(systemID, at, values, retention) })
+}
+
+func (s *tracedStorage) ListHistory(systemID string, since time.Time) ([]*models.HistoryBucket, error) {
+	return traced(s, "ListHistory", func() ([]*models.HistoryBucket, error) { return s.Storage.ListHistory(systemID, since) })
+}
+
+func (s *tracedStorage) UpdateConfig(fn func(tx ConfigTx) error) error {
+	return s.trace("UpdateConfig", func() error { return s.Storage.UpdateConfig(fn) })
+}
@@ -0,0 +1,115 @@
+// Package logging 基于log/slog的结构化日志：JSON或文本输出、可运行时调整的日志级别，
+// 以及为每个请求分配请求ID的Gin中间件。
+package logging
+
+import (
+	"backend/internal/config"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 日志格式
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// level 当前日志级别，配置重载时直接修改，已创建的logger立即生效
+var level slog.LevelVar
+
+// Setup 按配置设置slog默认logger。依赖库通过标准库log的输出同样经过该logger，记为INFO级别
+func Setup(cfg config.LogConfig) error {
+	return setup(os.Stderr, cfg)
+}
+
+func setup(w io.Writer, cfg config.LogConfig) error {
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: &level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("未知的日志格式: %s", cfg.Format)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// SetLevel 修改日志级别：debug、info、warn 或 error
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// ParseLevel 解析日志级别名称，空字符串视为info
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("未知的日志级别: %s", name)
+}
+
+// Err 统一错误字段的名称
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 把请求ID放入ctx，之后使用该ctx记录的日志都会带上 request_id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回ctx中的请求ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 从ctx中取出请求ID和trace ID追加到日志记录中，
+// 使用 slog.InfoContext 等带ctx的方法时生效
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
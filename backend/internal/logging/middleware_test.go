@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	if err := setup(&buf, config.LogConfig{Level: "info", Format: FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(), Recovery())
+	r.GET("/api/systems/:id", func(c *gin.Context) {
+		slog.WarnContext(c.Request.Context(), "处理器日志", "op", "test")
+		c.Status(http.StatusOK)
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	tests := []struct {
+		name     string
+		path     string
+		incoming string
+		reuse    bool
+		status   int
+	}{
+		{name: "沿用合法的请求ID", path: "/api/systems/abc", incoming: "req-1.a_b", reuse: true, status: http.StatusOK},
+		{name: "没有请求ID时生成", path: "/api/systems/abc", status: http.StatusOK},
+		{name: "非法请求ID被替换", path: "/api/systems/abc", incoming: "bad id\n", status: http.StatusOK},
+		{name: "超长请求ID被替换", path: "/api/systems/abc", incoming: strings.Repeat("a", maxRequestIDLength+1), status: http.StatusOK},
+		{name: "panic返回500", path: "/panic", incoming: "req-2", reuse: true, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d, 期望 %d", w.Code, tt.status)
+			}
+			id := w.Header().Get(RequestIDHeader)
+			if tt.reuse && id != tt.incoming {
+				t.Errorf("%s = %q, 期望沿用 %q", RequestIDHeader, id, tt.incoming)
+			}
+			if !tt.reuse && (!validRequestID(id) || id == tt.incoming) {
+				t.Errorf("%s = %q, 期望新生成的ID", RequestIDHeader, id)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("期望2条日志, 得到 %d: %s", len(lines), buf.String())
+			}
+			for _, line := range lines {
+				var record map[string]any
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("日志不是JSON: %s", line)
+				}
+				if record["request_id"] != id {
+					t.Errorf("日志中 request_id = %v, 期望 %q", record["request_id"], id)
+				}
+			}
+			var access map[string]any
+			json.Unmarshal([]byte(lines[1]), &access)
+			if access["op"] != "http.request" || access["status"] != float64(tt.status) {
+				t.Errorf("访问日志 = %v", access)
+			}
+		})
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	if err := setup(&buf, config.LogConfig{Level: "warn", Format: FormatText}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		SetLevel("info")
+	})
+
+	slog.Info("忽略")
+	if buf.Len() != 0 {
+		t.Errorf("warn级别输出了info日志: %s", buf.String())
+	}
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	slog.Debug("输出", "op", "test")
+	if !strings.Contains(buf.String(), "op=test") {
+		t.Errorf("调整级别后未输出debug日志: %q", buf.String())
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("未知级别应返回错误")
+	}
+}
@@ -0,0 +1,164 @@
+// Package metrics 提供Prometheus指标：上游调用（PocketBase、Redis、v2board面板）的自身指标，
+// 以及由service包注册的按系统、按节点的负载指标。
+package metrics
+
+import (
+	"backend/internal/pocketbase"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// Namespace 所有指标名称的前缀
+const Namespace = "beszel"
+
+// Registry 本服务的指标注册表，不使用全局默认注册表，避免依赖库注册的指标混入
+var Registry = prometheus.NewRegistry()
+
+var (
+	pocketBaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "pocketbase",
+		Name:      "request_duration_seconds",
+		Help:      "PocketBase HTTP请求耗时（不含重试等待），status为0表示没有收到响应",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "endpoint", "status"})
+
+	pocketBaseErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "pocketbase",
+		Name:      "request_errors_total",
+		Help:      "PocketBase请求失败次数，reason为 transport、timeout、circuit_open 或 http_5xx",
+	}, []string{"endpoint", "reason"})
+
+	redisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "redis",
+		Name:      "command_duration_seconds",
+		Help:      "Redis命令耗时",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	redisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "redis",
+		Name:      "command_errors_total",
+		Help:      "Redis命令失败次数（key不存在不计入）",
+	}, []string{"command"})
+
+	panelDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "panel",
+		Name:      "request_duration_seconds",
+		Help:      "v2board/Xboard管理接口请求耗时，status为0表示没有收到响应",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "endpoint", "status"})
+
+	actuatorActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "actuator",
+		Name:      "actions_total",
+		Help:      "执行器对面板节点的操作次数，result为 applied、dry_run、failed、reverted 或 revert_failed",
+	}, []string{"action", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		pocketBaseDuration,
+		pocketBaseErrors,
+		redisDuration,
+		redisErrors,
+		panelDuration,
+		actuatorActions,
+	)
+}
+
+// Handler 返回 /metrics 的处理器
+func Handler() gin.HandlerFunc {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	return gin.WrapH(handler)
+}
+
+// ObservePocketBaseRequest 记录一次PocketBase请求，作为 pocketbase.Client 的 OnRequest 回调
+func ObservePocketBaseRequest(method, endpoint string, status int, duration time.Duration, err error) {
+	endpoint = pocketbase.NormalizeEndpoint(endpoint)
+
+	var reason string
+	switch {
+	case errors.Is(err, pocketbase.ErrCircuitOpen):
+		reason = "circuit_open"
+	case err != nil && isTimeout(err):
+		reason = "timeout"
+	case err != nil:
+		reason = "transport"
+	case status >= 500:
+		reason = "http_5xx"
+	}
+	if reason != "" {
+		pocketBaseErrors.WithLabelValues(endpoint, reason).Inc()
+	}
+	// 熔断时没有真正发出请求，不计入耗时
+	if reason != "circuit_open" {
+		pocketBaseDuration.WithLabelValues(method, endpoint, strconv.Itoa(status)).Observe(duration.Seconds())
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// RedisHook 记录Redis命令耗时和错误的go-redis钩子
+type RedisHook struct{}
+
+// DialHook 不记录建立连接
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 记录单条命令
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd.Name(), time.Since(start), err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 整个pipeline记录为一次 pipeline 命令
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeRedis("pipeline", time.Since(start), err)
+		return err
+	}
+}
+
+func observeRedis(command string, duration time.Duration, err error) {
+	redisDuration.WithLabelValues(command).Observe(duration.Seconds())
+	if err != nil && !errors.Is(err, redis.Nil) {
+		redisErrors.WithLabelValues(command).Inc()
+	}
+}
+
+// ObservePanelRequest 记录一次面板管理接口请求，作为 v2board.Client 的 OnRequest 回调
+func ObservePanelRequest(method, endpoint string, status int, duration time.Duration, err error) {
+	panelDuration.WithLabelValues(method, endpoint, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveActuation 记录一次执行器操作
+func ObserveActuation(action, result string) {
+	actuatorActions.WithLabelValues(action, result).Inc()
+}
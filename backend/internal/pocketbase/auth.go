@@ -0,0 +1,337 @@
+package pocketbase
+
+import (
+	"backend/internal/logging"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// UsersCollection 普通用户认证集合
+	UsersCollection = "users"
+	// SuperusersCollection 超级用户认证集合（PocketBase v0.23+）
+	SuperusersCollection = "_superusers"
+
+	// tokenRefreshLeeway token到期前提前刷新的时间窗口
+	tokenRefreshLeeway = 5 * time.Minute
+	// fallbackTokenTTL 无法从token中解析exp时假定的有效期，取较短值以便尽早刷新
+	fallbackTokenTTL = time.Hour
+	// refreshAtLifetimeRatio 后台刷新的时间点：token寿命过去的比例
+	refreshAtLifetimeRatio = 0.8
+)
+
+// AuthResponse 认证响应（auth-with-password 与 auth-refresh 返回结构相同）
+type AuthResponse struct {
+	Token  string `json:"token"`
+	Record struct {
+		ID       string `json:"id"`
+		Email    string `json:"email"`
+		Username string `json:"username"`
+	} `json:"record"`
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Identity string `json:"identity"`
+	Password string `json:"password"`
+}
+
+// 认证模式
+const (
+	// AuthModePassword 使用邮箱/密码登录，token由客户端自动续期
+	AuthModePassword = "password"
+	// AuthModeToken 使用预先签发的token（API token或impersonate token）
+	AuthModeToken = "token"
+)
+
+// tokenClaims 客户端关心的JWT声明
+type tokenClaims struct {
+	ExpireAt time.Time
+	// Refreshable 是否允许调用auth-refresh；impersonate签发的token为false
+	Refreshable bool
+}
+
+// tokenState 读取当前token及其过期时间
+func (pb *Client) tokenState() (string, time.Time) {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	return pb.authToken, pb.tokenExpireAt
+}
+
+// credentials 读取保存的登录凭据
+func (pb *Client) credentials() (string, string) {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	return pb.email, pb.password
+}
+
+// AuthMode 返回当前认证模式
+func (pb *Client) AuthMode() string {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	if pb.staticToken {
+		return AuthModeToken
+	}
+	return AuthModePassword
+}
+
+// SetCredentials 保存密码凭据但不立即登录，用于token失效时的后备登录
+func (pb *Client) SetCredentials(email, password string) {
+	pb.mu.Lock()
+	pb.email = email
+	pb.password = password
+	pb.mu.Unlock()
+}
+
+// SetAuthToken 使用预先签发的token认证（如API token或impersonate token），不会发起登录。
+// 可刷新的token会在到期前通过auth-refresh续期；不可刷新的token到期后，
+// 若通过SetCredentials配置了凭据则回退到密码登录，否则请求将返回认证错误。
+func (pb *Client) SetAuthToken(token string) time.Time {
+	pb.authMu.Lock()
+	defer pb.authMu.Unlock()
+
+	pb.mu.Lock()
+	pb.staticToken = true
+	pb.mu.Unlock()
+
+	expireAt := pb.setToken(token)
+	slog.Info("PocketBase 使用预签发token认证", "op", "pocketbase.auth", "expire_at", expireAt)
+	return expireAt
+}
+
+// TokenExpireAt 返回当前token的过期时间，未登录时为零值
+func (pb *Client) TokenExpireAt() time.Time {
+	_, expireAt := pb.tokenState()
+	return expireAt
+}
+
+// Authenticated 是否持有未过期的token
+func (pb *Client) Authenticated() bool {
+	token, expireAt := pb.tokenState()
+	return token != "" && time.Now().Before(expireAt)
+}
+
+// NextRefreshAt 返回建议的后台刷新时间（token寿命过去80%时），未登录时为零值。
+// 既不能刷新又没有后备凭据的token无事可做，直接返回其过期时间。
+func (pb *Client) NextRefreshAt() time.Time {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	if pb.authToken == "" {
+		return time.Time{}
+	}
+	if !pb.tokenRefreshable && (pb.email == "" || pb.password == "") {
+		return pb.tokenExpireAt
+	}
+	lifetime := pb.tokenExpireAt.Sub(pb.tokenIssuedAt)
+	return pb.tokenIssuedAt.Add(time.Duration(float64(lifetime) * refreshAtLifetimeRatio))
+}
+
+// setToken 保存新token，并从JWT声明中读取过期时间和是否可刷新
+func (pb *Client) setToken(token string) time.Time {
+	now := time.Now()
+	claims, err := parseTokenClaims(token)
+	if err != nil {
+		slog.Warn("PocketBase 无法解析token过期时间", "op", "pocketbase.auth", "fallback_ttl", fallbackTokenTTL, logging.Err(err))
+		claims = &tokenClaims{ExpireAt: now.Add(fallbackTokenTTL), Refreshable: true}
+	}
+
+	pb.mu.Lock()
+	pb.authToken = token
+	pb.tokenIssuedAt = now
+	pb.tokenExpireAt = claims.ExpireAt
+	pb.tokenRefreshable = claims.Refreshable
+	pb.mu.Unlock()
+	return claims.ExpireAt
+}
+
+// tokenUsable 判断token是否存在且未进入提前刷新窗口
+func tokenUsable(token string, expireAt time.Time) bool {
+	return token != "" && time.Now().Add(tokenRefreshLeeway).Before(expireAt)
+}
+
+// parseTokenClaims 解析JWT载荷中的exp和refreshable声明（不校验签名，签名由PocketBase负责）
+func parseTokenClaims(token string) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token不是有效的JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("解码JWT载荷失败: %w", err)
+	}
+
+	var raw struct {
+		Exp         float64 `json:"exp"`
+		Refreshable *bool   `json:"refreshable"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("解析JWT载荷失败: %w", err)
+	}
+	if raw.Exp <= 0 {
+		return nil, fmt.Errorf("JWT缺少exp声明")
+	}
+
+	sec, frac := math.Modf(raw.Exp)
+	claims := &tokenClaims{
+		ExpireAt: time.Unix(int64(sec), int64(frac*1e9)),
+		// 旧版PocketBase不带refreshable声明，视为可刷新
+		Refreshable: raw.Refreshable == nil || *raw.Refreshable,
+	}
+	return claims, nil
+}
+
+// authEndpoint 返回当前认证集合下的认证端点
+func (pb *Client) authEndpoint(action string) string {
+	collection := pb.AuthCollection
+	if collection == "" {
+		collection = UsersCollection
+	}
+	return "/api/collections/" + url.PathEscape(collection) + "/" + action
+}
+
+// ensureAuthenticated 确保客户端已认证并且token有效，返回可用的token
+func (pb *Client) ensureAuthenticated(ctx context.Context) (string, error) {
+	token, expireAt := pb.tokenState()
+	if tokenUsable(token, expireAt) {
+		return token, nil
+	}
+	return pb.renewToken(ctx, token, false)
+}
+
+// renewToken 以single-flight方式续期token。
+// stale 是调用方认为已失效的token：等待authMu期间若其他goroutine已经换到了新token，
+// 则直接复用，避免一波401或过期请求触发多次并发登录。
+// rejected 表示服务端已用401拒绝了该token，此时auth-refresh同样会失败，直接走密码登录。
+// 续期结果由所有等待的请求共享，因此不随触发续期的请求一起取消。
+func (pb *Client) renewToken(ctx context.Context, stale string, rejected bool) (string, error) {
+	ctx = context.WithoutCancel(ctx)
+	pb.authMu.Lock()
+	defer pb.authMu.Unlock()
+
+	token, expireAt := pb.tokenState()
+	if token != stale && tokenUsable(token, expireAt) {
+		return token, nil
+	}
+
+	pb.mu.RLock()
+	refreshable := pb.tokenRefreshable
+	pb.mu.RUnlock()
+
+	// 优先用auth-refresh续期，失败后才回退到密码登录
+	if !rejected && refreshable && token != "" && time.Now().Before(expireAt) {
+		err := pb.refresh(ctx, token)
+		if err == nil {
+			token, _ = pb.tokenState()
+			return token, nil
+		}
+		slog.WarnContext(ctx, "PocketBase 刷新token失败，改用密码登录", "op", "pocketbase.refresh", logging.Err(err))
+	}
+
+	email, password := pb.credentials()
+	if email == "" || password == "" {
+		if token != "" && !rejected && time.Now().Before(expireAt) {
+			// 预签发token无法续期，但在真正过期前仍可继续使用
+			return token, nil
+		}
+		if pb.AuthMode() == AuthModeToken {
+			return "", fmt.Errorf("预签发token已过期或被拒绝，且未配置后备密码凭据")
+		}
+		return "", fmt.Errorf("缺少认证信息")
+	}
+	slog.InfoContext(ctx, "PocketBase token即将过期或已失效，重新登录", "op", "pocketbase.login", "rejected", rejected)
+	if err := pb.login(ctx, email, password); err != nil {
+		return "", err
+	}
+
+	token, _ = pb.tokenState()
+	return token, nil
+}
+
+// Login 用户登录认证，可被多个goroutine并发调用
+func (pb *Client) Login(email, password string) error {
+	// 保存认证信息用于后续自动重新登录
+	pb.SetCredentials(email, password)
+
+	pb.authMu.Lock()
+	defer pb.authMu.Unlock()
+	return pb.login(context.Background(), email, password)
+}
+
+// RefreshAuth 通过auth-refresh续期token，失败时回退到密码登录
+func (pb *Client) RefreshAuth() error {
+	token, _ := pb.tokenState()
+	_, err := pb.renewToken(context.Background(), token, false)
+	return err
+}
+
+// login 执行密码登录并保存token，调用方必须持有authMu
+func (pb *Client) login(ctx context.Context, email, password string) error {
+	loginReq := LoginRequest{
+		Identity: email,
+		Password: password,
+	}
+	payload, err := json.Marshal(loginReq)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := pb.doRequest(ctx, "POST", pb.authEndpoint("auth-with-password"), payload, "")
+	if err != nil {
+		return fmt.Errorf("登录请求失败: %w", err)
+	}
+
+	authResp, err := decodeAuthResponse(resp, "登录")
+	if err != nil {
+		return err
+	}
+
+	expireAt := pb.setToken(authResp.Token)
+	slog.InfoContext(ctx, "PocketBase 登录成功", "op", "pocketbase.login", "collection", pb.AuthCollection, "expire_at", expireAt)
+	return nil
+}
+
+// refresh 调用auth-refresh用当前token换取新token，调用方必须持有authMu
+func (pb *Client) refresh(ctx context.Context, token string) error {
+	resp, err := pb.doRequest(ctx, "POST", pb.authEndpoint("auth-refresh"), nil, token)
+	if err != nil {
+		return fmt.Errorf("刷新请求失败: %w", err)
+	}
+
+	authResp, err := decodeAuthResponse(resp, "刷新")
+	if err != nil {
+		return err
+	}
+
+	expireAt := pb.setToken(authResp.Token)
+	slog.InfoContext(ctx, "PocketBase token刷新成功", "op", "pocketbase.refresh", "expire_at", expireAt)
+	return nil
+}
+
+// decodeAuthResponse 解析认证端点的响应并关闭响应体
+func decodeAuthResponse(resp *http.Response, action string) (*AuthResponse, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s失败，状态码 %d: %s", action, resp.StatusCode, string(body))
+	}
+
+	var authResp AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		return nil, fmt.Errorf("解析认证响应失败: %w", err)
+	}
+	if authResp.Token == "" {
+		return nil, fmt.Errorf("%s响应中缺少token", action)
+	}
+	return &authResp, nil
+}
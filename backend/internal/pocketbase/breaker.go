@@ -0,0 +1,141 @@
+package pocketbase
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开时直接返回的错误，不会发出任何请求
+var ErrCircuitOpen = errors.New("PocketBase熔断器已打开，暂停请求")
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerStatus 熔断器状态快照
+type BreakerStatus struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+}
+
+// CircuitBreaker 连续失败计数熔断器。
+// 连续失败达到阈值后打开，冷却期内所有请求快速失败；冷却期结束后进入半开状态，
+// 只放行一个探测请求，成功则关闭，失败则重新打开。
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	lastErr       error
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+// NewCircuitBreaker 创建熔断器，threshold<=0 表示禁用熔断
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow 判断是否允许发出请求
+func (b *CircuitBreaker) Allow() bool {
+	if b == nil || b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probeInFlight = true
+		return true
+	case BreakerHalfOpen:
+		// 半开状态只允许一个探测请求在途
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess 记录一次成功，关闭熔断器
+func (b *CircuitBreaker) RecordSuccess() {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probeInFlight = false
+}
+
+// RecordFailure 记录一次失败，达到阈值或半开探测失败时打开熔断器
+func (b *CircuitBreaker) RecordFailure(err error) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastErr = err
+	b.probeInFlight = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release 放弃本次请求的结果，既不计为成功也不计为失败。
+// 用于调用方取消的请求：半开状态下释放探测名额，熔断器保持半开，下一个请求继续探测
+func (b *CircuitBreaker) Release() {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeInFlight = false
+}
+
+// Status 返回熔断器当前状态
+func (b *CircuitBreaker) Status() BreakerStatus {
+	if b == nil || b.threshold <= 0 {
+		return BreakerStatus{State: BreakerClosed}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	if b.state != BreakerClosed {
+		status.OpenedAt = b.openedAt
+	}
+	return status
+}
@@ -0,0 +1,110 @@
+package pocketbase
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	b := NewCircuitBreaker(3, 50*time.Millisecond)
+	boom := errors.New("boom")
+
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatalf("breaker should allow requests before threshold (failure %d)", i)
+		}
+		b.RecordFailure(boom)
+	}
+	if got := b.Status().State; got != BreakerClosed {
+		t.Fatalf("expected closed below threshold, got %s", got)
+	}
+
+	b.RecordFailure(boom)
+	status := b.Status()
+	if status.State != BreakerOpen || status.ConsecutiveFailures != 3 || status.LastError != "boom" {
+		t.Fatalf("expected open after 3 failures, got %+v", status)
+	}
+	if b.Allow() {
+		t.Fatal("open breaker must reject requests during cooldown")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("breaker should allow a probe after cooldown")
+	}
+	if b.Allow() {
+		t.Fatal("half-open breaker must allow only one probe in flight")
+	}
+
+	// 探测失败，重新打开
+	b.RecordFailure(boom)
+	if got := b.Status().State; got != BreakerOpen {
+		t.Fatalf("failed probe should reopen breaker, got %s", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("breaker should allow a probe after second cooldown")
+	}
+	b.RecordSuccess()
+	status = b.Status()
+	if status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Fatalf("successful probe should close breaker, got %+v", status)
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	b := NewCircuitBreaker(1, 10*time.Millisecond)
+	b.RecordFailure(errors.New("boom"))
+	time.Sleep(20 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatal("breaker should allow a probe after cooldown")
+	}
+	// 探测请求被取消，释放名额但保持半开
+	b.Release()
+	status := b.Status()
+	if status.State != BreakerHalfOpen || status.ConsecutiveFailures != 1 {
+		t.Fatalf("released probe should keep breaker half-open, got %+v", status)
+	}
+	if !b.Allow() {
+		t.Fatal("breaker should allow a new probe after release")
+	}
+	if b.Allow() {
+		t.Fatal("half-open breaker must still allow only one probe in flight")
+	}
+}
+
+func TestDisabledCircuitBreaker(t *testing.T) {
+	for _, b := range []*CircuitBreaker{nil, NewCircuitBreaker(0, time.Second)} {
+		for i := 0; i < 10; i++ {
+			b.RecordFailure(errors.New("boom"))
+		}
+		if !b.Allow() {
+			t.Error("disabled breaker must always allow requests")
+		}
+		if got := b.Status().State; got != BreakerClosed {
+			t.Errorf("disabled breaker should report closed, got %s", got)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	bounds := []struct{ min, max time.Duration }{
+		{50 * time.Millisecond, 100 * time.Millisecond},
+		{100 * time.Millisecond, 200 * time.Millisecond},
+		{150 * time.Millisecond, 300 * time.Millisecond}, // 400ms 被限制到 300ms
+		{150 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for i, bound := range bounds {
+		for n := 0; n < 20; n++ {
+			d := p.backoff(i + 1)
+			if d < bound.min || d > bound.max {
+				t.Fatalf("retry %d: backoff %v outside [%v, %v]", i+1, d, bound.min, bound.max)
+			}
+		}
+	}
+}
@@ -1,37 +1,63 @@
 package pocketbase
 
 import (
+	"backend/internal/logging"
+	"backend/internal/tracing"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Client PocketBase API 客户端
+// Client PocketBase API 客户端，可安全地被多个goroutine并发使用
 type Client struct {
-	BaseURL       string
-	HTTPClient    *http.Client
-	AuthToken     string
-	Email         string // 保存认证信息用于自动重新登录
-	Password      string
-	TokenExpireAt time.Time // Token过期时间
+	BaseURL        string
+	HTTPClient     *http.Client
+	AuthCollection string // 认证集合：users 或 _superusers
+	Retry          RetryPolicy
+	Breaker        *CircuitBreaker // 为nil时不熔断
+	// OnRequest 每次HTTP请求结束后调用，用于记录耗时和错误；status为0表示没有收到响应
+	OnRequest func(method, endpoint string, status int, duration time.Duration, err error)
+
+	mu            sync.RWMutex // 保护下面的认证状态
+	authToken     string
+	email         string // 保存认证信息用于自动重新登录
+	password      string
+	tokenIssuedAt time.Time // Token获取时间
+	tokenExpireAt time.Time // Token过期时间（来自JWT的exp声明）
+	// tokenRefreshable 当前token是否允许auth-refresh
+	tokenRefreshable bool
+	// staticToken 是否使用预签发token（AuthModeToken）
+	staticToken bool
+
+	authMu sync.Mutex // 串行化登录，保证同一时刻最多一个登录请求在途
 }
 
 // System 表示服务器/系统记录
 type System struct {
-	ID             string   `json:"id"`
-	CollectionID   string   `json:"collectionId"`
-	CollectionName string   `json:"collectionName"`
-	Created        string   `json:"created"`
-	Updated        string   `json:"updated"`
-	Name           string   `json:"name"`
-	Host           string   `json:"host"`
-	Port           string   `json:"port"`
-	Status         string   `json:"status"`
-	Users          []string `json:"users"`
+	ID             string     `json:"id"`
+	CollectionID   string     `json:"collectionId"`
+	CollectionName string     `json:"collectionName"`
+	Created        string     `json:"created"`
+	Updated        string     `json:"updated"`
+	Name           string     `json:"name"`
+	Host           string     `json:"host"`
+	Port           string     `json:"port"`
+	Status         string     `json:"status"`
+	Users          []string   `json:"users"`
+	Info           SystemInfo `json:"info"`
 }
 
 // SystemStats 表示系统统计记录
@@ -46,16 +72,6 @@ type SystemStats struct {
 	Stats          StatsData `json:"stats"`
 }
 
-// StatsData 包含实际的指标数据
-type StatsData struct {
-	CPU         float64 `json:"cpu"`
-	Mem         float64 `json:"m"`  // 总内存 GB
-	MemUsed     float64 `json:"mu"` // 使用内存 GB
-	MemPct      float64 `json:"mp"` // 内存使用百分比
-	NetworkSent float64 `json:"ns"` // 网络发送（字节/秒）
-	NetworkRecv float64 `json:"nr"` // 网络接收（字节/秒）
-}
-
 // 自定义JSON解码，处理stats字段
 func (s *SystemStats) UnmarshalJSON(data []byte) error {
 	type Alias SystemStats
@@ -91,22 +107,6 @@ func (s *SystemStats) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// AuthResponse 认证响应
-type AuthResponse struct {
-	Token  string `json:"token"`
-	Record struct {
-		ID       string `json:"id"`
-		Email    string `json:"email"`
-		Username string `json:"username"`
-	} `json:"record"`
-}
-
-// LoginRequest 登录请求
-type LoginRequest struct {
-	Identity string `json:"identity"`
-	Password string `json:"password"`
-}
-
 // ListResponse PocketBase API 响应包装器
 type ListResponse[T any] struct {
 	Page       int `json:"page"`
@@ -123,167 +123,311 @@ func NewClient(baseURL string) *Client {
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		AuthCollection: UsersCollection,
+		Retry:          DefaultRetryPolicy,
+		Breaker:        NewCircuitBreaker(5, 30*time.Second),
 	}
 }
 
-// ensureAuthenticated 确保客户端已认证并且token有效
-func (pb *Client) ensureAuthenticated() error {
-	// 检查token是否即将过期（提前5分钟刷新）
-	if pb.AuthToken == "" || time.Now().Add(5*time.Minute).After(pb.TokenExpireAt) {
-		if pb.Email == "" || pb.Password == "" {
-			return fmt.Errorf("缺少认证信息")
-		}
-		fmt.Printf("[PocketBase] Token即将过期或已过期，重新登录...\n")
-		return pb.Login(pb.Email, pb.Password)
+// BreakerStatus 返回熔断器状态
+func (pb *Client) BreakerStatus() BreakerStatus {
+	return pb.Breaker.Status()
+}
+
+// Ping 请求PocketBase的 /api/health，用于就绪检查。
+// 不携带token、不重试，也不经过熔断器，探测结果不影响正常请求
+func (pb *Client) Ping(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "PocketBase GET /api/health")
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pb.BaseURL+"/api/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := pb.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PocketBase健康检查返回状态码 %d", resp.StatusCode)
 	}
 	return nil
 }
 
-// makeRequest 向PocketBase API发送HTTP请求
-func (pb *Client) makeRequest(method, endpoint string, body interface{}) (*http.Response, error) {
-	// 对于非认证请求，确保token有效
-	if !isAuthEndpoint(endpoint) {
-		if err := pb.ensureAuthenticated(); err != nil {
-			return nil, fmt.Errorf("认证失败: %w", err)
+// makeRequest 向PocketBase API发送HTTP请求。
+// GET请求在网络错误、429和5xx时按Retry策略退避重试；熔断器打开时直接返回ErrCircuitOpen。
+// ctx取消后不再重试，ctx中的span作为本次调用span的父span
+func (pb *Client) makeRequest(ctx context.Context, method, endpoint string, body interface{}) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(ctx, "PocketBase "+method+" "+NormalizeEndpoint(endpoint),
+		attribute.String("pocketbase.endpoint", endpoint))
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 		}
-	}
-
-	var bodyReader io.Reader
+		tracing.End(span, err)
+	}()
 
+	// 请求体只序列化一次，每次发送时重新包装reader，保证重试时可以重放
+	var payload []byte
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
+		payload = jsonBody
 	}
 
-	req, err := http.NewRequest(method, pb.BaseURL+endpoint, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	attempts := 1
+	if method == http.MethodGet {
+		attempts = pb.Retry.attempts()
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if pb.AuthToken != "" {
-		req.Header.Set("Authorization", "Bearer "+pb.AuthToken)
+	for attempt := 1; ; attempt++ {
+		resp, err := pb.sendAuthenticated(ctx, method, endpoint, payload)
+		if attempt >= attempts {
+			return resp, err
+		}
+
+		switch {
+		case err != nil && !isRetryableError(err):
+			return nil, err
+		case err == nil && !isRetryableStatus(resp.StatusCode):
+			return resp, nil
+		case err == nil:
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+
+		delay := pb.Retry.backoff(attempt)
+		slog.WarnContext(ctx, "PocketBase 请求失败，稍后重试", "op", "pocketbase.request",
+			"method", method, "endpoint", endpoint, "attempt", attempt, "delay", delay, logging.Err(err))
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
+}
 
-	resp, err := pb.HTTPClient.Do(req)
+// sendAuthenticated 携带token发送一次请求，收到401时重新登录（并发时只会登录一次）后重发一次
+func (pb *Client) sendAuthenticated(ctx context.Context, method, endpoint string, payload []byte) (*http.Response, error) {
+	token, err := pb.ensureAuthenticated(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("认证失败: %w", err)
+	}
+
+	resp, err := pb.doRequest(ctx, method, endpoint, payload, token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
 
-	// 如果收到401未授权错误，尝试重新登录一次
-	if resp.StatusCode == http.StatusUnauthorized && !isAuthEndpoint(endpoint) {
+	if resp.StatusCode == http.StatusUnauthorized {
 		resp.Body.Close()
-		if err := pb.Login(pb.Email, pb.Password); err != nil {
-			return nil, fmt.Errorf("重新登录失败: %w", err)
-		}
-		// 重新构建请求
-		req, err = http.NewRequest(method, pb.BaseURL+endpoint, bodyReader)
+		token, err = pb.renewToken(ctx, token, true)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+			return nil, fmt.Errorf("重新登录失败: %w", err)
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+pb.AuthToken)
-		return pb.HTTPClient.Do(req)
+		return pb.doRequest(ctx, method, endpoint, payload, token)
 	}
 
 	return resp, nil
 }
 
-// isAuthEndpoint 检查是否为认证相关的端点
-func isAuthEndpoint(endpoint string) bool {
-	return endpoint == "/api/collections/users/auth-with-password" ||
-		endpoint == "/api/collections/users/auth-refresh"
-}
+// doRequest 构建并发送单次HTTP请求，每次发送（包括重试和登录）对应一个client span，
+// 并通过traceparent头把trace传给PocketBase
+func (pb *Client) doRequest(ctx context.Context, method, endpoint string, payload []byte, token string) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(ctx, "HTTP "+method,
+		attribute.String("http.request.method", method),
+		attribute.String("url.path", NormalizeEndpoint(endpoint)),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
 
-// Login 用户登录认证
-func (pb *Client) Login(email, password string) error {
-	// 保存认证信息用于后续自动重新登录
-	pb.Email = email
-	pb.Password = password
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
 
-	loginReq := LoginRequest{
-		Identity: email,
-		Password: password,
+	req, err := http.NewRequestWithContext(ctx, method, pb.BaseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	if !pb.Breaker.Allow() {
+		pb.observe(method, endpoint, 0, 0, ErrCircuitOpen)
+		return nil, ErrCircuitOpen
 	}
 
-	resp, err := pb.makeRequest("POST", "/api/collections/users/auth-with-password", loginReq)
+	start := time.Now()
+	resp, err = pb.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("登录请求失败: %w", err)
+		pb.observe(method, endpoint, 0, time.Since(start), err)
+		// 调用方取消（如客户端断开）不代表PocketBase不健康，只释放半开状态的探测名额
+		if ctx.Err() == nil {
+			pb.Breaker.RecordFailure(err)
+		} else {
+			pb.Breaker.Release()
+		}
+		return nil, &transportError{fmt.Errorf("failed to execute request: %w", err)}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("登录失败，状态码 %d: %s", resp.StatusCode, string(body))
+	pb.observe(method, endpoint, resp.StatusCode, time.Since(start), nil)
+
+	// 只有5xx视为PocketBase不健康，4xx说明服务端可达
+	if resp.StatusCode >= http.StatusInternalServerError {
+		pb.Breaker.RecordFailure(fmt.Errorf("%s %s 返回状态码 %d", method, endpoint, resp.StatusCode))
+	} else {
+		pb.Breaker.RecordSuccess()
 	}
+	return resp, nil
+}
 
-	var authResp AuthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		return fmt.Errorf("解析认证响应失败: %w", err)
+// NormalizeEndpoint 去掉查询参数和记录ID，用于指标标签和span名称，保证取值有限
+// （/api/collections/systems/records/abc → /api/collections/systems/records/:id）
+func NormalizeEndpoint(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil {
+		endpoint = u.Path
+	}
+	parts := strings.Split(endpoint, "/")
+	if len(parts) > 5 && parts[4] == "records" {
+		parts = append(parts[:5], ":id")
 	}
+	return strings.Join(parts, "/")
+}
 
-	pb.AuthToken = authResp.Token
-	// PocketBase JWT token默认有效期为14天，我们设置为13天后过期以确保安全
-	pb.TokenExpireAt = time.Now().Add(13 * 24 * time.Hour)
-	fmt.Printf("[PocketBase] 登录成功，Token将在 %s 过期\n", pb.TokenExpireAt.Format("2006-01-02 15:04:05"))
-	return nil
+// observe 调用OnRequest回调
+func (pb *Client) observe(method, endpoint string, status int, duration time.Duration, err error) {
+	if pb.OnRequest != nil {
+		pb.OnRequest(method, endpoint, status, duration, err)
+	}
 }
 
-// RefreshAuth 刷新认证token
-func (pb *Client) RefreshAuth() error {
-	if pb.Email == "" || pb.Password == "" {
-		return fmt.Errorf("缺少认证信息")
+// ListSystems 获取所有系统/服务器，自动翻页
+func (pb *Client) ListSystems(ctx context.Context) ([]System, error) {
+	params := url.Values{}
+	params.Set("sort", "-created,id")
+
+	items, truncated, err := listAll[System](ctx, pb, "systems", params, Filter{}, maxSystems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch systems: %w", err)
 	}
-	return pb.Login(pb.Email, pb.Password)
+	if truncated {
+		slog.WarnContext(ctx, "系统数量超过上限，只返回部分系统", "op", "pocketbase.list_systems", "limit", maxSystems)
+	}
+	return items, nil
 }
 
-// ListSystems 获取所有系统/服务器
-func (pb *Client) ListSystems() (*ListResponse[System], error) {
+// GetSystemLoadAverage 获取指定系统的负载平均值数据
+func (pb *Client) GetSystemLoadAverage(ctx context.Context, systemID string, count int) (*ListResponse[SystemStats], error) {
 	params := url.Values{}
 	params.Set("page", "1")
-	params.Set("perPage", "50")
+	params.Set("perPage", fmt.Sprintf("%d", count))
 	params.Set("sort", "-created")
 
-	endpoint := "/api/collections/systems/records?" + params.Encode()
+	// 只过滤该系统的1m类型数据
+	filter := And(Eq("system", systemID), Eq("type", "1m"))
 
-	resp, err := pb.makeRequest("GET", endpoint, nil)
+	result, err := getList[SystemStats](ctx, pb, "system_stats", params, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch systems: %w", err)
+		return nil, fmt.Errorf("failed to fetch system stats: %w", err)
 	}
-	defer resp.Body.Close()
+	return result, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+// StatsQuery system_stats的时间范围查询条件
+type StatsQuery struct {
+	SystemID   string
+	Type       string    // 1m, 10m, 20m, 120m, 480m
+	From       time.Time // 为零值时不限制起始时间
+	To         time.Time // 为零值时不限制结束时间
+	MaxRecords int       // 最多返回的记录数，<=0 时使用 maxStatsRecords
+	// Fields 只返回stats中的这些key（如 cpu、mu），通过PocketBase的fields参数在服务端裁剪，为空时返回全部
+	Fields []string
+}
+
+const (
+	// listPageSize 分页拉取记录时每页的记录数
+	listPageSize = 500
+	// maxSystems 系统列表最多拉取的记录数
+	maxSystems = 10000
+	// maxStatsRecords 单次范围查询最多拉取的记录数，防止误用时拉取整张表
+	maxStatsRecords = 20000
+)
+
+// ListSystemStats 按时间范围查询系统统计数据，自动翻页，结果按时间升序排列。
+// 记录数超过上限时保留最新的记录，truncated 为true
+func (pb *Client) ListSystemStats(ctx context.Context, q StatsQuery) (items []SystemStats, truncated bool, err error) {
+	filter := And(
+		Eq("system", q.SystemID),
+		Eq("type", q.Type),
+		TimeRange("created", q.From, q.To),
+	)
+
+	params := url.Values{}
+	// 从最新的记录开始拉取，超过上限时丢弃的是最早的记录
+	params.Set("sort", "-created")
+	if len(q.Fields) > 0 {
+		fields := []string{"id", "system", "type", "created"}
+		for _, field := range q.Fields {
+			fields = append(fields, "stats."+field)
+		}
+		params.Set("fields", strings.Join(fields, ","))
 	}
 
-	var result ListResponse[System]
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	limit := q.MaxRecords
+	if limit <= 0 || limit > maxStatsRecords {
+		limit = maxStatsRecords
 	}
 
-	return &result, nil
+	items, truncated, err = listAll[SystemStats](ctx, pb, "system_stats", params, filter, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch system stats: %w", err)
+	}
+	slices.Reverse(items)
+	return items, truncated, nil
 }
 
-// GetSystemLoadAverage 获取指定系统的负载平均值数据
-func (pb *Client) GetSystemLoadAverage(systemID string, count int) (*ListResponse[SystemStats], error) {
-	params := url.Values{}
-	params.Set("page", "1")
-	params.Set("perPage", fmt.Sprintf("%d", count))
-	params.Set("sort", "-created")
+// formatTime 将时间格式化为PocketBase过滤表达式使用的UTC日期格式
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000Z")
+}
 
-	// 只过滤该系统的1m类型数据
-	filter := fmt.Sprintf(`system = "%s" && type = "1m"`, systemID)
-	params.Set("filter", filter)
+// getList 获取集合的一页记录，过滤条件必须通过Filter构造
+func getList[T any](ctx context.Context, pb *Client, collection string, params url.Values, filter Filter) (*ListResponse[T], error) {
+	expr, err := filter.Build()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if expr != "" {
+		params.Set("filter", expr)
+	} else {
+		params.Del("filter")
+	}
 
-	endpoint := "/api/collections/system_stats/records?" + params.Encode()
+	endpoint := "/api/collections/" + collection + "/records?" + params.Encode()
 
-	resp, err := pb.makeRequest("GET", endpoint, nil)
+	resp, err := pb.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch system stats: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -292,10 +436,37 @@ func (pb *Client) GetSystemLoadAverage(systemID string, count int) (*ListRespons
 		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var result ListResponse[SystemStats]
+	var result ListResponse[T]
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	return &result, nil
-}
\ No newline at end of file
+}
+
+// listAll 逐页拉取集合记录，直到没有更多数据或超过limit。
+// 超过limit时只返回前limit条，truncated 为true
+func listAll[T any](ctx context.Context, pb *Client, collection string, params url.Values, filter Filter, limit int) (items []T, truncated bool, err error) {
+	params.Set("perPage", strconv.Itoa(listPageSize))
+	// 不需要总数，跳过PocketBase的COUNT查询
+	params.Set("skipTotal", "1")
+
+	// 多拉取一条以判断是否还有更多记录
+	for page := 1; len(items) <= limit; page++ {
+		params.Set("page", strconv.Itoa(page))
+		result, err := getList[T](ctx, pb, collection, params, filter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items = append(items, result.Items...)
+		if len(result.Items) < listPageSize {
+			break
+		}
+	}
+
+	if len(items) > limit {
+		return items[:limit], true, nil
+	}
+	return items, false, nil
+}
@@ -0,0 +1,638 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// fakePocketBase 模拟PocketBase的最小实现：登录/刷新签发带exp的JWT，接受所有未吊销的token
+type fakePocketBase struct {
+	mu        sync.Mutex
+	valid     map[string]bool
+	logins    atomic.Int32
+	refreshes atomic.Int32
+	// 认证集合，默认 users
+	collection string
+	// 签发token的有效期，默认1小时
+	tokenTTL time.Duration
+	// 为true时auth-refresh返回403，用于测试回退到密码登录
+	refreshDisabled bool
+	// 每次登录前的人为延迟，放大并发登录的竞争窗口
+	loginDelay time.Duration
+	// 接下来多少个数据请求返回503
+	failNext atomic.Int32
+	// 数据请求计数（不含认证）
+	requests atomic.Int32
+	// 每个数据请求的人为延迟，单位纳秒
+	requestDelay atomic.Int64
+}
+
+// makeJWT 构造载荷包含exp的JWT（签名部分无意义）
+func makeJWT(id string, exp time.Time) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := enc.EncodeToString([]byte(fmt.Sprintf(`{"id":%q,"type":"auth","exp":%d}`, id, exp.Unix())))
+	return header + "." + payload + ".signature"
+}
+
+func (f *fakePocketBase) authorized(r *http.Request) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.valid[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+}
+
+// revoke 使已签发的token全部失效，模拟服务端轮换密钥
+func (f *fakePocketBase) revoke() {
+	f.mu.Lock()
+	f.valid = nil
+	f.mu.Unlock()
+}
+
+// issue 签发一个新token
+func (f *fakePocketBase) issue(w http.ResponseWriter, id string) {
+	ttl := f.tokenTTL
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+	token := makeJWT(id, time.Now().Add(ttl))
+	f.mu.Lock()
+	if f.valid == nil {
+		f.valid = make(map[string]bool)
+	}
+	f.valid[token] = true
+	f.mu.Unlock()
+	json.NewEncoder(w).Encode(AuthResponse{Token: token})
+}
+
+func (f *fakePocketBase) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	collection := f.collection
+	if collection == "" {
+		collection = UsersCollection
+	}
+	authPrefix := "/api/collections/" + collection + "/"
+
+	switch {
+	case r.URL.Path == authPrefix+"auth-with-password":
+		var req LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password != "secret" {
+			http.Error(w, `{"message":"invalid credentials"}`, http.StatusBadRequest)
+			return
+		}
+		time.Sleep(f.loginDelay)
+		f.issue(w, fmt.Sprintf("login-%d", f.logins.Add(1)))
+		return
+	case !f.authorized(r):
+		http.Error(w, `{"message":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	case r.URL.Path == authPrefix+"auth-refresh":
+		if f.refreshDisabled {
+			http.Error(w, `{"message":"refresh not allowed"}`, http.StatusForbidden)
+			return
+		}
+		f.issue(w, fmt.Sprintf("refresh-%d", f.refreshes.Add(1)))
+		return
+	}
+
+	f.requests.Add(1)
+	time.Sleep(time.Duration(f.requestDelay.Load()))
+	if f.failNext.Add(-1) >= 0 {
+		http.Error(w, `{"message":"hub overloaded"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/collections/systems/records":
+		json.NewEncoder(w).Encode(ListResponse[System]{
+			Page:  1,
+			Items: []System{{ID: "sys1", Name: "server-1", Status: "up"}},
+		})
+	case "/api/echo":
+		io.Copy(w, r.Body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestClient(t *testing.T, fake *fakePocketBase) *Client {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := NewClient(srv.URL)
+	client.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	client.mu.Lock()
+	client.email = "admin@example.com"
+	client.password = "secret"
+	client.mu.Unlock()
+	return client
+}
+
+// runConcurrently 并发执行fn n次并收集错误
+func runConcurrently(n int, fn func() error) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn()
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestConcurrentRequestsLoginOnce(t *testing.T) {
+	fake := &fakePocketBase{loginDelay: 50 * time.Millisecond}
+	client := newTestClient(t, fake)
+
+	errs := runConcurrently(32, func() error {
+		_, err := client.ListSystems(context.Background())
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("ListSystems failed: %v", err)
+		}
+	}
+
+	if got := fake.logins.Load(); got != 1 {
+		t.Errorf("expected exactly 1 login, got %d", got)
+	}
+}
+
+func TestUnauthorizedBurstReauthenticatesOnce(t *testing.T) {
+	fake := &fakePocketBase{loginDelay: 50 * time.Millisecond}
+	client := newTestClient(t, fake)
+
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("initial ListSystems failed: %v", err)
+	}
+
+	// 服务端吊销token后，所有在途请求都会拿到401
+	fake.revoke()
+
+	errs := runConcurrently(32, func() error {
+		_, err := client.ListSystems(context.Background())
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("ListSystems after revoke failed: %v", err)
+		}
+	}
+
+	if got := fake.logins.Load(); got != 2 {
+		t.Errorf("expected 2 logins (initial + one re-auth), got %d", got)
+	}
+}
+
+func TestUnauthorizedRetryReplaysBody(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("initial ListSystems failed: %v", err)
+	}
+	fake.revoke()
+
+	resp, err := client.makeRequest(context.Background(), "POST", "/api/echo", map[string]string{"hello": "world"})
+	if err != nil {
+		t.Fatalf("makeRequest failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 after retry, got %d", resp.StatusCode)
+	}
+	echoed, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(echoed), `"hello":"world"`) {
+		t.Errorf("retried request lost its body, server echoed %q", echoed)
+	}
+}
+
+func TestConcurrentLoginAndRequests(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+
+	// 显式Login与普通请求、RefreshAuth交错执行，由race detector检查数据竞争
+	errs := runConcurrently(24, func() error {
+		if err := client.Login("admin@example.com", "secret"); err != nil {
+			return err
+		}
+		if err := client.RefreshAuth(); err != nil {
+			return err
+		}
+		_, err := client.ListSystems(context.Background())
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent operation failed: %v", err)
+		}
+	}
+}
+
+func TestMissingCredentials(t *testing.T) {
+	fake := &fakePocketBase{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	if _, err := client.ListSystems(context.Background()); err == nil {
+		t.Fatal("expected error without credentials")
+	}
+	if got := fake.logins.Load(); got != 0 {
+		t.Errorf("expected no login attempts, got %d", got)
+	}
+}
+
+func TestParseTokenClaims(t *testing.T) {
+	exp := time.Now().Add(72 * time.Hour).Truncate(time.Second)
+	claims, err := parseTokenClaims(makeJWT("abc", exp))
+	if err != nil {
+		t.Fatalf("parseTokenClaims failed: %v", err)
+	}
+	if !claims.ExpireAt.Equal(exp) {
+		t.Errorf("expected %v, got %v", exp, claims.ExpireAt)
+	}
+	if !claims.Refreshable {
+		t.Error("token without refreshable claim should be treated as refreshable")
+	}
+
+	claims, err = parseTokenClaims(makeImpersonateJWT(exp))
+	if err != nil || claims.Refreshable {
+		t.Errorf("impersonate token should be non-refreshable, got %+v, %v", claims, err)
+	}
+
+	for _, bad := range []string{"", "not-a-jwt", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte(`{"id":"x"}`)) + ".c"} {
+		if _, err := parseTokenClaims(bad); err == nil {
+			t.Errorf("expected error for token %q", bad)
+		}
+	}
+}
+
+func TestTokenExpiryFromJWT(t *testing.T) {
+	fake := &fakePocketBase{tokenTTL: 48 * time.Hour}
+	client := newTestClient(t, fake)
+
+	if err := client.Login("admin@example.com", "secret"); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	remaining := time.Until(client.TokenExpireAt())
+	if remaining < 47*time.Hour || remaining > 48*time.Hour {
+		t.Errorf("expected expiry ~48h from now, got %v", remaining)
+	}
+
+	refreshIn := time.Until(client.NextRefreshAt())
+	if refreshIn < 38*time.Hour || refreshIn > 39*time.Hour {
+		t.Errorf("expected next refresh at ~80%% of lifetime, got %v", refreshIn)
+	}
+}
+
+func TestNearExpiryUsesAuthRefresh(t *testing.T) {
+	// 有效期短于提前刷新窗口，第二次请求前需要续期
+	fake := &fakePocketBase{tokenTTL: 2 * time.Minute}
+	client := newTestClient(t, fake)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ListSystems(context.Background()); err != nil {
+			t.Fatalf("ListSystems failed: %v", err)
+		}
+	}
+
+	if got := fake.logins.Load(); got != 1 {
+		t.Errorf("expected 1 login, got %d", got)
+	}
+	if got := fake.refreshes.Load(); got != 1 {
+		t.Errorf("expected 1 auth-refresh, got %d", got)
+	}
+}
+
+func TestRefreshFailureFallsBackToLogin(t *testing.T) {
+	fake := &fakePocketBase{refreshDisabled: true}
+	client := newTestClient(t, fake)
+
+	if err := client.Login("admin@example.com", "secret"); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if err := client.RefreshAuth(); err != nil {
+		t.Fatalf("RefreshAuth failed: %v", err)
+	}
+
+	if got := fake.logins.Load(); got != 2 {
+		t.Errorf("expected fallback password login (2 logins), got %d", got)
+	}
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Errorf("ListSystems after fallback failed: %v", err)
+	}
+}
+
+func TestSuperuserAuth(t *testing.T) {
+	fake := &fakePocketBase{collection: SuperusersCollection, tokenTTL: 2 * time.Minute}
+	client := newTestClient(t, fake)
+	client.AuthCollection = SuperusersCollection
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ListSystems(context.Background()); err != nil {
+			t.Fatalf("ListSystems as superuser failed: %v", err)
+		}
+	}
+	if fake.logins.Load() != 1 || fake.refreshes.Load() != 1 {
+		t.Errorf("expected 1 login and 1 refresh against _superusers, got %d/%d",
+			fake.logins.Load(), fake.refreshes.Load())
+	}
+}
+
+// makeImpersonateJWT 构造不可刷新的JWT，模拟impersonate签发的长期token
+func makeImpersonateJWT(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	payload := enc.EncodeToString([]byte(fmt.Sprintf(`{"id":"bot","type":"auth","refreshable":false,"exp":%d}`, exp.Unix())))
+	return enc.EncodeToString([]byte(`{"alg":"HS256"}`)) + "." + payload + ".signature"
+}
+
+// register 将一个外部签发的token登记为有效
+func (f *fakePocketBase) register(token string) {
+	f.mu.Lock()
+	if f.valid == nil {
+		f.valid = make(map[string]bool)
+	}
+	f.valid[token] = true
+	f.mu.Unlock()
+}
+
+func TestStaticTokenAuth(t *testing.T) {
+	fake := &fakePocketBase{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	token := makeImpersonateJWT(time.Now().Add(365 * 24 * time.Hour))
+	fake.register(token)
+
+	client := NewClient(srv.URL)
+	client.SetAuthToken(token)
+
+	if client.AuthMode() != AuthModeToken {
+		t.Errorf("expected token auth mode, got %s", client.AuthMode())
+	}
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("ListSystems with static token failed: %v", err)
+	}
+	if err := client.RefreshAuth(); err != nil {
+		t.Fatalf("RefreshAuth on valid non-refreshable token failed: %v", err)
+	}
+	if fake.logins.Load() != 0 || fake.refreshes.Load() != 0 {
+		t.Errorf("static token must not trigger login/refresh, got %d/%d",
+			fake.logins.Load(), fake.refreshes.Load())
+	}
+	if !client.NextRefreshAt().Equal(client.TokenExpireAt()) {
+		t.Errorf("non-refreshable token without credentials should not schedule refresh before expiry")
+	}
+}
+
+func TestRejectedStaticTokenWithoutCredentials(t *testing.T) {
+	fake := &fakePocketBase{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.SetAuthToken(makeImpersonateJWT(time.Now().Add(time.Hour)))
+
+	if _, err := client.ListSystems(context.Background()); err == nil {
+		t.Fatal("expected error for rejected static token")
+	}
+	if got := fake.logins.Load(); got != 0 {
+		t.Errorf("expected no login attempts, got %d", got)
+	}
+}
+
+func TestExpiredStaticTokenFallsBackToCredentials(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+	client.SetAuthToken(makeImpersonateJWT(time.Now().Add(-time.Minute)))
+
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("ListSystems failed: %v", err)
+	}
+	if got := fake.logins.Load(); got != 1 {
+		t.Errorf("expected fallback password login, got %d logins", got)
+	}
+}
+
+func TestGetRetriesTransientFailures(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+	fake.failNext.Store(2)
+
+	result, err := client.ListSystems(context.Background())
+	if err != nil {
+		t.Fatalf("ListSystems should succeed after retries: %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("expected 1 system, got %d", len(result))
+	}
+	if got := fake.requests.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestGetGivesUpAfterMaxAttempts(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+	fake.failNext.Store(10)
+
+	if _, err := client.ListSystems(context.Background()); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if got := fake.requests.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestPostIsNotRetried(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+	fake.failNext.Store(1)
+
+	resp, err := client.makeRequest(context.Background(), "POST", "/api/echo", map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("makeRequest failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the 503 to be returned as-is, got %d", resp.StatusCode)
+	}
+	if got := fake.requests.Load(); got != 1 {
+		t.Errorf("POST must not be retried, got %d attempts", got)
+	}
+}
+
+func TestCircuitOpensAndFailsFast(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	client.Breaker = NewCircuitBreaker(2, time.Hour)
+
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("initial ListSystems failed: %v", err)
+	}
+
+	fake.failNext.Store(100)
+	for i := 0; i < 2; i++ {
+		client.ListSystems(context.Background())
+	}
+	if got := client.BreakerStatus().State; got != BreakerOpen {
+		t.Fatalf("expected open breaker after consecutive 5xx, got %s", got)
+	}
+
+	before := fake.requests.Load()
+	start := time.Now()
+	_, err := client.ListSystems(context.Background())
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if fake.requests.Load() != before {
+		t.Error("open breaker must not send requests")
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("open breaker should fail fast")
+	}
+}
+
+func TestTransportErrorsTripBreaker(t *testing.T) {
+	fake := &fakePocketBase{}
+	srv := httptest.NewServer(fake)
+	client := NewClient(srv.URL)
+	client.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	client.Breaker = NewCircuitBreaker(2, time.Hour)
+	client.SetCredentials("admin@example.com", "secret")
+	srv.Close()
+
+	if _, err := client.ListSystems(context.Background()); err == nil {
+		t.Fatal("expected error with hub down")
+	}
+	if got := client.BreakerStatus().State; got != BreakerOpen {
+		t.Errorf("expected breaker to open after connection failures, got %s", got)
+	}
+}
+
+func TestCanceledRequestDoesNotTripBreaker(t *testing.T) {
+	fake := &fakePocketBase{loginDelay: 200 * time.Millisecond}
+	client := newTestClient(t, fake)
+	client.Breaker = NewCircuitBreaker(1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// 登录在续期中共享，不随请求取消；登录之后的数据请求因ctx已超时而失败
+	if _, err := client.ListSystems(ctx); err == nil {
+		t.Fatal("expected error after context deadline")
+	}
+	if got := client.BreakerStatus().State; got != BreakerClosed {
+		t.Errorf("canceled request should not trip the breaker, got %s", got)
+	}
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("later request failed: %v", err)
+	}
+}
+
+func TestCanceledProbeReleasesBreaker(t *testing.T) {
+	fake := &fakePocketBase{}
+	client := newTestClient(t, fake)
+	client.Retry = RetryPolicy{MaxAttempts: 1}
+	client.Breaker = NewCircuitBreaker(1, 10*time.Millisecond)
+
+	fake.failNext.Store(1)
+	client.ListSystems(context.Background())
+	if got := client.BreakerStatus().State; got != BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// 半开状态的探测请求被调用方取消
+	fake.requestDelay.Store(int64(200 * time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.ListSystems(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the probe to be canceled, got %v", err)
+	}
+	if got := client.BreakerStatus().State; got != BreakerHalfOpen {
+		t.Fatalf("canceled probe should keep breaker half-open, got %s", got)
+	}
+
+	fake.requestDelay.Store(0)
+	if _, err := client.ListSystems(context.Background()); err != nil {
+		t.Fatalf("next probe should be allowed, got %v", err)
+	}
+	if got := client.BreakerStatus().State; got != BreakerClosed {
+		t.Errorf("successful probe should close breaker, got %s", got)
+	}
+}
+
+func TestTraceContextPropagated(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	fake := &fakePocketBase{}
+	var traceparents []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		mu.Unlock()
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	client := NewClient(srv.URL)
+	client.SetCredentials("admin@example.com", "secret")
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if _, err := client.ListSystems(ctx); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	traceID := parent.SpanContext().TraceID().String()
+	// 登录和数据请求都带上了同一个trace
+	if len(traceparents) != 2 {
+		t.Fatalf("expected login and list requests, got %d", len(traceparents))
+	}
+	for _, header := range traceparents {
+		if !strings.Contains(header, traceID) {
+			t.Errorf("traceparent %q does not carry trace %s", header, traceID)
+		}
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() != traceID {
+			t.Errorf("span %s started a new trace", span.Name())
+		}
+		names = append(names, span.Name())
+	}
+	want := "PocketBase GET /api/collections/systems/records"
+	if !slices.Contains(names, want) {
+		t.Errorf("spans %v, want %q", names, want)
+	}
+}
@@ -0,0 +1,171 @@
+package pocketbase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Filter PocketBase过滤表达式。
+// 只能通过Eq、Gte、And等构造函数创建，值在构造时完成转义，
+// 因此外部输入只会作为字面量出现，不会改变表达式结构。
+// 构造出错时错误会随表达式传递，在Build时统一返回。
+type Filter struct {
+	expr string
+	err  error
+}
+
+// 比较运算符
+const (
+	OpEq      = "="
+	OpNeq     = "!="
+	OpGt      = ">"
+	OpGte     = ">="
+	OpLt      = "<"
+	OpLte     = "<="
+	OpLike    = "~"
+	OpNotLike = "!~"
+)
+
+// fieldPattern 字段名只允许字母、数字、下划线以及关联字段使用的点号（单独的"_"不是合法标识符）
+var fieldPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*|_[A-Za-z0-9_]+)(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+var validOps = map[string]bool{
+	OpEq: true, OpNeq: true, OpGt: true, OpGte: true,
+	OpLt: true, OpLte: true, OpLike: true, OpNotLike: true,
+}
+
+// ErrUnsafeFilterValue 字符串值无法安全地表示为PocketBase字面量
+var ErrUnsafeFilterValue = errors.New("过滤值无法安全转义")
+
+// Compare 构造 field op value 形式的比较表达式
+func Compare(field, op string, value interface{}) Filter {
+	if !fieldPattern.MatchString(field) {
+		return Filter{err: fmt.Errorf("非法的过滤字段: %q", field)}
+	}
+	if !validOps[op] {
+		return Filter{err: fmt.Errorf("非法的过滤运算符: %q", op)}
+	}
+	literal, err := quoteValue(value)
+	if err != nil {
+		return Filter{err: fmt.Errorf("字段%s: %w", field, err)}
+	}
+	return Filter{expr: field + " " + op + " " + literal}
+}
+
+// Eq field = value
+func Eq(field string, value interface{}) Filter { return Compare(field, OpEq, value) }
+
+// Neq field != value
+func Neq(field string, value interface{}) Filter { return Compare(field, OpNeq, value) }
+
+// Gt field > value
+func Gt(field string, value interface{}) Filter { return Compare(field, OpGt, value) }
+
+// Gte field >= value
+func Gte(field string, value interface{}) Filter { return Compare(field, OpGte, value) }
+
+// Lt field < value
+func Lt(field string, value interface{}) Filter { return Compare(field, OpLt, value) }
+
+// Lte field <= value
+func Lte(field string, value interface{}) Filter { return Compare(field, OpLte, value) }
+
+// Like field ~ value（包含匹配）
+func Like(field string, value interface{}) Filter { return Compare(field, OpLike, value) }
+
+// NotLike field !~ value
+func NotLike(field string, value interface{}) Filter { return Compare(field, OpNotLike, value) }
+
+// TimeRange 时间范围 from <= field <= to，零值的一端不做限制
+func TimeRange(field string, from, to time.Time) Filter {
+	var filters []Filter
+	if !from.IsZero() {
+		filters = append(filters, Gte(field, from))
+	}
+	if !to.IsZero() {
+		filters = append(filters, Lte(field, to))
+	}
+	return And(filters...)
+}
+
+// And 用 && 连接多个表达式，忽略空表达式
+func And(filters ...Filter) Filter { return join(" && ", filters) }
+
+// Or 用 || 连接多个表达式，忽略空表达式
+func Or(filters ...Filter) Filter { return join(" || ", filters) }
+
+func join(sep string, filters []Filter) Filter {
+	var parts []string
+	for _, f := range filters {
+		if f.err != nil {
+			return f
+		}
+		if f.expr == "" {
+			continue
+		}
+		parts = append(parts, f.expr)
+	}
+	switch len(parts) {
+	case 0:
+		return Filter{}
+	case 1:
+		return Filter{expr: parts[0]}
+	default:
+		// 子表达式加括号，保证嵌套And/Or时优先级不变
+		return Filter{expr: "(" + strings.Join(parts, sep) + ")"}
+	}
+}
+
+// IsZero 是否为空表达式（不做过滤）
+func (f Filter) IsZero() bool {
+	return f.expr == "" && f.err == nil
+}
+
+// Build 返回最终的过滤字符串
+func (f Filter) Build() (string, error) {
+	return f.expr, f.err
+}
+
+// quoteValue 将值转换为PocketBase过滤字面量
+func quoteValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return quoteString(v)
+	case time.Time:
+		return quoteString(formatTime(v))
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("不支持的过滤值类型: %T", value)
+	}
+}
+
+// quoteString 用双引号包裹字符串，并将其中的双引号转义为 \"。
+// PocketBase的解析器只把 \" 还原为 "，其余反斜杠原样保留，
+// 但结尾的反斜杠会转义收尾引号，这类值无法安全表示，直接拒绝；
+// 解析器把NUL当作输入结束，同样拒绝。
+func quoteString(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("%w: 非法的UTF-8字符串", ErrUnsafeFilterValue)
+	}
+	if strings.ContainsRune(s, 0) {
+		return "", fmt.Errorf("%w: 包含NUL字符", ErrUnsafeFilterValue)
+	}
+	if strings.HasSuffix(s, `\`) {
+		return "", fmt.Errorf("%w: 以反斜杠结尾", ErrUnsafeFilterValue)
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`, nil
+}
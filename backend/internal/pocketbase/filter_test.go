@@ -0,0 +1,174 @@
+package pocketbase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ganigeorgiev/fexpr"
+)
+
+func TestFilterBuild(t *testing.T) {
+	from := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{"eq", Eq("system", "abc"), `system = "abc"`},
+		{"quote", Eq("name", `a"b`), `name = "a\"b"`},
+		{"number", Gt("cpu", 12.5), `cpu > 12.5`},
+		{"bool", Neq("active", true), `active != true`},
+		{"null", Eq("info", nil), `info = null`},
+		{"like", Like("name", "web"), `name ~ "web"`},
+		{"and", And(Eq("system", "a"), Eq("type", "1m")), `(system = "a" && type = "1m")`},
+		{"nested", And(Eq("a", 1), Or(Eq("b", 2), Eq("c", 3))), `(a = 1 && (b = 2 || c = 3))`},
+		{"skip empty", And(Filter{}, Eq("a", 1), Filter{}), `a = 1`},
+		{"empty", Or(), ``},
+		{"time range", TimeRange("created", from, from.Add(time.Hour)),
+			`(created >= "2026-10-18 00:00:00.000Z" && created <= "2026-10-18 01:00:00.000Z")`},
+		{"open range", TimeRange("created", from, time.Time{}), `created >= "2026-10-18 00:00:00.000Z"`},
+	}
+	for _, tc := range cases {
+		got, err := tc.filter.Build()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFilterRejectsUnsafeInput(t *testing.T) {
+	cases := []Filter{
+		Eq("system = 1 || id", "x"),
+		Eq("", "x"),
+		Compare("system", "= 1 ||", "x"),
+		Eq("system", `abc\`),
+		Eq("system", "a\x00b"),
+		Eq("system", "\xff"),
+		Eq("system", struct{}{}),
+		And(Eq("type", "1m"), Eq("system", `x\`)),
+	}
+	for i, f := range cases {
+		if _, err := f.Build(); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+// FuzzFilterValue 验证任意输入都只能作为字面量出现：
+// 要么被拒绝，要么被PocketBase解析器还原为原值且表达式结构不变
+func FuzzFilterValue(f *testing.F) {
+	seeds := []string{
+		"sys1",
+		`" || id != "`,
+		`abc" || "1" = "1`,
+		`\" || 1=1 //`,
+		`x\\" && type = "10m`,
+		`) || (id != ""`,
+		"' || 1=1 --",
+		"// comment",
+		"\n\t",
+		`\`,
+		`\\`,
+		`a\"`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed, seed)
+	}
+
+	f.Fuzz(func(t *testing.T, systemID, statsType string) {
+		filter := And(Eq("system", systemID), Or(Eq("type", statsType), Like("name", systemID)))
+		expr, err := filter.Build()
+		if err != nil {
+			if !errors.Is(err, ErrUnsafeFilterValue) {
+				t.Fatalf("unexpected error type: %v", err)
+			}
+			if unsafeFilterString(systemID) || unsafeFilterString(statsType) {
+				return
+			}
+			t.Fatalf("safe input rejected: %q %q: %v", systemID, statsType, err)
+		}
+
+		groups, err := fexpr.Parse(expr)
+		if err != nil {
+			t.Fatalf("built filter does not parse: %s: %v", expr, err)
+		}
+		// 期望结构：一个分组，内含 system条件 && 一个分组(type条件 || name条件)
+		outer := singleGroup(t, groups, expr)
+		if len(outer) != 2 {
+			t.Fatalf("expected 2 AND operands, got %d: %s", len(outer), expr)
+		}
+		assertExpr(t, outer[0], fexpr.JoinAnd, "system", fexpr.SignEq, systemID)
+
+		inner, ok := outer[1].Item.([]fexpr.ExprGroup)
+		if !ok || len(inner) != 2 || outer[1].Join != fexpr.JoinAnd {
+			t.Fatalf("unexpected OR group: %+v (%s)", outer[1], expr)
+		}
+		assertExpr(t, inner[0], fexpr.JoinAnd, "type", fexpr.SignEq, statsType)
+		assertExpr(t, inner[1], fexpr.JoinOr, "name", fexpr.SignLike, systemID)
+	})
+}
+
+func unsafeFilterString(s string) bool {
+	return !utf8.ValidString(s) || strings.HasSuffix(s, `\`) || strings.ContainsRune(s, 0)
+}
+
+func singleGroup(t *testing.T, groups []fexpr.ExprGroup, expr string) []fexpr.ExprGroup {
+	t.Helper()
+	if len(groups) != 1 {
+		t.Fatalf("expected a single top-level group, got %d: %s", len(groups), expr)
+	}
+	items, ok := groups[0].Item.([]fexpr.ExprGroup)
+	if !ok {
+		t.Fatalf("expected nested group, got %T: %s", groups[0].Item, expr)
+	}
+	return items
+}
+
+func assertExpr(t *testing.T, group fexpr.ExprGroup, join fexpr.JoinOp, field string, op fexpr.SignOp, value string) {
+	t.Helper()
+	e, ok := group.Item.(fexpr.Expr)
+	if !ok {
+		t.Fatalf("expected expression, got %T", group.Item)
+	}
+	if group.Join != join {
+		t.Errorf("join changed: got %s, want %s", group.Join, join)
+	}
+	if e.Left.Type != fexpr.TokenIdentifier || e.Left.Literal != field {
+		t.Errorf("left operand changed: %+v", e.Left)
+	}
+	if e.Op != op {
+		t.Errorf("operator changed: got %s, want %s", e.Op, op)
+	}
+	if e.Right.Type != fexpr.TokenText || e.Right.Literal != value {
+		t.Errorf("value changed: got %q (%s), want %q", e.Right.Literal, e.Right.Type, value)
+	}
+}
+
+// FuzzFilterField 验证非法字段名总是被拒绝，合法字段名被解析为单个标识符
+func FuzzFilterField(f *testing.F) {
+	for _, seed := range []string{"system", "expand.system.name", "a = 1 || b", "@request.auth.id", "id)", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, field string) {
+		expr, err := Eq(field, "x").Build()
+		if err != nil {
+			return
+		}
+		groups, err := fexpr.Parse(expr)
+		if err != nil {
+			t.Fatalf("built filter does not parse: %s: %v", expr, err)
+		}
+		if len(groups) != 1 {
+			t.Fatalf("expected a single expression, got %d: %s", len(groups), expr)
+		}
+		assertExpr(t, groups[0], fexpr.JoinAnd, field, fexpr.SignEq, "x")
+	})
+}
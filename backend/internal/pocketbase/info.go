@@ -0,0 +1,67 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SystemInfo Beszel在systems记录的info字段中保存的主机信息
+type SystemInfo struct {
+	Hostname      string     `json:"h"`
+	KernelVersion string     `json:"k,omitempty"`
+	Cores         int        `json:"c"`
+	Threads       int        `json:"t,omitempty"`
+	CPUModel      string     `json:"m"`
+	Uptime        uint64     `json:"u"` // 运行时间（秒）
+	CPU           float64    `json:"cpu"`
+	MemPct        float64    `json:"mp"`
+	DiskPct       float64    `json:"dp"`
+	Bandwidth     float64    `json:"b"` // MB/s
+	AgentVersion  string     `json:"v"`
+	Podman        bool       `json:"p,omitempty"`
+	LoadAvg       [3]float64 `json:"la,omitempty"`
+	// OS 在agent中是枚举值（0 linux、1 darwin、2 windows、3 freebsd），部分版本直接上报字符串
+	OS json.RawMessage `json:"os,omitempty"`
+}
+
+// agentOSNames Beszel agent的操作系统枚举
+var agentOSNames = []string{"linux", "darwin", "windows", "freebsd"}
+
+// UnmarshalJSON info字段与stats一样，可能是JSON字符串、对象或null
+func (i *SystemInfo) UnmarshalJSON(data []byte) error {
+	type plain SystemInfo
+
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err == nil {
+		if strings.TrimSpace(encoded) == "" {
+			return nil
+		}
+		data = []byte(encoded)
+	}
+	if string(data) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(data, (*plain)(i))
+}
+
+// OSName 返回操作系统名称，未知时返回空字符串
+func (i *SystemInfo) OSName() string {
+	if len(i.OS) == 0 {
+		return ""
+	}
+
+	var code int
+	if err := json.Unmarshal(i.OS, &code); err == nil {
+		if code >= 0 && code < len(agentOSNames) {
+			return agentOSNames[code]
+		}
+		return ""
+	}
+
+	var name string
+	if err := json.Unmarshal(i.OS, &name); err == nil {
+		return strings.ToLower(name)
+	}
+	return ""
+}
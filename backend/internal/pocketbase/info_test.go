@@ -0,0 +1,49 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeSystemInfo(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		os   string
+	}{
+		{"object", `{"id":"s1","info":{"h":"hk-01","k":"6.1.0","c":4,"t":8,"m":"AMD EPYC 7763","u":3600,"v":"0.12.1","os":0}}`, "linux"},
+		{"string", `{"id":"s1","info":"{\"h\":\"hk-01\",\"k\":\"6.1.0\",\"c\":4,\"t\":8,\"m\":\"AMD EPYC 7763\",\"u\":3600,\"v\":\"0.12.1\",\"os\":3}"}`, "freebsd"},
+		{"string os", `{"id":"s1","info":{"h":"hk-01","k":"6.1.0","c":4,"t":8,"m":"AMD EPYC 7763","u":3600,"v":"0.12.1","os":"Linux"}}`, "linux"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sys System
+			if err := json.Unmarshal([]byte(tc.raw), &sys); err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			info := sys.Info
+			if info.Hostname != "hk-01" || info.KernelVersion != "6.1.0" || info.Cores != 4 || info.Threads != 8 {
+				t.Errorf("unexpected info: %+v", info)
+			}
+			if info.CPUModel != "AMD EPYC 7763" || info.Uptime != 3600 || info.AgentVersion != "0.12.1" {
+				t.Errorf("unexpected info: %+v", info)
+			}
+			if got := info.OSName(); got != tc.os {
+				t.Errorf("expected os %q, got %q", tc.os, got)
+			}
+		})
+	}
+}
+
+func TestDecodeMissingSystemInfo(t *testing.T) {
+	for _, raw := range []string{`{"id":"s1"}`, `{"id":"s1","info":null}`, `{"id":"s1","info":""}`} {
+		var sys System
+		if err := json.Unmarshal([]byte(raw), &sys); err != nil {
+			t.Fatalf("decode %s failed: %v", raw, err)
+		}
+		if sys.Info.Hostname != "" || sys.Info.OSName() != "" {
+			t.Errorf("expected empty info for %s, got %+v", raw, sys.Info)
+		}
+	}
+}
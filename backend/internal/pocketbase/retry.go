@@ -0,0 +1,62 @@
+package pocketbase
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 幂等请求（GET）的重试策略
+type RetryPolicy struct {
+	MaxAttempts int           // 最大尝试次数（含第一次），<=1 表示不重试
+	BaseDelay   time.Duration // 第一次重试前的基础等待时间
+	MaxDelay    time.Duration // 单次等待上限
+}
+
+// DefaultRetryPolicy 默认重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// attempts 返回有效的尝试次数
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff 返回第retry次重试（从1开始）前的等待时间：指数退避，并在[d/2, d]之间随机抖动
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// transportError 网络层错误（连接失败、超时等），可以安全重试
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// isRetryableError 判断错误是否值得重试：只有网络层错误才重试，熔断和认证配置错误不重试
+func isRetryableError(err error) bool {
+	var te *transportError
+	return errors.As(err, &te)
+}
+
+// isRetryableStatus 判断响应状态码是否值得重试
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,116 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StatsData 包含实际的指标数据，字段名与Beszel agent上报的JSON键一致
+type StatsData struct {
+	CPU          float64 `json:"cpu"`
+	MaxCPU       float64 `json:"cpum,omitempty"` // 采样周期内CPU峰值
+	Mem          float64 `json:"m"`              // 总内存 GB
+	MemUsed      float64 `json:"mu"`             // 使用内存 GB
+	MemPct       float64 `json:"mp"`             // 内存使用百分比
+	MemBuffCache float64 `json:"mb,omitempty"`   // 缓冲/缓存 GB
+	MemZfsArc    float64 `json:"mz,omitempty"`   // ZFS ARC GB
+
+	Swap     float64 `json:"s,omitempty"`  // 交换分区总量 GB
+	SwapUsed float64 `json:"su,omitempty"` // 交换分区使用量 GB
+
+	DiskTotal     float64 `json:"d"`             // 根分区总量 GB
+	DiskUsed      float64 `json:"du"`            // 根分区使用量 GB
+	DiskPct       float64 `json:"dp"`            // 根分区使用百分比
+	DiskReadPs    float64 `json:"dr"`            // 磁盘读 MB/s
+	DiskWritePs   float64 `json:"dw"`            // 磁盘写 MB/s
+	MaxDiskReadPs float64 `json:"drm,omitempty"` // 采样周期内磁盘读峰值
+	MaxDiskWrite  float64 `json:"dwm,omitempty"` // 采样周期内磁盘写峰值
+
+	NetworkSent    float64 `json:"ns"`            // 网络发送（MB/秒）
+	NetworkRecv    float64 `json:"nr"`            // 网络接收（MB/秒）
+	MaxNetworkSent float64 `json:"nsm,omitempty"` // 采样周期内发送峰值
+	MaxNetworkRecv float64 `json:"nrm,omitempty"` // 采样周期内接收峰值
+
+	// 负载均值：旧版agent使用l1/l5/l15，新版使用la数组
+	LoadAvg1  float64    `json:"l1,omitempty"`
+	LoadAvg5  float64    `json:"l5,omitempty"`
+	LoadAvg15 float64    `json:"l15,omitempty"`
+	LoadAvg   [3]float64 `json:"la,omitempty"`
+
+	Temperatures map[string]float64        `json:"t,omitempty"`   // 传感器温度 ℃
+	ExtraFs      map[string]FsStats        `json:"efs,omitempty"` // 额外挂载的文件系统
+	GPUs         map[string]GPUData        `json:"g,omitempty"`   // GPU
+	Interfaces   map[string]InterfaceStats `json:"ni,omitempty"`  // 每个网卡的带宽
+}
+
+// FsStats 额外文件系统的用量和IO
+type FsStats struct {
+	DiskTotal     float64 `json:"d"`
+	DiskUsed      float64 `json:"du"`
+	DiskReadPs    float64 `json:"r"`
+	DiskWritePs   float64 `json:"w"`
+	MaxDiskReadPs float64 `json:"rm,omitempty"`
+	MaxDiskWrite  float64 `json:"wm,omitempty"`
+}
+
+// GPUData GPU使用情况
+type GPUData struct {
+	Name        string  `json:"n"`
+	MemoryUsed  float64 `json:"mu,omitempty"` // 显存使用 MB
+	MemoryTotal float64 `json:"mt,omitempty"` // 显存总量 MB
+	Usage       float64 `json:"u"`            // 使用率 %
+	Power       float64 `json:"p,omitempty"`  // 功耗 W
+}
+
+// InterfaceStats 单个网卡的带宽
+type InterfaceStats struct {
+	Sent float64 `json:"sent"` // 发送（MB/秒）
+	Recv float64 `json:"recv"` // 接收（MB/秒）
+}
+
+// UnmarshalJSON 网卡数据在agent中以 [发送, 接收, ...] 数组上报，也兼容对象格式
+func (i *InterfaceStats) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err == nil {
+		if len(values) < 2 {
+			return fmt.Errorf("网卡数据至少需要发送和接收两个值: %s", data)
+		}
+		i.Sent, i.Recv = values[0], values[1]
+		return nil
+	}
+
+	type plain InterfaceStats
+	return json.Unmarshal(data, (*plain)(i))
+}
+
+// MemPercent 返回内存使用百分比，旧数据缺少mp时按使用量计算
+func (s *StatsData) MemPercent() float64 {
+	if s.MemPct == 0 && s.Mem > 0 && s.MemUsed > 0 {
+		return (s.MemUsed / s.Mem) * 100
+	}
+	return s.MemPct
+}
+
+// SwapPercent 返回交换分区使用百分比，没有交换分区时为0
+func (s *StatsData) SwapPercent() float64 {
+	if s.Swap <= 0 {
+		return 0
+	}
+	return (s.SwapUsed / s.Swap) * 100
+}
+
+// DiskPercent 返回根分区使用百分比，缺少dp时按使用量计算
+func (s *StatsData) DiskPercent() float64 {
+	if s.DiskPct == 0 && s.DiskTotal > 0 && s.DiskUsed > 0 {
+		return (s.DiskUsed / s.DiskTotal) * 100
+	}
+	return s.DiskPct
+}
+
+// LoadAverages 返回1/5/15分钟负载均值，优先使用新版la字段
+func (s *StatsData) LoadAverages() [3]float64 {
+	if s.LoadAvg != [3]float64{} {
+		return s.LoadAvg
+	}
+	return [3]float64{s.LoadAvg1, s.LoadAvg5, s.LoadAvg15}
+}
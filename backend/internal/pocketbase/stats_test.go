@@ -0,0 +1,186 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const beszelStatsRecord = `{
+	"id": "rec1",
+	"system": "sys1",
+	"type": "1m",
+	"created": "2026-10-18 08:00:00.000Z",
+	"stats": {
+		"cpu": 12.5, "m": 8, "mu": 4, "mp": 50, "mb": 1.5,
+		"s": 2, "su": 0.5,
+		"d": 100, "du": 92, "dp": 92, "dr": 1.2, "dw": 3.4,
+		"ns": 10.5, "nr": 20.25,
+		"la": [0.5, 0.4, 0.3],
+		"t": {"cpu_thermal": 55.5},
+		"efs": {"sdb1": {"d": 500, "du": 250, "r": 0.1, "w": 0.2}},
+		"g": {"0": {"n": "RTX 4090", "mu": 2048, "mt": 24576, "u": 35, "p": 120}},
+		"ni": {"eth0": [10.5, 20.25, 1000, 2000]}
+	}
+}`
+
+func TestDecodeFullBeszelStats(t *testing.T) {
+	var rec SystemStats
+	if err := json.Unmarshal([]byte(beszelStatsRecord), &rec); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	st := rec.Stats
+	if st.CPU != 12.5 || st.MemBuffCache != 1.5 {
+		t.Errorf("unexpected cpu/mem: %+v", st)
+	}
+	if got := st.SwapPercent(); got != 25 {
+		t.Errorf("expected swap 25%%, got %v", got)
+	}
+	if got := st.DiskPercent(); got != 92 {
+		t.Errorf("expected disk 92%%, got %v", got)
+	}
+	if st.DiskReadPs != 1.2 || st.DiskWritePs != 3.4 {
+		t.Errorf("unexpected disk io: %v/%v", st.DiskReadPs, st.DiskWritePs)
+	}
+	if got := st.LoadAverages(); got != [3]float64{0.5, 0.4, 0.3} {
+		t.Errorf("unexpected load averages: %v", got)
+	}
+	if st.Temperatures["cpu_thermal"] != 55.5 {
+		t.Errorf("unexpected temperatures: %v", st.Temperatures)
+	}
+	if fs := st.ExtraFs["sdb1"]; fs.DiskTotal != 500 || fs.DiskUsed != 250 || fs.DiskWritePs != 0.2 {
+		t.Errorf("unexpected extra fs: %+v", fs)
+	}
+	if gpu := st.GPUs["0"]; gpu.Name != "RTX 4090" || gpu.Usage != 35 || gpu.MemoryTotal != 24576 {
+		t.Errorf("unexpected gpu: %+v", gpu)
+	}
+	if nic := st.Interfaces["eth0"]; nic.Sent != 10.5 || nic.Recv != 20.25 {
+		t.Errorf("unexpected interface stats: %+v", nic)
+	}
+}
+
+func TestDecodeLegacyStats(t *testing.T) {
+	// 旧版agent：stats为字符串，负载使用l1/l5/l15，没有mp/dp
+	raw := `{"id":"rec2","stats":"{\"cpu\":5,\"m\":4,\"mu\":1,\"d\":50,\"du\":10,\"l1\":1.5,\"l5\":1,\"l15\":0.5}"}`
+
+	var rec SystemStats
+	if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if got := rec.Stats.MemPercent(); got != 25 {
+		t.Errorf("expected computed mem 25%%, got %v", got)
+	}
+	if got := rec.Stats.DiskPercent(); got != 20 {
+		t.Errorf("expected computed disk 20%%, got %v", got)
+	}
+	if got := rec.Stats.LoadAverages(); got != [3]float64{1.5, 1, 0.5} {
+		t.Errorf("unexpected legacy load averages: %v", got)
+	}
+	if got := rec.Stats.SwapPercent(); got != 0 {
+		t.Errorf("expected 0 swap without swap total, got %v", got)
+	}
+}
+
+func TestInterfaceStatsObjectForm(t *testing.T) {
+	var nic InterfaceStats
+	if err := json.Unmarshal([]byte(`{"sent":1,"recv":2}`), &nic); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if nic.Sent != 1 || nic.Recv != 2 {
+		t.Errorf("unexpected interface stats: %+v", nic)
+	}
+	if err := json.Unmarshal([]byte(`[1]`), &nic); err == nil {
+		t.Error("expected error for short interface array")
+	}
+}
+
+func TestListSystemStatsPaginates(t *testing.T) {
+	const total = 1234
+	var filters, fields []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filters = append(filters, query.Get("filter"))
+		fields = append(fields, query.Get("fields"))
+		page, _ := strconv.Atoi(query.Get("page"))
+		perPage, _ := strconv.Atoi(query.Get("perPage"))
+
+		// 记录ID即创建顺序，按sort参数返回
+		resp := ListResponse[SystemStats]{Page: page, PerPage: perPage}
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			id := i
+			if query.Get("sort") == "-created" {
+				id = total - 1 - i
+			}
+			resp.Items = append(resp.Items, SystemStats{ID: strconv.Itoa(id), System: "sys1", Type: "10m"})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.SetAuthToken("static-token")
+
+	from := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	items, truncated, err := client.ListSystemStats(context.Background(), StatsQuery{
+		SystemID: "sys1",
+		Type:     "10m",
+		From:     from,
+		To:       from.Add(12 * time.Hour),
+		Fields:   []string{"cpu", "mu"},
+	})
+	if err != nil {
+		t.Fatalf("ListSystemStats failed: %v", err)
+	}
+	if len(items) != total || truncated {
+		t.Fatalf("expected %d items, got %d (truncated %v)", total, len(items), truncated)
+	}
+	if items[0].ID != "0" || items[total-1].ID != strconv.Itoa(total-1) {
+		t.Errorf("items out of order, first id %s, last id %s", items[0].ID, items[total-1].ID)
+	}
+	if len(filters) != 3 {
+		t.Errorf("expected 3 page requests, got %d", len(filters))
+	}
+	if fields[0] != "id,system,type,created,stats.cpu,stats.mu" {
+		t.Errorf("fields not pushed down: %s", fields[0])
+	}
+	if !strings.Contains(filters[0], `created >= "2026-10-18 00:00:00.000Z"`) ||
+		!strings.Contains(filters[0], `created <= "2026-10-18 12:00:00.000Z"`) {
+		t.Errorf("time range missing from filter: %s", filters[0])
+	}
+}
+
+func TestListSystemStatsRespectsLimit(t *testing.T) {
+	// 总共2000条记录，按创建时间倒序返回
+	const total = 2000
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+		var resp ListResponse[SystemStats]
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			resp.Items = append(resp.Items, SystemStats{ID: strconv.Itoa(total - 1 - i)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.SetAuthToken("static-token")
+
+	items, truncated, err := client.ListSystemStats(context.Background(), StatsQuery{SystemID: "sys1", Type: "1m", MaxRecords: 700})
+	if err != nil {
+		t.Fatalf("ListSystemStats failed: %v", err)
+	}
+	if len(items) != 700 || !truncated {
+		t.Fatalf("expected 700 truncated items, got %d (truncated %v)", len(items), truncated)
+	}
+	// 保留最新的700条，按时间升序
+	if items[0].ID != "1300" || items[699].ID != "1999" {
+		t.Errorf("expected newest records, got %s..%s", items[0].ID, items[699].ID)
+	}
+}
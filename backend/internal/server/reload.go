@@ -0,0 +1,209 @@
+package server
+
+import (
+	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/service"
+	"backend/pkg/models"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"reflect"
+	"time"
+)
+
+// defaultWatchInterval 文件监听关闭时，重新检查监听配置的间隔
+const defaultWatchInterval = 5 * time.Second
+
+// CurrentConfig 返回当前生效的配置
+func (s *Server) CurrentConfig() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// LastReload 返回最近一次配置重载的结果，从未重载时返回nil
+func (s *Server) LastReload() *models.ReloadResult {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.lastReload
+}
+
+// Reload 重新读取配置文件和环境变量，并替换受影响的客户端。
+// 先准备所有新客户端（Redis连接、PocketBase认证），全部成功后统一替换；
+// 任一步骤失败则保留全部旧配置和旧客户端，期间请求始终由旧客户端或新客户端之一处理。
+// Redis配置没有变化、只是之前连接失败时，重连失败只记为警告，不影响其他配置段生效。
+func (s *Server) Reload(trigger string) *models.ReloadResult {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	result := &models.ReloadResult{Trigger: trigger, StartedAt: time.Now(), Changed: []string{}}
+	defer func() {
+		result.FinishedAt = time.Now()
+		s.configMu.Lock()
+		s.lastReload = result
+		s.configMu.Unlock()
+
+		if result.Success {
+			slog.Info("配置重载成功", "op", "config.reload", "trigger", trigger,
+				"changed", result.Changed, "restart_required", result.RestartRequired, "warnings", result.Warnings)
+		} else {
+			slog.Error("配置重载失败，继续使用旧配置", "op", "config.reload", "trigger", trigger, "error", result.Error)
+		}
+	}()
+
+	newCfg, err := config.LoadFile(s.configPath)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	oldCfg := s.CurrentConfig()
+
+	// 监听地址、数据库目录、认证方式和链路追踪无法在运行时切换
+	if !reflect.DeepEqual(oldCfg.Server, newCfg.Server) {
+		result.RestartRequired = append(result.RestartRequired, "server")
+	}
+	if !reflect.DeepEqual(oldCfg.Database, newCfg.Database) {
+		result.RestartRequired = append(result.RestartRequired, "database")
+	}
+	if !reflect.DeepEqual(oldCfg.Auth, newCfg.Auth) {
+		result.RestartRequired = append(result.RestartRequired, "auth")
+	}
+	if !reflect.DeepEqual(oldCfg.Tracing, newCfg.Tracing) {
+		result.RestartRequired = append(result.RestartRequired, "tracing")
+	}
+	if oldCfg.Log.Format != newCfg.Log.Format {
+		result.RestartRequired = append(result.RestartRequired, "log.format")
+	}
+
+	var swaps []*service.PendingSwap
+	abort := func() {
+		for _, swap := range swaps {
+			swap.Abort()
+		}
+	}
+
+	// Redis配置变化，或者之前连接失败时，都尝试建立新连接
+	redisChanged := !reflect.DeepEqual(oldCfg.Redis, newCfg.Redis)
+	redisDown := newCfg.Redis.Host != "" && !s.redisService.Available()
+	if redisChanged || redisDown {
+		swap, err := s.redisService.Prepare(&newCfg.Redis)
+		switch {
+		case err == nil:
+			swaps = append(swaps, swap)
+			result.Changed = append(result.Changed, "redis")
+		case redisChanged:
+			result.Error = err.Error()
+			return result
+		default:
+			// 配置没有变化，继续使用原来的（不可用的）连接
+			result.Warnings = append(result.Warnings, err.Error())
+		}
+	}
+	if !reflect.DeepEqual(oldCfg.PocketBase, newCfg.PocketBase) {
+		swap, err := s.systemService.PreparePocketBase(&newCfg.PocketBase)
+		if err != nil {
+			abort()
+			result.Error = err.Error()
+			return result
+		}
+		swaps = append(swaps, swap)
+		result.Changed = append(result.Changed, "pocketbase")
+	}
+
+	// 所有新客户端准备就绪，统一生效
+	for _, swap := range swaps {
+		swap.Commit()
+	}
+	if !reflect.DeepEqual(oldCfg.CORS, newCfg.CORS) {
+		s.cors.Update(newCfg.CORS)
+		result.Changed = append(result.Changed, "cors")
+	}
+	if !reflect.DeepEqual(oldCfg.Thresholds, newCfg.Thresholds) {
+		service.SetThresholdDefaults(newCfg.Thresholds)
+		result.Changed = append(result.Changed, "thresholds")
+	}
+	if !reflect.DeepEqual(oldCfg.Publish, newCfg.Publish) {
+		// 发布器每次发布前读取当前配置
+		result.Changed = append(result.Changed, "publish")
+	}
+	if !reflect.DeepEqual(oldCfg.Actuator, newCfg.Actuator) {
+		// 执行器每轮执行前读取当前配置
+		result.Changed = append(result.Changed, "actuator")
+	}
+	if !reflect.DeepEqual(oldCfg.History, newCfg.History) {
+		// 采样器每次采样前读取当前配置，修改保留天数只影响之后写入的数据
+		result.Changed = append(result.Changed, "history")
+	}
+	if !reflect.DeepEqual(oldCfg.Forecast, newCfg.Forecast) {
+		result.Changed = append(result.Changed, "forecast")
+	}
+	if !reflect.DeepEqual(oldCfg.Anomaly, newCfg.Anomaly) {
+		// 修改计算方法后基线立即重新计算，其他参数在下次检测时生效
+		result.Changed = append(result.Changed, "anomaly")
+	}
+	if !reflect.DeepEqual(oldCfg.Reload, newCfg.Reload) {
+		result.Changed = append(result.Changed, "reload")
+	}
+	if oldCfg.Log.Level != newCfg.Log.Level {
+		// 已经通过校验，不会失败
+		logging.SetLevel(newCfg.Log.Level)
+		result.Changed = append(result.Changed, "log.level")
+	}
+
+	// 需要重启的配置段保持旧值，CurrentConfig 始终反映实际运行的配置
+	newCfg.Server = oldCfg.Server
+	newCfg.Database = oldCfg.Database
+	newCfg.Auth = oldCfg.Auth
+	newCfg.Tracing = oldCfg.Tracing
+	newCfg.Log.Format = oldCfg.Log.Format
+	s.configMu.Lock()
+	s.config = newCfg
+	s.configMu.Unlock()
+
+	result.Success = true
+	return result
+}
+
+// watchConfigFile 轮询配置文件，内容变化时触发重载。
+// 使用轮询而不是inotify，兼容Docker绑定挂载以及Kubernetes ConfigMap的符号链接替换。
+func (s *Server) watchConfigFile(stop <-chan struct{}) {
+	last, _ := fileDigest(s.configPath)
+	for {
+		interval := time.Duration(s.CurrentConfig().Reload.WatchIntervalSeconds) * time.Second
+		enabled := interval > 0
+		if !enabled {
+			interval = defaultWatchInterval
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		if !enabled {
+			continue
+		}
+
+		digest, err := fileDigest(s.configPath)
+		if err != nil {
+			slog.Error("读取配置文件失败", "op", "config.watch", "path", s.configPath, logging.Err(err))
+			continue
+		}
+		if digest == last {
+			continue
+		}
+		// 无论重载是否成功都记录新内容，避免无效配置被反复重载
+		last = digest
+		s.Reload("file")
+	}
+}
+
+// fileDigest 计算文件内容摘要
+func fileDigest(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"backend/internal/api/router"
+	"backend/internal/config"
+	"backend/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const baseConfig = `
+pocketbase:
+  base_url: https://hub.example.com
+  token: test-token
+redis:
+  host: ""
+cors:
+  allow_origins: [https://a.example.com]
+reload:
+  watch_interval_seconds: 0
+`
+
+// newTestServer 创建不监听端口、不连接外部服务的Server
+func newTestServer(t *testing.T, content string) (*Server, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, content)
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	s := New(cfg, path)
+	s.systemService = service.NewSystemService(cfg)
+	s.redisService = service.NewRedisService()
+	s.cors = router.NewCORSMiddleware(cfg.CORS)
+	return s, path
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func allowedOrigin(s *Server, origin string) string {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(s.cors.Handler())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", origin)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Header().Get("Access-Control-Allow-Origin")
+}
+
+func TestReloadAppliesChanges(t *testing.T) {
+	s, path := newTestServer(t, baseConfig)
+	if got := allowedOrigin(s, "https://b.example.com"); got != "" {
+		t.Fatalf("origin should not be allowed before reload, got %q", got)
+	}
+
+	writeConfig(t, path, baseConfig+`
+server:
+  port: "9090"
+thresholds:
+  cpu_alert_limit: 75
+publish:
+  channel: ""
+actuator:
+  max_actions_per_hour: 5
+history:
+  retention_days: 28
+anomaly:
+  threshold: 4
+`)
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://b.example.com")
+	t.Setenv("POCKETBASE_URL", "https://other-hub.example.com")
+
+	result := s.Reload("api")
+	if !result.Success {
+		t.Fatalf("reload failed: %s", result.Error)
+	}
+	for _, section := range []string{"cors", "pocketbase", "thresholds", "publish", "actuator", "history", "anomaly"} {
+		if !slices.Contains(result.Changed, section) {
+			t.Errorf("expected %s in changed sections: %v", section, result.Changed)
+		}
+	}
+	if !slices.Contains(result.RestartRequired, "server") {
+		t.Errorf("server port change should require restart: %v", result.RestartRequired)
+	}
+
+	cfg := s.CurrentConfig()
+	if cfg.Server.Port != "8080" {
+		t.Errorf("running config should keep the old port, got %s", cfg.Server.Port)
+	}
+	if cfg.PocketBase.BaseURL != "https://other-hub.example.com" {
+		t.Errorf("unexpected base url %s", cfg.PocketBase.BaseURL)
+	}
+	if got := service.DefaultThreshold("sys1").CPUAlertLimit; got != 75 {
+		t.Errorf("expected reloaded cpu default 75, got %v", got)
+	}
+	if got := allowedOrigin(s, "https://b.example.com"); got != "https://b.example.com" {
+		t.Errorf("expected reloaded origin to be allowed, got %q", got)
+	}
+	if s.LastReload() != result {
+		t.Error("last reload result not recorded")
+	}
+	service.SetThresholdDefaults(config.Default().Thresholds)
+}
+
+func TestReloadKeepsOldConfigOnError(t *testing.T) {
+	s, path := newTestServer(t, baseConfig)
+	before := s.CurrentConfig()
+
+	writeConfig(t, path, "pocketbase:\n  base_url: not-a-url\n")
+	result := s.Reload("file")
+	if result.Success || result.Error == "" {
+		t.Fatalf("expected failed reload, got %+v", result)
+	}
+	if s.CurrentConfig() != before {
+		t.Error("config should not change after a failed reload")
+	}
+
+	// Redis不可达时整个重载失败，PocketBase和CORS都不应切换
+	writeConfig(t, path, baseConfig)
+	t.Setenv("REDIS_HOST", "127.0.0.1")
+	t.Setenv("REDIS_PORT", "1")
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://b.example.com")
+	result = s.Reload("signal")
+	if result.Success || !strings.Contains(result.Error, "Redis") {
+		t.Fatalf("expected reload to fail when redis is unreachable, got %+v", result)
+	}
+	if s.CurrentConfig() != before {
+		t.Error("config should not change when a client fails to connect")
+	}
+	if got := allowedOrigin(s, "https://b.example.com"); got != "" {
+		t.Errorf("CORS should not change after a failed reload, got %q", got)
+	}
+}
+
+func TestReloadWithRedisDown(t *testing.T) {
+	// 启动时Redis就不可达，之后的重载没有修改Redis配置
+	t.Setenv("REDIS_HOST", "127.0.0.1")
+	t.Setenv("REDIS_PORT", "1")
+	s, path := newTestServer(t, baseConfig)
+
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://b.example.com")
+	writeConfig(t, path, baseConfig)
+	result := s.Reload("signal")
+	if !result.Success {
+		t.Fatalf("reload should succeed when only CORS changed, got %+v", result)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "Redis") {
+		t.Errorf("expected a redis reconnect warning, got %v", result.Warnings)
+	}
+	if slices.Contains(result.Changed, "redis") || !slices.Contains(result.Changed, "cors") {
+		t.Errorf("unexpected changed sections: %v", result.Changed)
+	}
+	if got := allowedOrigin(s, "https://b.example.com"); got != "https://b.example.com" {
+		t.Errorf("CORS should be updated, got %q", got)
+	}
+}
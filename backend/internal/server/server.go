@@ -4,12 +4,18 @@ import (
 	"backend/internal/api/handlers"
 	"backend/internal/api/router"
 	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/metrics"
 	"backend/internal/service"
+	"backend/internal/tracing"
+	"backend/pkg/models"
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,30 +24,63 @@ import (
 
 // Server 服务器结构
 type Server struct {
-	config        *config.Config
+	configPath    string
 	httpServer    *http.Server
 	router        *gin.Engine
+	cors          *router.CORSMiddleware
 	systemService *service.SystemService
 	redisService  *service.RedisService
 	nodeService   *service.NodeService
+	authService   *service.AuthService
+	publisher     *service.LoadPublisher
+	actuator      *service.Actuator
+	history       *service.HistoryRecorder
+	// stopBackground 停止后台发布节点负载状态、执行器和负载历史采样
+	stopBackground context.CancelFunc
+	// shutdownTracing 导出缓冲中剩余的span
+	shutdownTracing func(context.Context) error
+
+	// config 配置重载时整体替换，读取请使用 CurrentConfig()
+	configMu   sync.RWMutex
+	config     *config.Config
+	lastReload *models.ReloadResult
+	// reloadMu 保证同一时间只有一次重载
+	reloadMu sync.Mutex
 }
 
-// New 创建新的服务器实例
-func New(cfg *config.Config) *Server {
+// New 创建新的服务器实例，configPath为空时只从环境变量重载配置
+func New(cfg *config.Config, configPath string) *Server {
 	return &Server{
-		config: cfg,
+		config:     cfg,
+		configPath: configPath,
 	}
 }
 
 // Start 启动服务器
 func (s *Server) Start() error {
+	// 先初始化链路追踪，服务创建的客户端才能使用全局TracerProvider
+	shutdownTracing, err := tracing.Setup(context.Background(), s.config.Tracing)
+	if err != nil {
+		return err
+	}
+	s.shutdownTracing = shutdownTracing
+
 	// 初始化服务
 	if err := s.initServices(); err != nil {
 		return err
 	}
 
+	// 后台发布节点负载状态、执行面板操作和记录负载历史，未开启时只定期检查配置
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	s.stopBackground = stopBackground
+	go s.publisher.Run(backgroundCtx)
+	go s.actuator.Run(backgroundCtx)
+	go s.history.Run(backgroundCtx)
+
 	// 设置路由
-	s.router = router.SetupRouter(s.config, s.systemService)
+	s.cors = router.NewCORSMiddleware(s.config.CORS)
+	handlers.InitAdminHandler(s)
+	s.router = router.SetupRouter(s.cors, s.systemService, s.authService, s.config.Server.TrustedProxies)
 
 	// 创建HTTP服务器
 	s.httpServer = &http.Server{
@@ -53,12 +92,13 @@ func (s *Server) Start() error {
 	}
 
 	// 启动服务器
-	log.Printf("Server starting on %s", s.config.GetAddress())
+	slog.Info("Server starting", "op", "server.start", "addr", s.config.GetAddress())
 	
 	// 在goroutine中启动服务器
 	go func() {
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			slog.Error("Failed to start server", "op", "server.start", logging.Err(err))
+			os.Exit(1)
 		}
 	}()
 
@@ -74,14 +114,18 @@ func (s *Server) Stop() error {
 		return nil
 	}
 
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server", "op", "server.stop")
 	
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
+
 	// 关闭Redis连接
 	if s.redisService != nil {
 		if err := s.redisService.Close(); err != nil {
-			log.Printf("Failed to close Redis connection: %v", err)
+			slog.Error("Failed to close Redis connection", "op", "server.stop", logging.Err(err))
 		} else {
-			log.Println("Redis connection closed")
+			slog.Info("Redis connection closed", "op", "server.stop")
 		}
 	}
 
@@ -91,53 +135,100 @@ func (s *Server) Stop() error {
 
 	// 优雅关闭服务器
 	if err := s.httpServer.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+		slog.Error("Server forced to shutdown", "op", "server.stop", logging.Err(err))
 		return err
 	}
 
-	log.Println("Server stopped gracefully")
+	if s.shutdownTracing != nil {
+		if err := s.shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "op", "server.stop", logging.Err(err))
+		}
+	}
+
+	slog.Info("Server stopped gracefully", "op", "server.stop")
 	return nil
 }
 
 // initServices 初始化服务
 func (s *Server) initServices() error {
-	log.Println("Initializing services...")
+	slog.Info("Initializing services", "op", "server.init")
 	
 	// 初始化系统服务
 	s.systemService = service.NewSystemService(s.config)
 	
-	// 初始化Redis服务
-	var err error
-	s.redisService, err = service.NewRedisService(s.config)
-	if err != nil {
-		log.Printf("Redis服务初始化失败: %v", err)
+	// 初始化Redis服务，连接失败时服务仍然创建，配置重载后可重新连接
+	s.redisService = service.NewRedisService()
+	if s.config.Redis.Host == "" {
+		slog.Warn("未配置Redis，节点查询功能将不可用", "op", "server.init")
+	} else if err := s.redisService.Connect(&s.config.Redis); err != nil {
 		// Redis失败不应该阻止服务启动，但会影响节点查询功能
-		log.Println("节点查询功能将不可用")
+		slog.Error("Redis服务初始化失败，节点查询功能将不可用", "op", "server.init", logging.Err(err))
 	}
 	
 	// 初始化节点服务
-	if s.redisService != nil {
-		s.nodeService = service.NewNodeService(s.redisService)
-		// 设置SystemService的NodeService引用
-		s.systemService.SetNodeService(s.nodeService)
-		// 初始化节点处理器
-		handlers.InitNodeHandler(s.nodeService)
-		log.Println("节点服务初始化成功")
+	s.nodeService = service.NewNodeService(s.redisService)
+	// 设置SystemService的NodeService引用
+	s.systemService.SetNodeService(s.nodeService)
+	// 按负载历史的基线检测异常
+	s.systemService.SetAnomalyDetector(service.NewAnomalyDetector(s.CurrentConfig))
+	// 初始化节点处理器
+	handlers.InitNodeHandler(s.nodeService)
+	
+	// 初始化节点负载状态发布
+	s.publisher = service.NewLoadPublisher(s.systemService, s.nodeService, s.redisService, s.CurrentConfig)
+	
+	// 初始化面板节点执行器
+	s.actuator = service.NewActuator(s.systemService, s.nodeService, s.CurrentConfig)
+	handlers.InitActuatorHandler(s.actuator)
+	
+	// 初始化负载历史采样和容量预测
+	s.history = service.NewHistoryRecorder(s.systemService, s.CurrentConfig)
+	handlers.InitForecastHandler(service.NewForecaster(s.systemService, s.CurrentConfig))
+	
+	// 初始化就绪检查
+	handlers.InitHealthHandler(service.NewHealthService(s.systemService, s.redisService, s.CurrentConfig))
+	
+	// 注册Prometheus负载指标
+	if err := metrics.Registry.Register(service.NewMetricsCollector(s.systemService, s.nodeService)); err != nil {
+		return fmt.Errorf("注册Prometheus指标失败: %w", err)
+	}
+	
+	// 初始化认证服务，首次启动时创建管理员
+	s.authService = service.NewAuthService(s.config.Auth)
+	if s.config.Auth.Enabled {
+		if err := s.authService.Bootstrap(); err != nil {
+			return err
+		}
+	} else {
+		slog.Warn("认证已关闭（AUTH_ENABLED=false），所有接口均可匿名访问", "op", "server.init")
 	}
 	
-	log.Println("Services initialized successfully")
+	slog.Info("Services initialized successfully", "op", "server.init")
 	return nil
 }
 
-// waitForShutdown 等待关闭信号
+// waitForShutdown 等待关闭信号，收到SIGHUP时重载配置
 func (s *Server) waitForShutdown() {
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	if s.configPath != "" {
+		go s.watchConfigFile(stopWatch)
+	}
+
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	
-	<-quit
-	log.Println("Received shutdown signal")
+	for sig := range quit {
+		if sig == syscall.SIGHUP {
+			slog.Info("Received SIGHUP, reloading configuration", "op", "server.signal")
+			s.Reload("signal")
+			continue
+		}
+		break
+	}
+	slog.Info("Received shutdown signal", "op", "server.signal")
 	
 	if err := s.Stop(); err != nil {
-		log.Printf("Error during shutdown: %v", err)
+		slog.Error("Error during shutdown", "op", "server.stop", logging.Err(err))
 	}
 }
\ No newline at end of file
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/internal/metrics"
+	"backend/internal/v2board"
+	"backend/pkg/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 执行器对高负载节点的操作
+const (
+	ActuatorHide = "hide" // 在面板中隐藏节点
+	ActuatorRate = "rate" // 调整节点倍率
+)
+
+// 执行器操作记录的状态
+const (
+	ActuationActive   = "active"   // 已生效，节点恢复后还原
+	ActuationReverted = "reverted" // 已还原
+	ActuationFailed   = "failed"   // 修改面板失败，没有生效
+)
+
+// actuatorRevertedBy 节点恢复后由执行器自动还原时记录的操作者
+const actuatorRevertedBy = "actuator"
+
+var (
+	// ErrActuationNotFound 操作记录不存在
+	ErrActuationNotFound = errors.New("操作记录不存在")
+	// ErrActuationNotActive 操作已还原或没有生效
+	ErrActuationNotActive = errors.New("操作已还原或没有生效")
+)
+
+// Actuator 根据高负载节点列表通过v2board/Xboard管理接口隐藏节点或调整倍率，节点恢复后还原。
+// 每次操作都记录操作前的值作为撤销日志；dry_run 时只记录不修改面板
+type Actuator struct {
+	systemService *SystemService
+	nodeService   *NodeService
+	currentConfig func() *config.Config
+	now           func() time.Time
+
+	// mu 串行化自动执行和手动撤销
+	mu sync.Mutex
+}
+
+// NewActuator 创建执行器，currentConfig 返回当前生效的配置，执行器配置可以热加载
+func NewActuator(systemService *SystemService, nodeService *NodeService, currentConfig func() *config.Config) *Actuator {
+	return &Actuator{
+		systemService: systemService,
+		nodeService:   nodeService,
+		currentConfig: currentConfig,
+		now:           time.Now,
+	}
+}
+
+// Run 按配置的间隔执行，直到ctx取消
+func (a *Actuator) Run(ctx context.Context) {
+	for {
+		cfg := a.currentConfig().Actuator
+		interval := publisherIdleInterval
+		if cfg.Enabled {
+			interval = time.Duration(cfg.IntervalSeconds) * time.Second
+			if err := a.RunOnce(ctx, &cfg); err != nil {
+				slog.WarnContext(ctx, "执行器运行失败", "op", "actuator.run", logging.Err(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RunOnce 获取当前的高负载节点并与面板同步一次
+func (a *Actuator) RunOnce(ctx context.Context, cfg *config.ActuatorConfig) error {
+	systems, err := a.systemService.GetSystemsWithLoadStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("获取系统负载状态失败: %w", err)
+	}
+	// PocketBase不可用时负载数据来自快照，不据此修改面板，也不还原已有的操作
+	if stale, asOf := staleLoadData(systems); stale {
+		slog.WarnContext(ctx, "负载数据来自快照，跳过本轮执行", "op", "actuator.run", "as_of", asOf)
+		return nil
+	}
+	high, err := a.nodeService.GetHighLoadNodes(ctx, systems)
+	if err != nil {
+		return fmt.Errorf("获取高负载节点失败: %w", err)
+	}
+	return a.reconcile(ctx, cfg, high)
+}
+
+// reconcile 对新出现的高负载节点执行操作，对已恢复的节点还原。
+// 先还原再执行新操作；同一节点两次操作间隔不足 node_cooldown_seconds 时推迟到下一轮，
+// 最近一小时的操作（不含还原）达到 max_actions_per_hour 后不再执行新操作
+func (a *Actuator) reconcile(ctx context.Context, cfg *config.ActuatorConfig, high []models.HighLoadNode) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	actuations, err := database.GetStorageContext(ctx).ListActuations()
+	if err != nil {
+		return fmt.Errorf("获取操作记录失败: %w", err)
+	}
+
+	now := a.now()
+	active := make(map[string]*models.Actuation)
+	lastAction := make(map[string]time.Time)
+	actionsLastHour := 0
+	for _, actuation := range actuations {
+		// 关闭dry_run后，之前只记录的操作作废，不计入冷却时间和操作上限，节点按新出现的高负载节点处理
+		if actuation.DryRun && !cfg.DryRun {
+			if actuation.Status == ActuationActive {
+				if err := a.closeActuation(ctx, actuation, actuatorRevertedBy, now); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		key := tagKey(actuation.NodeType, actuation.NodeID)
+		at := actuation.CreatedAt
+		if actuation.RevertedAt != nil {
+			at = *actuation.RevertedAt
+		}
+		if at.After(lastAction[key]) {
+			lastAction[key] = at
+		}
+		if now.Sub(actuation.CreatedAt) < time.Hour {
+			actionsLastHour++
+		}
+		if actuation.Status == ActuationActive {
+			active[key] = actuation
+		}
+	}
+
+	highByKey := make(map[string]models.HighLoadNode, len(high))
+	var pending []models.HighLoadNode
+	for _, node := range high {
+		key := tagKey(node.Type, node.ID)
+		highByKey[key] = node
+		if active[key] == nil {
+			pending = append(pending, node)
+		}
+	}
+	var recovered []*models.Actuation
+	for key, actuation := range active {
+		if _, ok := highByKey[key]; !ok {
+			recovered = append(recovered, actuation)
+		}
+	}
+	if len(pending) == 0 && len(recovered) == 0 {
+		return nil
+	}
+
+	client := newPanelClient(cfg)
+	panelNodes, err := client.GetNodes(ctx)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]*v2board.Node, len(panelNodes))
+	for _, node := range panelNodes {
+		byKey[tagKey(node.Type, node.ID)] = node
+	}
+
+	cooldown := time.Duration(cfg.NodeCooldownSeconds) * time.Second
+	coolingDown := func(key string) bool {
+		last, ok := lastAction[key]
+		return ok && now.Sub(last) < cooldown
+	}
+
+	sort.Slice(recovered, func(i, j int) bool { return recovered[i].CreatedAt.Before(recovered[j].CreatedAt) })
+	for _, actuation := range recovered {
+		key := tagKey(actuation.NodeType, actuation.NodeID)
+		if coolingDown(key) {
+			continue
+		}
+		if err := a.revert(ctx, client, actuation, byKey[key], actuatorRevertedBy); err != nil {
+			slog.WarnContext(ctx, "还原节点失败，下一轮重试", "op", "actuator.revert",
+				"node_type", actuation.NodeType, "node_id", actuation.NodeID, logging.Err(err))
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Type != pending[j].Type {
+			return pending[i].Type < pending[j].Type
+		}
+		return pending[i].ID < pending[j].ID
+	})
+	for i, node := range pending {
+		key := tagKey(node.Type, node.ID)
+		panelNode := byKey[key]
+		if panelNode == nil || coolingDown(key) {
+			continue
+		}
+		if actionsLastHour >= cfg.MaxActionsPerHour {
+			slog.WarnContext(ctx, "已达到每小时操作上限，剩余节点推迟到下一轮", "op", "actuator.apply",
+				"limit", cfg.MaxActionsPerHour, "pending", len(pending)-i)
+			break
+		}
+		attempted, err := a.apply(ctx, client, cfg, node, panelNode)
+		if attempted {
+			actionsLastHour++
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "修改面板节点失败", "op", "actuator.apply",
+				"node_type", node.Type, "node_id", node.ID, logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// apply 对高负载节点执行配置的操作。节点已经是目标状态（如已被手动隐藏）时不操作，
+// 也不记录，之后不会被执行器还原。attempted 表示是否计入操作次数
+func (a *Actuator) apply(ctx context.Context, client *v2board.Client, cfg *config.ActuatorConfig, node models.HighLoadNode, panelNode *v2board.Node) (attempted bool, err error) {
+	actuation := &models.Actuation{
+		NodeType:     node.Type,
+		NodeID:       node.ID,
+		NodeName:     node.Name,
+		SystemID:     node.SystemID,
+		Action:       cfg.Action,
+		Reason:       node.Reason,
+		PreviousShow: panelNode.Show,
+		PreviousRate: panelNode.Rate,
+		DryRun:       cfg.DryRun,
+		Status:       ActuationActive,
+		CreatedAt:    a.now(),
+	}
+	switch cfg.Action {
+	case ActuatorHide:
+		if !panelNode.Show {
+			return false, nil
+		}
+	case ActuatorRate:
+		if panelNode.Rate == cfg.HighRate {
+			return false, nil
+		}
+		actuation.Rate = cfg.HighRate
+	default:
+		return false, fmt.Errorf("未知的执行器操作 %q", cfg.Action)
+	}
+
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return false, fmt.Errorf("生成操作记录ID失败: %w", err)
+	}
+	actuation.ID = hex.EncodeToString(idBytes)
+
+	result := "dry_run"
+	if !cfg.DryRun {
+		result = "applied"
+		if cfg.Action == ActuatorHide {
+			err = client.SetShow(ctx, node.Type, node.ID, false)
+		} else {
+			err = client.SetRate(ctx, panelNode, cfg.HighRate)
+		}
+		if err != nil {
+			result = "failed"
+			actuation.Status = ActuationFailed
+			actuation.Error = err.Error()
+		}
+	}
+	metrics.ObserveActuation(cfg.Action, result)
+
+	if saveErr := database.GetStorageContext(ctx).SaveActuation(actuation); saveErr != nil {
+		return true, errors.Join(err, fmt.Errorf("保存操作记录失败: %w", saveErr))
+	}
+	if err == nil {
+		slog.InfoContext(ctx, "已修改面板节点", "op", "actuator.apply", "actuation_id", actuation.ID,
+			"node_type", node.Type, "node_id", node.ID, "node", node.Name, "action", cfg.Action,
+			"reason", node.Reason, "dry_run", cfg.DryRun)
+	}
+	return true, err
+}
+
+// revert 把节点还原为操作前的值。节点已被删除或已被其他人改动时不再修改面板，直接结束记录。
+// 还原失败时记录保持 active，下一轮重试
+func (a *Actuator) revert(ctx context.Context, client *v2board.Client, actuation *models.Actuation, panelNode *v2board.Node, by string) error {
+	if !actuation.DryRun && panelNode != nil {
+		var err error
+		switch {
+		case actuation.Action == ActuatorHide && !panelNode.Show:
+			err = client.SetShow(ctx, actuation.NodeType, actuation.NodeID, actuation.PreviousShow)
+		case actuation.Action == ActuatorRate && panelNode.Rate == actuation.Rate:
+			err = client.SetRate(ctx, panelNode, actuation.PreviousRate)
+		}
+		if err != nil {
+			metrics.ObserveActuation(actuation.Action, "revert_failed")
+			actuation.RevertError = err.Error()
+			if saveErr := database.GetStorageContext(ctx).SaveActuation(actuation); saveErr != nil {
+				return errors.Join(err, fmt.Errorf("保存操作记录失败: %w", saveErr))
+			}
+			return err
+		}
+	}
+
+	metrics.ObserveActuation(actuation.Action, "reverted")
+	if err := a.closeActuation(ctx, actuation, by, a.now()); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "已还原面板节点", "op", "actuator.revert", "actuation_id", actuation.ID,
+		"node_type", actuation.NodeType, "node_id", actuation.NodeID, "node", actuation.NodeName,
+		"action", actuation.Action, "reverted_by", by, "dry_run", actuation.DryRun)
+	return nil
+}
+
+// closeActuation 把记录标记为已还原
+func (a *Actuator) closeActuation(ctx context.Context, actuation *models.Actuation, by string, now time.Time) error {
+	actuation.Status = ActuationReverted
+	actuation.RevertedAt = &now
+	actuation.RevertedBy = by
+	actuation.RevertError = ""
+	if err := database.GetStorageContext(ctx).SaveActuation(actuation); err != nil {
+		return fmt.Errorf("保存操作记录失败: %w", err)
+	}
+	return nil
+}
+
+// Undo 手动还原一次操作。节点仍为高负载时，冷却时间过后会再次被操作，
+// 需要长期保留节点时应使用 policy 为 exclude 的维护窗口
+func (a *Actuator) Undo(ctx context.Context, id string, change Change) (*models.Actuation, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	actuation, err := a.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if actuation.Status != ActuationActive {
+		return nil, fmt.Errorf("%w: %s", ErrActuationNotActive, id)
+	}
+	before := *actuation
+
+	cfg := a.currentConfig().Actuator
+	client := newPanelClient(&cfg)
+	var panelNode *v2board.Node
+	if !actuation.DryRun {
+		panelNode, err = client.GetNode(ctx, actuation.NodeType, actuation.NodeID)
+		if err != nil && !errors.Is(err, v2board.ErrNodeNotFound) {
+			return nil, err
+		}
+	}
+	if err := a.revert(ctx, client, actuation, panelNode, change.Actor); err != nil {
+		return nil, err
+	}
+	recordAudit(change, "undo", AuditActuation, id, 0, &before, actuation)
+	return actuation, nil
+}
+
+// List 列出操作记录，按时间倒序；activeOnly为true时只返回尚未还原的
+func (a *Actuator) List(activeOnly bool) ([]*models.Actuation, error) {
+	actuations, err := database.GetStorage().ListActuations()
+	if err != nil {
+		return nil, fmt.Errorf("获取操作记录失败: %w", err)
+	}
+	result := make([]*models.Actuation, 0, len(actuations))
+	for _, actuation := range actuations {
+		if !activeOnly || actuation.Status == ActuationActive {
+			result = append(result, actuation)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// Get 获取操作记录
+func (a *Actuator) Get(id string) (*models.Actuation, error) {
+	actuation, err := database.GetStorage().GetActuation(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取操作记录失败: %w", err)
+	}
+	if actuation == nil {
+		return nil, fmt.Errorf("%w: %s", ErrActuationNotFound, id)
+	}
+	return actuation, nil
+}
+
+// Status 返回执行器的配置和操作统计
+func (a *Actuator) Status() (*models.ActuatorStatus, error) {
+	actuations, err := database.GetStorage().ListActuations()
+	if err != nil {
+		return nil, fmt.Errorf("获取操作记录失败: %w", err)
+	}
+	cfg := a.currentConfig().Actuator
+	status := &models.ActuatorStatus{
+		Enabled:           cfg.Enabled,
+		DryRun:            cfg.DryRun,
+		Flavor:            cfg.Flavor,
+		Action:            cfg.Action,
+		MaxActionsPerHour: cfg.MaxActionsPerHour,
+	}
+	now := a.now()
+	for _, actuation := range actuations {
+		if actuation.Status == ActuationActive {
+			status.Active++
+		}
+		if now.Sub(actuation.CreatedAt) < time.Hour {
+			status.ActionsLastHour++
+		}
+	}
+	return status, nil
+}
+
+// newPanelClient 按配置创建面板管理接口客户端
+func newPanelClient(cfg *config.ActuatorConfig) *v2board.Client {
+	client := v2board.NewClient(cfg.BaseURL, cfg.SecurePath, cfg.Flavor, cfg.Token, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	client.OnRequest = metrics.ObservePanelRequest
+	return client
+}
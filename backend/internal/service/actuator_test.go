@@ -0,0 +1,308 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/pocketbase"
+	"backend/internal/v2board"
+	"backend/internal/v2board/v2boardtest"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setupActuator 启动模拟面板并创建指向它的执行器，返回的配置可在测试中修改
+func setupActuator(t *testing.T, flavor string) (*Actuator, *config.ActuatorConfig, *v2boardtest.Panel) {
+	t.Helper()
+	setupThresholdStorage(t)
+
+	panel := v2boardtest.NewPanel(flavor)
+	t.Cleanup(panel.Close)
+	panel.AddNode("trojan", 1, "hk-01", 1)
+	panel.AddNode("trojan", 2, "hk-02", 1)
+	panel.AddNode("vmess", 3, "jp-01", 1)
+
+	cfg := config.Default()
+	cfg.Actuator.Enabled = true
+	cfg.Actuator.DryRun = false
+	cfg.Actuator.Flavor = flavor
+	cfg.Actuator.BaseURL = panel.URL
+	cfg.Actuator.SecurePath = v2boardtest.SecurePath
+	cfg.Actuator.Token = v2boardtest.Token
+
+	a := NewActuator(nil, nil, func() *config.Config { return cfg })
+	return a, &cfg.Actuator, panel
+}
+
+func highNode(nodeType string, id int) models.HighLoadNode {
+	return models.HighLoadNode{Type: nodeType, ID: id, SystemID: "sys1", Reason: HighLoadReasonHigh}
+}
+
+func activeActuations(t *testing.T, a *Actuator) []*models.Actuation {
+	t.Helper()
+	actuations, err := a.List(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actuations
+}
+
+func TestActuatorHideAndRevert(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorV2board)
+	ctx := context.Background()
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 0 {
+		t.Fatalf("高负载节点应被隐藏, show = %v", show)
+	}
+	active := activeActuations(t, a)
+	if len(active) != 1 || !active[0].PreviousShow || active[0].Action != ActuatorHide {
+		t.Fatalf("操作记录: %+v", active)
+	}
+
+	// 仍为高负载时不重复操作
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if writes := panel.Writes(); len(writes) != 1 {
+		t.Fatalf("写请求次数 = %d, 期望 1", len(writes))
+	}
+
+	// 冷却时间内恢复不还原，冷却结束后还原
+	if err := a.reconcile(ctx, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 0 {
+		t.Fatal("冷却时间内不应还原")
+	}
+	now = now.Add(time.Duration(cfg.NodeCooldownSeconds) * time.Second)
+	if err := a.reconcile(ctx, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 1 {
+		t.Fatalf("恢复后应重新显示, show = %v", show)
+	}
+	actuation, err := a.Get(active[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actuation.Status != ActuationReverted || actuation.RevertedBy != actuatorRevertedBy || actuation.RevertedAt == nil {
+		t.Errorf("还原后的记录: %+v", actuation)
+	}
+}
+
+func TestActuatorDryRun(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorV2board)
+	ctx := context.Background()
+	cfg.DryRun = true
+
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if writes := panel.Writes(); len(writes) != 0 {
+		t.Fatalf("dry_run 不应修改面板: %+v", writes)
+	}
+	active := activeActuations(t, a)
+	if len(active) != 1 || !active[0].DryRun {
+		t.Fatalf("dry_run 应记录操作: %+v", active)
+	}
+
+	// 关闭dry_run后之前的记录作废，节点立即被实际操作，不受冷却时间限制
+	cfg.DryRun = false
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 0 {
+		t.Fatalf("关闭dry_run后应隐藏节点, show = %v", show)
+	}
+	if active = activeActuations(t, a); len(active) != 1 || active[0].DryRun {
+		t.Fatalf("操作记录: %+v", active)
+	}
+}
+
+func TestActuatorRateXboard(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorXboard)
+	ctx := context.Background()
+	cfg.Action = ActuatorRate
+	cfg.HighRate = 3
+	cfg.NodeCooldownSeconds = 0
+
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("vmess", 3)}); err != nil {
+		t.Fatal(err)
+	}
+	if rate := panel.Node("vmess", 3)["rate"]; rate != "3" {
+		t.Fatalf("倍率 = %v, 期望 3", rate)
+	}
+	if show := panel.Node("vmess", 3)["show"]; show != true {
+		t.Errorf("调整倍率不应隐藏节点, show = %v", show)
+	}
+
+	if err := a.reconcile(ctx, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rate := panel.Node("vmess", 3)["rate"]; rate != "1" {
+		t.Errorf("恢复后倍率 = %v, 期望 1", rate)
+	}
+}
+
+func TestActuatorHourlyLimit(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorV2board)
+	ctx := context.Background()
+	now := time.Now()
+	a.now = func() time.Time { return now }
+	cfg.MaxActionsPerHour = 2
+
+	high := []models.HighLoadNode{highNode("trojan", 1), highNode("trojan", 2), highNode("vmess", 3)}
+	if err := a.reconcile(ctx, cfg, high); err != nil {
+		t.Fatal(err)
+	}
+	if active := activeActuations(t, a); len(active) != 2 {
+		t.Fatalf("达到上限后不应继续操作, 记录数 = %d", len(active))
+	}
+	if show := panel.Node("vmess", 3)["show"]; show != 1 {
+		t.Error("超过上限的节点不应被隐藏")
+	}
+
+	// 一小时后剩余节点被操作
+	now = now.Add(time.Hour)
+	if err := a.reconcile(ctx, cfg, high); err != nil {
+		t.Fatal(err)
+	}
+	if show := panel.Node("vmess", 3)["show"]; show != 0 {
+		t.Error("一小时后应隐藏剩余节点")
+	}
+}
+
+func TestActuatorPanelFailures(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorV2board)
+	ctx := context.Background()
+	cfg.NodeCooldownSeconds = 0
+
+	// 修改失败时记录为failed，下一轮重试
+	panel.FailWrites(1)
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := a.List(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Status != ActuationFailed || all[0].Error == "" {
+		t.Fatalf("失败的操作记录: %+v", all)
+	}
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	active := activeActuations(t, a)
+	if len(active) != 1 {
+		t.Fatalf("重试后应生效: %+v", active)
+	}
+
+	// 还原失败时记录保持active，下一轮重试
+	panel.FailWrites(1)
+	if err := a.reconcile(ctx, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	actuation, err := a.Get(active[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actuation.Status != ActuationActive || actuation.RevertError == "" {
+		t.Fatalf("还原失败后的记录: %+v", actuation)
+	}
+	if err := a.reconcile(ctx, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 1 {
+		t.Errorf("重试还原后应重新显示, show = %v", show)
+	}
+}
+
+func TestActuatorUndo(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorV2board)
+	ctx := context.Background()
+
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	id := activeActuations(t, a)[0].ID
+
+	actuation, err := a.Undo(ctx, id, Change{Actor: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actuation.Status != ActuationReverted || actuation.RevertedBy != "alice" {
+		t.Errorf("撤销后的记录: %+v", actuation)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 1 {
+		t.Errorf("撤销后应重新显示, show = %v", show)
+	}
+
+	entries, err := database.GetStorage().ListAuditEntries(&models.AuditQuery{Resource: AuditActuation})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != "undo" || entries[0].ResourceID != id {
+		t.Errorf("审计日志: %+v", entries)
+	}
+
+	if _, err := a.Undo(ctx, id, Change{}); !errors.Is(err, ErrActuationNotActive) {
+		t.Errorf("期望 ErrActuationNotActive, 得到 %v", err)
+	}
+	if _, err := a.Undo(ctx, "missing", Change{}); !errors.Is(err, ErrActuationNotFound) {
+		t.Errorf("期望 ErrActuationNotFound, 得到 %v", err)
+	}
+}
+
+func TestActuatorSkipsStaleSnapshot(t *testing.T) {
+	a, cfg, panel := setupActuator(t, v2board.FlavorV2board)
+	ctx := context.Background()
+
+	var hubDown atomic.Bool
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hubDown.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"page":1,"items":[{"id":"sys1","name":"hk","status":"up"}]}`))
+	}))
+	defer hub.Close()
+	client := pocketbase.NewClient(hub.URL)
+	client.SetAuthToken("static-token")
+	client.Retry = pocketbase.RetryPolicy{MaxAttempts: 1}
+	a.systemService = &SystemService{
+		pbClient:         client,
+		thresholdService: NewThresholdService(),
+		statsSnapshot:    make(map[string]*models.AverageStats),
+	}
+	a.nodeService = NewNodeService(nil)
+	if _, err := a.systemService.GetSystems(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.reconcile(ctx, cfg, []models.HighLoadNode{highNode("trojan", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return time.Now().Add(time.Duration(cfg.NodeCooldownSeconds) * time.Second) }
+
+	// 快照数据中没有高负载节点，但不能据此还原已隐藏的节点
+	hubDown.Store(true)
+	if err := a.RunOnce(ctx, cfg); err != nil {
+		t.Fatalf("快照数据应跳过本轮执行: %v", err)
+	}
+	if show := panel.Node("trojan", 1)["show"]; show != 0 {
+		t.Fatal("使用快照数据时不应还原节点")
+	}
+	if active := activeActuations(t, a); len(active) != 1 {
+		t.Fatalf("操作记录: %+v", active)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"backend/internal/database"
 	"backend/pkg/models"
+	"context"
 	"fmt"
 )
 
@@ -14,26 +15,51 @@ func NewAliasService() *AliasService {
 	return &AliasService{}
 }
 
-// SetAlias 设置服务器别名（每个服务器只能有一个别名）
-func (s *AliasService) SetAlias(systemID string, request *models.SystemAliasRequest) error {
+// SetAlias 设置服务器别名（每个服务器只能有一个别名），返回保存后的别名
+func (s *AliasService) SetAlias(systemID string, request *models.SystemAliasRequest, change Change) (*models.SystemAlias, error) {
 	storage := database.GetStorage()
 	
+	configMu.Lock()
+	defer configMu.Unlock()
+	
+	existing, err := storage.GetSystemAlias(systemID)
+	if err != nil {
+		return nil, fmt.Errorf("获取别名失败: %w", err)
+	}
+	if err := checkIfMatch(change.IfMatch, aliasVersion(existing)); err != nil {
+		return nil, err
+	}
+	
 	// 创建或更新别名
 	alias := &models.SystemAlias{
 		SystemID: systemID,
 		Alias:    request.Alias,
+		Version:  aliasVersion(existing) + 1,
 	}
 	
 	if err := storage.SetSystemAlias(alias); err != nil {
-		return fmt.Errorf("设置别名失败: %w", err)
+		return nil, fmt.Errorf("设置别名失败: %w", err)
 	}
 	
-	return nil
+	action := "update"
+	if existing == nil {
+		action = "create"
+	}
+	recordAudit(change, action, AuditAlias, systemID, alias.Version, existing, alias)
+	return alias, nil
+}
+
+// aliasVersion 存储中的别名版本，尚未设置过时为0
+func aliasVersion(alias *models.SystemAlias) int64 {
+	if alias == nil {
+		return 0
+	}
+	return alias.Version
 }
 
 // GetAlias 获取服务器别名
-func (s *AliasService) GetAlias(systemID string) (*models.SystemAlias, error) {
-	storage := database.GetStorage()
+func (s *AliasService) GetAlias(ctx context.Context, systemID string) (*models.SystemAlias, error) {
+	storage := database.GetStorageContext(ctx)
 	
 	alias, err := storage.GetSystemAlias(systemID)
 	if err != nil {
@@ -44,13 +70,28 @@ func (s *AliasService) GetAlias(systemID string) (*models.SystemAlias, error) {
 }
 
 // DeleteAlias 删除服务器别名
-func (s *AliasService) DeleteAlias(systemID string) error {
+func (s *AliasService) DeleteAlias(systemID string, change Change) error {
 	storage := database.GetStorage()
 	
+	configMu.Lock()
+	defer configMu.Unlock()
+	
+	existing, err := storage.GetSystemAlias(systemID)
+	if err != nil {
+		return fmt.Errorf("获取别名失败: %w", err)
+	}
+	if err := checkIfMatch(change.IfMatch, aliasVersion(existing)); err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+	
 	if err := storage.DeleteSystemAlias(systemID); err != nil {
 		return fmt.Errorf("删除别名失败: %w", err)
 	}
 	
+	recordAudit(change, "delete", AuditAlias, systemID, 0, existing, nil)
 	return nil
 }
 
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 异常检测状态，与负载状态相互独立
+const (
+	AnomalyStatusNormal  = "normal"
+	AnomalyStatusAnomaly = "anomaly"
+	AnomalyStatusUnknown = "unknown" // 所有指标的基线都不足
+)
+
+// 基线的计算方法
+const (
+	AnomalyMethodMAD    = "mad"
+	AnomalyMethodZScore = "zscore"
+)
+
+// anomalyMetrics 参与异常检测的指标
+var anomalyMetrics = []string{MetricCPU, MetricMemory, MetricNetUpMbps, MetricNetDownMbps, MetricOnlineUsers}
+
+// madScale 正态分布下MAD换算为标准差的系数
+const madScale = 1.4826
+
+// anomalyRecentExclusion 最近这段时间的历史不计入基线，避免持续中的异常拉偏基线
+const anomalyRecentExclusion = 24 * time.Hour
+
+// AnomalyDetector 把系统当前的指标与过去几周同一时段（星期几和小时，前后各放宽一小时）的每小时平均值比较，
+// 偏离基线的分数达到阈值时判定为异常。基线按系统缓存，每小时重新计算
+type AnomalyDetector struct {
+	currentConfig func() *config.Config
+	now           func() time.Time
+
+	mu        sync.Mutex
+	baselines map[string]*anomalyBaseline
+}
+
+// anomalyBaseline 系统在某个整点的基线
+type anomalyBaseline struct {
+	hour    time.Time
+	method  string
+	metrics map[string]*metricBaseline
+}
+
+type metricBaseline struct {
+	center    float64
+	deviation float64
+	samples   int
+}
+
+// NewAnomalyDetector 创建异常检测器，currentConfig 返回当前生效的配置
+func NewAnomalyDetector(currentConfig func() *config.Config) *AnomalyDetector {
+	return &AnomalyDetector{
+		currentConfig: currentConfig,
+		now:           time.Now,
+		baselines:     make(map[string]*anomalyBaseline),
+	}
+}
+
+// Evaluate 比较系统当前的指标与基线，未开启异常检测时返回nil。
+// 调用方应跳过维护中、已排空或负载数据不具代表性的系统（见 representativeLoad）
+func (d *AnomalyDetector) Evaluate(ctx context.Context, system *models.SystemWithAvgStats) (*models.SystemAnomaly, error) {
+	cfg := d.currentConfig()
+	if !cfg.Anomaly.Enabled {
+		return nil, nil
+	}
+	now := d.now()
+	baseline, err := d.baseline(ctx, system.ID, now, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SystemAnomaly{
+		SystemID:   system.ID,
+		SystemName: system.Name,
+		Status:     AnomalyStatusUnknown,
+		CheckedAt:  now,
+		Metrics:    make([]*models.MetricAnomaly, 0, len(anomalyMetrics)),
+	}
+	values := historyValues(system)
+	for _, metric := range anomalyMetrics {
+		anomaly := &models.MetricAnomaly{Metric: metric, Value: values[metric]}
+		result.Metrics = append(result.Metrics, anomaly)
+
+		b := baseline.metrics[metric]
+		if b != nil {
+			anomaly.Samples = b.samples
+		}
+		if b == nil || b.samples < cfg.Anomaly.MinSamples {
+			continue
+		}
+		anomaly.Baseline, anomaly.Deviation = b.center, b.deviation
+		anomaly.Score = (anomaly.Value - b.center) / b.deviation
+		if result.Status == AnomalyStatusUnknown {
+			result.Status = AnomalyStatusNormal
+		}
+		if math.Abs(anomaly.Score) >= cfg.Anomaly.Threshold {
+			anomaly.Anomalous = true
+			anomaly.Direction = "high"
+			if anomaly.Score < 0 {
+				anomaly.Direction = "low"
+			}
+			result.Status = AnomalyStatusAnomaly
+		}
+	}
+	return result, nil
+}
+
+// baseline 返回系统在当前整点的基线，同一小时内使用缓存
+func (d *AnomalyDetector) baseline(ctx context.Context, systemID string, now time.Time, cfg *config.Config) (*anomalyBaseline, error) {
+	hour := now.UTC().Truncate(time.Hour)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cached := d.baselines[systemID]; cached != nil && cached.hour.Equal(hour) && cached.method == cfg.Anomaly.Method {
+		return cached, nil
+	}
+
+	since := hour.Add(-time.Duration(cfg.History.RetentionDays) * 24 * time.Hour)
+	buckets, err := database.GetStorageContext(ctx).ListHistory(systemID, since)
+	if err != nil {
+		return nil, fmt.Errorf("获取负载历史失败: %w", err)
+	}
+	baseline := computeBaseline(buckets, hour, cfg.Anomaly.Method)
+	d.baselines[systemID] = baseline
+	return baseline, nil
+}
+
+// computeBaseline 取同一星期几、前后一小时以内且早于 anomalyRecentExclusion 的每小时平均值，计算每个指标的基线
+func computeBaseline(buckets []*models.HistoryBucket, hour time.Time, method string) *anomalyBaseline {
+	target := seasonSlot(hour, 168)
+	samples := make(map[string][]float64, len(anomalyMetrics))
+	for _, bucket := range buckets {
+		if hour.Sub(bucket.Hour) < anomalyRecentExclusion {
+			continue
+		}
+		distance := seasonSlot(bucket.Hour, 168) - target
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance > 1 && distance < 167 {
+			continue
+		}
+		for metric, value := range bucket.Metrics {
+			if value.Count > 0 {
+				samples[metric] = append(samples[metric], value.Sum/float64(value.Count))
+			}
+		}
+	}
+
+	baseline := &anomalyBaseline{hour: hour, method: method, metrics: make(map[string]*metricBaseline, len(samples))}
+	for metric, values := range samples {
+		var center, deviation float64
+		if method == AnomalyMethodZScore {
+			center, deviation = meanStd(values)
+		} else {
+			center, deviation = medianMAD(values)
+		}
+		baseline.metrics[metric] = &metricBaseline{
+			center:    center,
+			deviation: math.Max(deviation, minAnomalyDeviation(metric, center)),
+			samples:   len(values),
+		}
+	}
+	return baseline
+}
+
+// minAnomalyDeviation 基线标准差的下限，避免长期平稳的指标因很小的波动被判定为异常
+func minAnomalyDeviation(metric string, center float64) float64 {
+	switch metric {
+	case MetricCPU, MetricMemory:
+		return 2 // 百分点
+	case MetricOnlineUsers:
+		return math.Max(2, 0.1*math.Abs(center))
+	default:
+		return math.Max(1, 0.1*math.Abs(center)) // Mbps
+	}
+}
+
+// medianMAD 中位数和换算为标准差的中位数绝对偏差
+func medianMAD(values []float64) (median, deviation float64) {
+	median = medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, value := range values {
+		deviations[i] = math.Abs(value - median)
+	}
+	return median, madScale * medianOf(deviations)
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// meanStd 平均值和样本标准差
+func meanStd(values []float64) (mean, std float64) {
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	for _, value := range values {
+		std += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(std / float64(len(values)-1))
+}
+
+// GetAnomalies 获取所有系统的异常检测结果，all为false时只返回判定为异常的系统。
+// 异常的系统排在前面，按偏离最大的指标分数从高到低排序
+func (s *SystemService) GetAnomalies(ctx context.Context, all bool) ([]*models.SystemAnomaly, error) {
+	systems, err := s.GetSystemsWithLoadStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.SystemAnomaly, 0)
+	for _, system := range systems {
+		if system.AnomalyStatus == "" || (!all && system.AnomalyStatus != AnomalyStatusAnomaly) {
+			continue
+		}
+		// 基线已在计算负载状态时缓存，这里重新比较以返回全部指标
+		anomaly, err := s.anomalies.Evaluate(ctx, &system.SystemWithAvgStats)
+		if err != nil {
+			return nil, err
+		}
+		if anomaly != nil {
+			result = append(result, anomaly)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if (a.Status == AnomalyStatusAnomaly) != (b.Status == AnomalyStatusAnomaly) {
+			return a.Status == AnomalyStatusAnomaly
+		}
+		return maxAnomalyScore(a) > maxAnomalyScore(b)
+	})
+	return result, nil
+}
+
+func maxAnomalyScore(anomaly *models.SystemAnomaly) float64 {
+	score := 0.0
+	for _, metric := range anomaly.Metrics {
+		score = math.Max(score, math.Abs(metric.Score))
+	}
+	return score
+}
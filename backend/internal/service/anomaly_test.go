@@ -0,0 +1,161 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// normalLoad 系统平时的负载：CPU约20%，晚高峰（UTC 12-15点）下行带宽200Mbps，其余时间50Mbps
+func normalLoad(at time.Time) map[string]float64 {
+	down := 50.0
+	if at.Hour() >= 12 && at.Hour() < 16 {
+		down = 200
+	}
+	// 每周略有波动，基线的偏差不为0
+	wobble := float64(at.Day() % 3)
+	return map[string]float64{
+		MetricCPU:         20 + wobble,
+		MetricMemory:      40,
+		MetricNetUpMbps:   down / 10,
+		MetricNetDownMbps: down + 5*wobble,
+		MetricOnlineUsers: down / 2,
+	}
+}
+
+func loadStats(values map[string]float64) *models.SystemWithAvgStats {
+	return &models.SystemWithAvgStats{
+		System:      models.System{ID: "sys1", Name: "hk-01", Status: "up"},
+		AvgCPU:      values[MetricCPU],
+		AvgMemPct:   values[MetricMemory],
+		AvgNetSent:  values[MetricNetUpMbps] / 8,
+		AvgNetRecv:  values[MetricNetDownMbps] / 8,
+		OnlineUsers: int(values[MetricOnlineUsers]),
+	}
+}
+
+func TestAnomalyDetector(t *testing.T) {
+	setupThresholdStorage(t)
+	storage := database.GetStorage()
+	cfg := config.Default()
+	now := time.Date(2026, 10, 14, 13, 30, 0, 0, time.UTC) // 星期三晚高峰
+
+	// 过去三周每小时采样两次
+	for at := now.Add(-21 * 24 * time.Hour).Truncate(time.Hour); at.Before(now); at = at.Add(30 * time.Minute) {
+		if err := storage.AddHistorySample("sys1", at, normalLoad(at), 365*24*time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := NewAnomalyDetector(func() *config.Config { return cfg })
+	d.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	evaluate := func(t *testing.T, values map[string]float64) *models.SystemAnomaly {
+		t.Helper()
+		result, err := d.Evaluate(ctx, loadStats(values))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	metric := func(result *models.SystemAnomaly, name string) *models.MetricAnomaly {
+		for _, m := range result.Metrics {
+			if m.Metric == name {
+				return m
+			}
+		}
+		return nil
+	}
+
+	t.Run("正常负载", func(t *testing.T) {
+		result := evaluate(t, normalLoad(now))
+		if result.Status != AnomalyStatusNormal || len(result.Metrics) != len(anomalyMetrics) {
+			t.Fatalf("结果: %+v", result)
+		}
+		// 基线只取过去几周星期三12-14点（最早一周从13点开始采样），最近24小时不计入
+		down := metric(result, MetricNetDownMbps)
+		if down.Samples != 8 || down.Baseline < 200 || down.Baseline > 210 {
+			t.Errorf("下行带宽基线: %+v", down)
+		}
+	})
+
+	t.Run("CPU突增", func(t *testing.T) {
+		values := normalLoad(now)
+		values[MetricCPU] = 70
+		result := evaluate(t, values)
+		cpu := metric(result, MetricCPU)
+		if result.Status != AnomalyStatusAnomaly || !cpu.Anomalous || cpu.Direction != "high" {
+			t.Fatalf("CPU: %+v", cpu)
+		}
+		if metric(result, MetricMemory).Anomalous {
+			t.Error("内存不应判定为异常")
+		}
+	})
+
+	t.Run("流量骤降", func(t *testing.T) {
+		values := normalLoad(now)
+		values[MetricNetDownMbps] = 20
+		values[MetricOnlineUsers] = 10
+		result := evaluate(t, values)
+		down := metric(result, MetricNetDownMbps)
+		if result.Status != AnomalyStatusAnomaly || down.Direction != "low" || !metric(result, MetricOnlineUsers).Anomalous {
+			t.Fatalf("结果: %+v", down)
+		}
+	})
+
+	t.Run("平时的流量在高峰时段不正常", func(t *testing.T) {
+		values := normalLoad(now)
+		values[MetricNetDownMbps] = 50
+		if down := metric(evaluate(t, values), MetricNetDownMbps); !down.Anomalous {
+			t.Errorf("高峰时段的50Mbps应判定为异常: %+v", down)
+		}
+	})
+
+	t.Run("zscore", func(t *testing.T) {
+		cfg.Anomaly.Method = AnomalyMethodZScore
+		defer func() { cfg.Anomaly.Method = AnomalyMethodMAD }()
+		values := normalLoad(now)
+		values[MetricCPU] = 70
+		result := evaluate(t, values)
+		cpu := metric(result, MetricCPU)
+		if !cpu.Anomalous || math.Abs(cpu.Baseline-21) > 1 {
+			t.Errorf("CPU: %+v", cpu)
+		}
+	})
+
+	t.Run("基线不足", func(t *testing.T) {
+		stats := loadStats(normalLoad(now))
+		stats.ID = "new"
+		result, err := d.Evaluate(ctx, stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != AnomalyStatusUnknown || metric(result, MetricCPU).Score != 0 {
+			t.Errorf("结果: %+v", result)
+		}
+	})
+
+	t.Run("未开启", func(t *testing.T) {
+		cfg.Anomaly.Enabled = false
+		defer func() { cfg.Anomaly.Enabled = true }()
+		if result := evaluate(t, normalLoad(now)); result != nil {
+			t.Errorf("未开启时应返回nil: %+v", result)
+		}
+	})
+}
+
+func TestMedianMAD(t *testing.T) {
+	median, deviation := medianMAD([]float64{10, 12, 11, 13, 90})
+	if median != 12 || math.Abs(deviation-madScale) > 1e-9 {
+		t.Errorf("median = %v, deviation = %v", median, deviation)
+	}
+	mean, std := meanStd([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if mean != 5 || math.Abs(std-2.138) > 0.001 {
+		t.Errorf("mean = %v, std = %v", mean, std)
+	}
+}
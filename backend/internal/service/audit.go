@@ -0,0 +1,170 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/pkg/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 审计记录的资源类型
+const (
+	AuditThreshold        = "threshold"
+	AuditAlias            = "alias"
+	AuditThresholdProfile = "threshold_profile"
+	AuditNodeTag          = "node_tag"
+	AuditImport           = "import"
+	AuditUser             = "user"
+	AuditAPIKey           = "api_key"
+	AuditMaintenance      = "maintenance"
+	AuditDrain            = "drain"
+	AuditActuation        = "actuation"
+)
+
+// ErrVersionConflict If-Match与当前版本不一致，说明配置已被其他人修改
+var ErrVersionConflict = errors.New("配置已被修改，请刷新后重试")
+
+// Change 一次配置修改的上下文
+type Change struct {
+	Actor   string // 操作者，写入审计日志
+	IfMatch string // 客户端的If-Match头，为空时不检查版本
+}
+
+// configMu 串行化阈值和别名的读-校验-写，保证If-Match检查与写入之间不会插入其他修改
+var configMu sync.Mutex
+
+// checkIfMatch 检查If-Match是否与当前版本匹配。
+// 版本号即ETag的值（如 "3"，弱ETag W/"3" 同样接受）；"*" 要求配置已存在；
+// 尚未保存过的配置版本为0。
+func checkIfMatch(ifMatch string, current int64) error {
+	if ifMatch == "" {
+		return nil
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			if current > 0 {
+				return nil
+			}
+			continue
+		}
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+		if v, err := strconv.ParseInt(tag, 10, 64); err == nil && v == current {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w（当前版本 %d）", ErrVersionConflict, current)
+}
+
+// recordAudit 写入审计记录。写入失败只记录日志，不影响已经完成的修改
+func recordAudit(change Change, action, resource, resourceID string, version int64, before, after interface{}) {
+	entry := &models.AuditEntry{
+		Actor:      change.Actor,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Version:    version,
+		Changes:    auditDiff(before, after),
+	}
+	if entry.Actor == "" {
+		entry.Actor = "system"
+	}
+	if err := database.GetStorage().AppendAuditEntry(entry); err != nil {
+		slog.Error("写入审计日志失败", "op", "audit.append", "action", action, "resource", resource, "resource_id", resourceID, logging.Err(err))
+	}
+}
+
+// auditVersion 修改后的版本，没有版本的资源返回0
+func auditVersion(after interface{}) int64 {
+	switch v := after.(type) {
+	case *models.SystemThreshold:
+		return v.Version
+	case *models.SystemAlias:
+		return v.Version
+	}
+	return 0
+}
+
+// recordImportAudit 记录一次导入的汇总，逐项变更另有各自的审计记录
+func recordImportAudit(change Change, result *models.ImportResult) {
+	entry := &models.AuditEntry{
+		Actor:    change.Actor,
+		Action:   "import",
+		Resource: AuditImport,
+		Detail: fmt.Sprintf("mode=%s match=%s create=%d update=%d delete=%d skip=%d", result.Mode, result.Match,
+			result.Summary[ActionCreate], result.Summary[ActionUpdate], result.Summary[ActionDelete], result.Summary[ActionSkip]),
+	}
+	if entry.Actor == "" {
+		entry.Actor = "system"
+	}
+	if err := database.GetStorage().AppendAuditEntry(entry); err != nil {
+		slog.Error("写入审计日志失败", "op", "audit.append", "action", "import", "resource", AuditImport, logging.Err(err))
+	}
+}
+
+// auditIgnoredFields 不计入审计差异的字段
+var auditIgnoredFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+	"sources":    true,
+}
+
+// auditDiff 按JSON字段比较修改前后的值，nil表示不存在
+func auditDiff(before, after interface{}) []models.AuditFieldChange {
+	b, a := toFieldMap(before), toFieldMap(after)
+
+	fields := make(map[string]bool)
+	for k := range b {
+		fields[k] = true
+	}
+	for k := range a {
+		fields[k] = true
+	}
+
+	var changes []models.AuditFieldChange
+	for field := range fields {
+		if auditIgnoredFields[field] || reflect.DeepEqual(b[field], a[field]) {
+			continue
+		}
+		changes = append(changes, models.AuditFieldChange{Field: field, Before: b[field], After: a[field]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func toFieldMap(v interface{}) map[string]interface{} {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// ListAudit 查询审计记录
+func ListAudit(query *models.AuditQuery) ([]*models.AuditEntry, error) {
+	entries, err := database.GetStorage().ListAuditEntries(query)
+	if err != nil {
+		return nil, fmt.Errorf("获取审计日志失败: %w", err)
+	}
+	if entries == nil {
+		entries = []*models.AuditEntry{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestThresholdIfMatchAndAudit(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	// 尚未保存的配置版本为0
+	if _, err := s.UpdateThreshold("sys1", putBody(t, map[string]any{
+		"overrides": &models.ThresholdValues{CPUAlertLimit: floatPtr(70)},
+	}), Change{Actor: "alice", IfMatch: `"0"`}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := s.GetThreshold(context.Background(), "sys1")
+	if got.Version != 1 {
+		t.Fatalf("version = %d, 期望1", got.Version)
+	}
+
+	// 采集过程更新网络最大值不改变版本
+	if err := s.UpdateNetworkMax(context.Background(), "sys1", 10, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.GetThreshold(context.Background(), "sys1"); got.Version != 1 {
+		t.Fatalf("UpdateNetworkMax 后 version = %d, 期望不变", got.Version)
+	}
+
+	// bob基于版本1修改成功；alice仍持有版本1，她的修改被拒绝
+	patch := map[string]json.RawMessage{"mem_alert_limit": json.RawMessage(`60`)}
+	if _, err := s.PatchThreshold("sys1", patch, Change{Actor: "bob", IfMatch: `W/"1"`}); err != nil {
+		t.Fatal(err)
+	}
+	patch = map[string]json.RawMessage{"cpu_alert_limit": json.RawMessage(`50`)}
+	if _, err := s.PatchThreshold("sys1", patch, Change{Actor: "alice", IfMatch: `"1"`}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("err = %v, 期望 ErrVersionConflict", err)
+	}
+	if err := s.DeleteThreshold("sys1", Change{Actor: "alice", IfMatch: `"1"`}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("删除 err = %v, 期望 ErrVersionConflict", err)
+	}
+	if err := s.DeleteThreshold("sys1", Change{Actor: "alice", IfMatch: "*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ListAudit(&models.AuditQuery{Resource: AuditThreshold, ResourceID: "sys1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("审计记录 %d 条, 期望3条（create、update、delete）", len(entries))
+	}
+	// 按时间倒序
+	if entries[0].Action != "delete" || entries[1].Action != "update" || entries[2].Action != "create" {
+		t.Errorf("审计顺序 = %s, %s, %s", entries[0].Action, entries[1].Action, entries[2].Action)
+	}
+	update := entries[1]
+	if update.Actor != "bob" || update.Version != 2 {
+		t.Errorf("update 记录 = %+v", update)
+	}
+	var memChanged bool
+	for _, change := range update.Changes {
+		if change.Field == "mem_alert_limit" {
+			memChanged = change.Before == 90.0 && change.After == 60.0
+		}
+		if change.Field == "net_up_max" {
+			t.Errorf("网络最大值没有被用户修改，不应出现在差异中: %+v", change)
+		}
+	}
+	if !memChanged {
+		t.Errorf("update 记录缺少 mem_alert_limit 90→60: %+v", update.Changes)
+	}
+
+	byActor, _ := ListAudit(&models.AuditQuery{Actor: "alice"})
+	if len(byActor) != 2 {
+		t.Errorf("alice 的审计记录 %d 条, 期望2条", len(byActor))
+	}
+}
+
+func TestAliasIfMatch(t *testing.T) {
+	setupThresholdStorage(t)
+	s := NewAliasService()
+
+	alias, err := s.SetAlias("sys1", &models.SystemAliasRequest{Alias: "东京1"}, Change{Actor: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alias.Version != 1 {
+		t.Fatalf("version = %d, 期望1", alias.Version)
+	}
+	if _, err := s.SetAlias("sys1", &models.SystemAliasRequest{Alias: "东京2"}, Change{Actor: "bob", IfMatch: `"1"`}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetAlias("sys1", &models.SystemAliasRequest{Alias: "东京3"}, Change{Actor: "alice", IfMatch: `"1"`}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("err = %v, 期望 ErrVersionConflict", err)
+	}
+	stored, _ := database.GetStorage().GetSystemAlias("sys1")
+	if stored.Alias != "东京2" || stored.Version != 2 {
+		t.Errorf("别名 = %+v, 期望bob的修改", stored)
+	}
+}
+
+func TestVersionContinuesAfterDelete(t *testing.T) {
+	thresholds := setupThresholdStorage(t)
+	aliases := NewAliasService()
+	ctx := context.Background()
+
+	if _, err := aliases.SetAlias("sys1", &models.SystemAliasRequest{Alias: "东京1"}, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := aliases.DeleteAlias("sys1", Change{IfMatch: `"1"`}); err != nil {
+		t.Fatal(err)
+	}
+	// 重新创建的别名不能复用删除前的版本，否则删除前拿到的ETag会匹配到新记录
+	alias, err := aliases.SetAlias("sys1", &models.SystemAliasRequest{Alias: "东京2"}, Change{IfMatch: `"0"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alias.Version != 2 {
+		t.Fatalf("重新创建的别名 version = %d, 期望2", alias.Version)
+	}
+	if err := aliases.DeleteAlias("sys1", Change{IfMatch: `"1"`}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("删除前的ETag: err = %v, 期望 ErrVersionConflict", err)
+	}
+
+	if _, err := thresholds.UpdateThreshold("sys1", putBody(t, map[string]any{"overrides": map[string]any{}}), Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := thresholds.DeleteThreshold("sys1", Change{IfMatch: `"1"`}); err != nil {
+		t.Fatal(err)
+	}
+	// 采集过程重新创建记录时同样从删除前的版本继续
+	if err := thresholds.UpdateNetworkMax(ctx, "sys1", 10, 10); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := thresholds.GetThreshold(ctx, "sys1")
+	if got.Version != 2 {
+		t.Fatalf("重新创建的阈值 version = %d, 期望2", got.Version)
+	}
+	patch := map[string]json.RawMessage{"cpu_alert_limit": json.RawMessage(`50`)}
+	if _, err := thresholds.PatchThreshold("sys1", patch, Change{IfMatch: `"1"`}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("删除前的ETag: err = %v, 期望 ErrVersionConflict", err)
+	}
+}
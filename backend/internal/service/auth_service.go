@@ -0,0 +1,688 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/pkg/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 角色，按权限从低到高：viewer只读，operator可修改阈值、别名等配置，admin可管理用户、密钥和服务配置
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// API密钥作用域：load-status 只允许访问面板使用的负载状态接口，metrics 只允许Prometheus抓取
+const (
+	ScopeLoadStatus = "load-status"
+	ScopeMetrics    = "metrics"
+)
+
+// token前缀：API密钥格式为 bsk_<id>_<secret>，会话token为 bss_<random>
+const (
+	apiKeyPrefix  = "bsk_"
+	sessionPrefix = "bss_"
+)
+
+// 登录失败限制：同一用户名和来源连续失败达到次数后暂时拒绝登录；
+// 同一用户名不论来源累计失败达到 maxUserLoginFailures 次后同样锁定，防止轮换来源地址绕过限制
+const (
+	maxLoginFailures     = 5
+	maxUserLoginFailures = 20
+	loginLockout         = 5 * time.Minute
+	minPasswordLen   = 8
+	apiKeyTouchEvery = time.Minute
+)
+
+var (
+	// ErrUnauthenticated 未登录、会话过期或密钥无效
+	ErrUnauthenticated = errors.New("未登录或登录已过期")
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	// ErrLoginLocked 登录失败次数过多
+	ErrLoginLocked = errors.New("登录失败次数过多，请稍后再试")
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("用户不存在")
+	// ErrUserExists 用户名已存在
+	ErrUserExists = errors.New("用户已存在")
+	// ErrAPIKeyNotFound API密钥不存在
+	ErrAPIKeyNotFound = errors.New("API密钥不存在")
+	// ErrLastAdmin 不能删除或降级最后一个可用的管理员
+	ErrLastAdmin = errors.New("至少需要保留一个可用的管理员")
+	// ErrInvalidAuthRequest 用户或密钥参数不合法
+	ErrInvalidAuthRequest = errors.New("参数错误")
+)
+
+// roleRank 角色的权限等级
+var roleRank = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// apiKeyScopes 可以分配给API密钥的作用域
+var apiKeyScopes = []string{ScopeLoadStatus, ScopeMetrics}
+
+// usernamePattern 用户名：字母、数字、点、连字符和下划线
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+
+// RoleAllows 角色have是否满足最低角色need
+func RoleAllows(have, need string) bool {
+	return roleRank[have] > 0 && roleRank[have] >= roleRank[need]
+}
+
+// Principal 已认证的调用方
+type Principal struct {
+	Kind     string   `json:"kind"` // user, api_key
+	Name     string   `json:"name"` // 用户名或密钥名称
+	Role     string   `json:"role"`
+	Scopes   []string `json:"scopes,omitempty"`
+	APIKeyID string   `json:"api_key_id,omitempty"`
+	// TokenHash 当前会话token的哈希，登出时使用
+	TokenHash string `json:"-"`
+}
+
+// Actor 写入审计日志的操作者名称，API密钥带上前缀以便与用户区分
+func (p *Principal) Actor() string {
+	if p.Kind == "api_key" {
+		return "apikey:" + p.Name
+	}
+	return p.Name
+}
+
+// HasScope 是否可以访问要求scope作用域的接口。没有作用域的密钥和用户不受限制，
+// 带作用域的密钥只能访问声明了相同作用域的接口
+func (p *Principal) HasScope(scope string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+	return scope != "" && slices.Contains(p.Scopes, scope)
+}
+
+// AuthService 本地用户、登录会话和API密钥
+type AuthService struct {
+	cfg config.AuthConfig
+	now func() time.Time
+
+	failuresMu sync.Mutex
+	failures   map[string]*loginFailure
+	nextSweep  time.Time
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+}
+
+// loginFailure 连续登录失败的记录
+type loginFailure struct {
+	count int
+	until time.Time
+}
+
+// NewAuthService 创建认证服务
+func NewAuthService(cfg config.AuthConfig) *AuthService {
+	return &AuthService{
+		cfg:      cfg,
+		now:      time.Now,
+		failures: make(map[string]*loginFailure),
+	}
+}
+
+// Enabled 是否启用认证
+func (s *AuthService) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// CookieSecure 会话cookie是否只通过HTTPS发送
+func (s *AuthService) CookieSecure() bool {
+	return s.cfg.CookieSecure
+}
+
+// Bootstrap 没有任何用户时创建初始管理员。未配置密码时生成随机密码，只打印这一次。
+// 随机密码直接写到标准错误输出，不经过结构化日志，避免随日志转发到其他主机
+func (s *AuthService) Bootstrap() error {
+	users, err := database.GetStorage().ListUsers()
+	if err != nil {
+		return fmt.Errorf("获取用户列表失败: %w", err)
+	}
+	if len(users) > 0 {
+		return nil
+	}
+
+	password := s.cfg.AdminPassword
+	generated := password == ""
+	if generated {
+		if password, err = randomToken(18); err != nil {
+			return err
+		}
+	}
+	if _, err := s.CreateUser(&models.UserRequest{
+		Username: s.cfg.AdminUsername,
+		Password: password,
+		Role:     RoleAdmin,
+	}, Change{Actor: "system"}); err != nil {
+		return fmt.Errorf("创建初始管理员失败: %w", err)
+	}
+
+	if generated {
+		fmt.Fprintf(os.Stderr, "\n已创建初始管理员 %s，随机密码: %s\n请登录后立即修改，此密码不会再次显示\n\n", s.cfg.AdminUsername, password)
+		slog.Warn("已创建初始管理员，随机密码已打印到标准错误输出", "op", "auth.bootstrap", "username", s.cfg.AdminUsername)
+	} else {
+		slog.Info("已创建初始管理员", "op", "auth.bootstrap", "username", s.cfg.AdminUsername)
+	}
+	return nil
+}
+
+// Login 校验用户名和密码并创建会话，返回会话token（只在此时可见）
+func (s *AuthService) Login(username, password, remoteAddr string) (string, *models.Session, *models.User, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	failureKey := username + "|" + remoteAddr
+	userKey := "user:" + username
+	if s.lockedOut(failureKey, maxLoginFailures) || s.lockedOut(userKey, maxUserLoginFailures) {
+		return "", nil, nil, ErrLoginLocked
+	}
+
+	storage := database.GetStorage()
+	user, err := storage.GetUser(username)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	// 用户不存在时同样比较一次哈希，避免通过响应时间探测用户名
+	hash := s.getDummyHash()
+	if user != nil {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user == nil || user.Disabled {
+		s.recordFailure(failureKey)
+		s.recordFailure(userKey)
+		slog.Warn("用户登录失败", "op", "auth.login", "username", username, "remote_addr", remoteAddr)
+		return "", nil, nil, ErrInvalidCredentials
+	}
+	s.clearFailures(failureKey, userKey)
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	token = sessionPrefix + token
+	now := s.now()
+	session := &models.Session{
+		TokenHash: hashToken(token),
+		Username:  user.Username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(s.cfg.SessionTTLMinutes) * time.Minute),
+	}
+	if err := storage.CreateSession(session); err != nil {
+		return "", nil, nil, fmt.Errorf("创建会话失败: %w", err)
+	}
+
+	user.LastLoginAt = &now
+	if err := storage.CreateOrUpdateUser(user); err != nil {
+		slog.Warn("更新用户登录时间失败", "op", "auth.login", "username", user.Username, logging.Err(err))
+	}
+	return token, session, publicUser(user), nil
+}
+
+// Logout 删除当前会话，API密钥没有会话，直接忽略
+func (s *AuthService) Logout(principal *Principal) error {
+	if principal == nil || principal.TokenHash == "" {
+		return nil
+	}
+	if err := database.GetStorage().DeleteSession(principal.TokenHash); err != nil {
+		return fmt.Errorf("删除会话失败: %w", err)
+	}
+	return nil
+}
+
+// Authenticate 校验会话token或API密钥
+func (s *AuthService) Authenticate(token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		return s.authenticateAPIKey(token)
+	}
+
+	storage := database.GetStorage()
+	tokenHash := hashToken(token)
+	session, err := storage.GetSession(tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("获取会话失败: %w", err)
+	}
+	if session == nil || s.now().After(session.ExpiresAt) {
+		return nil, ErrUnauthenticated
+	}
+	// 每次都读取用户，禁用用户或修改角色立即生效
+	user, err := storage.GetUser(session.Username)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user == nil || user.Disabled {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Kind: "user", Name: user.Username, Role: user.Role, TokenHash: tokenHash}, nil
+}
+
+// authenticateAPIKey 校验 bsk_<id>_<secret> 格式的API密钥
+func (s *AuthService) authenticateAPIKey(token string) (*Principal, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, apiKeyPrefix), "_")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	storage := database.GetStorage()
+	key, err := storage.GetAPIKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, ErrUnauthenticated
+	}
+	now := s.now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, ErrUnauthenticated
+	}
+
+	// 面板会频繁轮询，最后使用时间只按分钟更新
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchEvery {
+		key.LastUsedAt = &now
+		if err := storage.CreateOrUpdateAPIKey(key); err != nil {
+			slog.Warn("更新API密钥使用时间失败", "op", "auth.api_key", "api_key", key.Name, logging.Err(err))
+		}
+	}
+	return &Principal{Kind: "api_key", Name: key.Name, Role: key.Role, Scopes: key.Scopes, APIKeyID: key.ID}, nil
+}
+
+// GetUser 获取用户（不含密码哈希）
+func (s *AuthService) GetUser(username string) (*models.User, error) {
+	username = strings.ToLower(username)
+	user, err := database.GetStorage().GetUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	return publicUser(user), nil
+}
+
+// ListUsers 列出所有用户（不含密码哈希）
+func (s *AuthService) ListUsers() ([]*models.User, error) {
+	users, err := database.GetStorage().ListUsers()
+	if err != nil {
+		return nil, fmt.Errorf("获取用户列表失败: %w", err)
+	}
+	result := make([]*models.User, 0, len(users))
+	for _, user := range users {
+		result = append(result, publicUser(user))
+	}
+	return result, nil
+}
+
+// CreateUser 创建用户，用户名不区分大小写
+func (s *AuthService) CreateUser(req *models.UserRequest, change Change) (*models.User, error) {
+	username := strings.ToLower(strings.TrimSpace(req.Username))
+	if !usernamePattern.MatchString(username) {
+		return nil, fmt.Errorf("%w: 用户名只能包含字母、数字、点、连字符和下划线", ErrInvalidAuthRequest)
+	}
+	if req.Role == "" {
+		req.Role = RoleViewer
+	}
+	if roleRank[req.Role] == 0 {
+		return nil, fmt.Errorf("%w: 未知角色 %q", ErrInvalidAuthRequest, req.Role)
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	storage := database.GetStorage()
+	existing, err := storage.GetUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserExists, username)
+	}
+
+	user := &models.User{Username: username, PasswordHash: hash, Role: req.Role}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	if err := storage.CreateOrUpdateUser(user); err != nil {
+		return nil, fmt.Errorf("保存用户失败: %w", err)
+	}
+	recordAudit(change, "create", AuditUser, username, 0, nil, publicUser(user))
+	return publicUser(user), nil
+}
+
+// UpdateUser 修改用户的角色、禁用状态或重置密码。禁用和重置密码会使该用户的所有会话失效
+func (s *AuthService) UpdateUser(username string, req *models.UserRequest, change Change) (*models.User, error) {
+	username = strings.ToLower(username)
+	if req.Role != "" && roleRank[req.Role] == 0 {
+		return nil, fmt.Errorf("%w: 未知角色 %q", ErrInvalidAuthRequest, req.Role)
+	}
+	var hash string
+	if req.Password != "" {
+		var err error
+		if hash, err = hashPassword(req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	storage := database.GetStorage()
+	stored, err := storage.GetUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	user := *stored
+	if req.Role != "" {
+		user.Role = req.Role
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	if hash != "" {
+		user.PasswordHash = hash
+	}
+	if isActiveAdmin(stored) && !isActiveAdmin(&user) {
+		if err := s.checkOtherAdmin(username); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := storage.CreateOrUpdateUser(&user); err != nil {
+		return nil, fmt.Errorf("保存用户失败: %w", err)
+	}
+	if hash != "" || user.Disabled {
+		if err := storage.DeleteUserSessions(username); err != nil {
+			slog.Warn("清除用户会话失败", "op", "auth.sessions", "username", username, logging.Err(err))
+		}
+	}
+	recordAudit(change, "update", AuditUser, username, 0, publicUser(stored), publicUser(&user))
+	if hash != "" {
+		recordAudit(change, "reset_password", AuditUser, username, 0, nil, nil)
+	}
+	return publicUser(&user), nil
+}
+
+// ChangePassword 用户修改自己的密码，需要提供旧密码。修改后该用户的所有会话失效
+func (s *AuthService) ChangePassword(username, oldPassword, newPassword string, change Change) error {
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	storage := database.GetStorage()
+	user, err := storage.GetUser(username)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)) != nil {
+		return fmt.Errorf("%w: 原密码错误", ErrInvalidAuthRequest)
+	}
+
+	user.PasswordHash = hash
+	if err := storage.CreateOrUpdateUser(user); err != nil {
+		return fmt.Errorf("保存用户失败: %w", err)
+	}
+	if err := storage.DeleteUserSessions(username); err != nil {
+		slog.Warn("清除用户会话失败", "op", "auth.sessions", "username", username, logging.Err(err))
+	}
+	recordAudit(change, "change_password", AuditUser, username, 0, nil, nil)
+	return nil
+}
+
+// DeleteUser 删除用户及其会话，不能删除最后一个可用的管理员
+func (s *AuthService) DeleteUser(username string, change Change) error {
+	username = strings.ToLower(username)
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	storage := database.GetStorage()
+	user, err := storage.GetUser(username)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if isActiveAdmin(user) {
+		if err := s.checkOtherAdmin(username); err != nil {
+			return err
+		}
+	}
+
+	if err := storage.DeleteUser(username); err != nil {
+		return fmt.Errorf("删除用户失败: %w", err)
+	}
+	if err := storage.DeleteUserSessions(username); err != nil {
+		slog.Warn("清除用户会话失败", "op", "auth.sessions", "username", username, logging.Err(err))
+	}
+	recordAudit(change, "delete", AuditUser, username, 0, publicUser(user), nil)
+	return nil
+}
+
+// checkOtherAdmin 除username外是否还有可用的管理员
+func (s *AuthService) checkOtherAdmin(username string) error {
+	users, err := database.GetStorage().ListUsers()
+	if err != nil {
+		return fmt.Errorf("获取用户列表失败: %w", err)
+	}
+	for _, user := range users {
+		if user.Username != username && isActiveAdmin(user) {
+			return nil
+		}
+	}
+	return ErrLastAdmin
+}
+
+// ListAPIKeys 列出所有API密钥（不含密钥哈希）
+func (s *AuthService) ListAPIKeys() ([]*models.APIKey, error) {
+	keys, err := database.GetStorage().ListAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	result := make([]*models.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, publicAPIKey(key))
+	}
+	return result, nil
+}
+
+// CreateAPIKey 创建API密钥，返回的明文密钥只在此时可见。
+// 未指定角色时为viewer；作用域为空时可访问角色允许的所有接口
+func (s *AuthService) CreateAPIKey(req *models.APIKeyRequest, change Change) (string, *models.APIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: 密钥名称不能为空", ErrInvalidAuthRequest)
+	}
+	role := req.Role
+	if role == "" {
+		role = RoleViewer
+	}
+	if roleRank[role] == 0 {
+		return "", nil, fmt.Errorf("%w: 未知角色 %q", ErrInvalidAuthRequest, role)
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return "", nil, fmt.Errorf("%w: 未知作用域 %q，可选 %s", ErrInvalidAuthRequest, scope, strings.Join(apiKeyScopes, ", "))
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		return "", nil, fmt.Errorf("%w: expires_in_days 不能为负数", ErrInvalidAuthRequest)
+	}
+
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &models.APIKey{
+		ID:         hex.EncodeToString(idBytes),
+		Name:       name,
+		SecretHash: hashToken(secret),
+		Role:       role,
+		Scopes:     req.Scopes,
+		CreatedBy:  change.Actor,
+		CreatedAt:  s.now(),
+	}
+	if req.ExpiresInDays > 0 {
+		expires := key.CreatedAt.AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expires
+	}
+	if err := database.GetStorage().CreateOrUpdateAPIKey(key); err != nil {
+		return "", nil, fmt.Errorf("保存API密钥失败: %w", err)
+	}
+	recordAudit(change, "create", AuditAPIKey, key.ID, 0, nil, publicAPIKey(key))
+	return apiKeyPrefix + key.ID + "_" + secret, publicAPIKey(key), nil
+}
+
+// DeleteAPIKey 吊销API密钥，立即生效
+func (s *AuthService) DeleteAPIKey(id string, change Change) error {
+	storage := database.GetStorage()
+	key, err := storage.GetAPIKey(id)
+	if err != nil {
+		return fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: %s", ErrAPIKeyNotFound, id)
+	}
+	if err := storage.DeleteAPIKey(id); err != nil {
+		return fmt.Errorf("删除API密钥失败: %w", err)
+	}
+	recordAudit(change, "delete", AuditAPIKey, id, 0, publicAPIKey(key), nil)
+	return nil
+}
+
+// lockedOut 是否因连续登录失败达到limit次被暂时锁定
+func (s *AuthService) lockedOut(key string, limit int) bool {
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+	f := s.failures[key]
+	return f != nil && f.count >= limit && s.now().Before(f.until)
+}
+
+// recordFailure 记录一次登录失败，锁定期过后重新计数。
+// 每个锁定期清理一次已过期的记录，避免不断变换用户名或来源时记录无限增长
+func (s *AuthService) recordFailure(key string) {
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for k, f := range s.failures {
+			if now.After(f.until) {
+				delete(s.failures, k)
+			}
+		}
+		s.nextSweep = now.Add(loginLockout)
+	}
+	f := s.failures[key]
+	if f == nil || now.After(f.until) {
+		f = &loginFailure{}
+		s.failures[key] = f
+	}
+	f.count++
+	f.until = now.Add(loginLockout)
+}
+
+func (s *AuthService) clearFailures(keys ...string) {
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+	for _, key := range keys {
+		delete(s.failures, key)
+	}
+}
+
+// getDummyHash 用于不存在的用户的哈希，保证登录耗时一致
+func (s *AuthService) getDummyHash() []byte {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	return s.dummyHash
+}
+
+// hashPassword 校验密码长度并生成bcrypt哈希
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLen {
+		return "", fmt.Errorf("%w: 密码至少%d个字符", ErrInvalidAuthRequest, minPasswordLen)
+	}
+	if len(password) > 72 {
+		return "", fmt.Errorf("%w: 密码不能超过72个字节", ErrInvalidAuthRequest)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+	return string(hash), nil
+}
+
+// hashToken 会话token和API密钥只保存SHA-256，数据库泄露也无法直接使用
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken 生成n字节的随机token，base64url编码
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机token失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func isActiveAdmin(user *models.User) bool {
+	return user.Role == RoleAdmin && !user.Disabled
+}
+
+// publicUser 去掉密码哈希的用户副本
+func publicUser(user *models.User) *models.User {
+	copied := *user
+	copied.PasswordHash = ""
+	return &copied
+}
+
+// publicAPIKey 去掉密钥哈希的副本
+func publicAPIKey(key *models.APIKey) *models.APIKey {
+	copied := *key
+	copied.SecretHash = ""
+	return &copied
+}
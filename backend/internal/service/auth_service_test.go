@@ -0,0 +1,178 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/pkg/models"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestAuthService(t *testing.T) *AuthService {
+	t.Helper()
+	setupThresholdStorage(t)
+	s := NewAuthService(config.AuthConfig{
+		Enabled:           true,
+		SessionTTLMinutes: 60,
+		AdminUsername:     "admin",
+		AdminPassword:     "admin-password",
+	})
+	if err := s.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLoginAndSession(t *testing.T) {
+	s := newTestAuthService(t)
+
+	// 已有用户时不再创建管理员
+	if err := s.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := s.ListUsers(); len(users) != 1 || users[0].PasswordHash != "" {
+		t.Fatalf("users = %+v, 期望只有一个不含哈希的管理员", users)
+	}
+
+	if _, _, _, err := s.Login("admin", "wrong-password", "1.2.3.4"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("错误密码 err = %v", err)
+	}
+	token, _, user, err := s.Login("Admin", "admin-password", "1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.LastLoginAt == nil {
+		t.Error("登录后应记录登录时间")
+	}
+
+	principal, err := s.Authenticate(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Name != "admin" || principal.Role != RoleAdmin || principal.Actor() != "admin" {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	// 会话过期
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := s.Authenticate(token); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("过期会话 err = %v", err)
+	}
+	s.now = time.Now
+
+	// 修改密码后旧会话失效
+	if err := s.ChangePassword("admin", "admin-password", "new-password", Change{Actor: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(token); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("修改密码后 err = %v", err)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newTestAuthService(t)
+
+	for i := 0; i < maxLoginFailures; i++ {
+		s.Login("admin", "wrong-password", "1.2.3.4")
+	}
+	if _, _, _, err := s.Login("admin", "admin-password", "1.2.3.4"); !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("err = %v, 期望 ErrLoginLocked", err)
+	}
+	// 其他来源不受影响
+	if _, _, _, err := s.Login("admin", "admin-password", "5.6.7.8"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每次换一个来源，同一用户名累计失败后同样锁定
+	for i := 0; i < maxUserLoginFailures; i++ {
+		s.Login("admin", "wrong-password", fmt.Sprintf("10.0.0.%d", i))
+	}
+	if _, _, _, err := s.Login("admin", "admin-password", "5.6.7.8"); !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("err = %v, 期望按用户名锁定", err)
+	}
+}
+
+func TestLoginFailuresEvicted(t *testing.T) {
+	s := newTestAuthService(t)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		s.Login(fmt.Sprintf("user%d", i), "wrong-password", fmt.Sprintf("10.0.0.%d", i))
+	}
+	if len(s.failures) != 20 {
+		t.Fatalf("failures = %d, 期望20", len(s.failures))
+	}
+
+	// 锁定期过后，下一次失败时清理过期的记录
+	now = now.Add(loginLockout + time.Second)
+	s.Login("admin", "wrong-password", "1.2.3.4")
+	if len(s.failures) != 2 {
+		t.Errorf("failures = %d, 过期记录应已清理", len(s.failures))
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	s := newTestAuthService(t)
+
+	if _, _, err := s.CreateAPIKey(&models.APIKeyRequest{Name: "panel", Scopes: []string{"everything"}}, Change{}); !errors.Is(err, ErrInvalidAuthRequest) {
+		t.Fatalf("未知作用域 err = %v", err)
+	}
+	secret, key, err := s.CreateAPIKey(&models.APIKeyRequest{Name: "panel", Scopes: []string{ScopeLoadStatus}}, Change{Actor: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.SecretHash != "" || key.Role != RoleViewer || key.CreatedBy != "admin" {
+		t.Errorf("api key = %+v", key)
+	}
+
+	principal, err := s.Authenticate(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Actor() != "apikey:panel" || !principal.HasScope(ScopeLoadStatus) || principal.HasScope("") {
+		t.Errorf("principal = %+v", principal)
+	}
+	if _, err := s.Authenticate(secret + "x"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("错误密钥 err = %v", err)
+	}
+
+	if err := s.DeleteAPIKey(key.ID, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("吊销后 err = %v", err)
+	}
+}
+
+func TestKeepLastAdmin(t *testing.T) {
+	s := newTestAuthService(t)
+
+	if err := s.DeleteUser("admin", Change{}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("删除唯一管理员 err = %v", err)
+	}
+	if _, err := s.UpdateUser("admin", &models.UserRequest{Role: RoleOperator}, Change{}); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("降级唯一管理员 err = %v", err)
+	}
+
+	if _, err := s.CreateUser(&models.UserRequest{Username: "bob", Password: "bob-password", Role: RoleAdmin}, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteUser("admin", Change{Actor: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := ListAudit(&models.AuditQuery{Resource: AuditUser, ResourceID: "admin"})
+	if len(entries) != 2 || entries[0].Action != "delete" {
+		t.Errorf("审计记录 = %+v", entries)
+	}
+	for _, change := range entries[0].Changes {
+		if change.Field == "password_hash" {
+			t.Error("审计记录不应包含密码哈希")
+		}
+	}
+	if stored, _ := database.GetStorage().GetUser("admin"); stored != nil {
+		t.Error("用户应已删除")
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDrainNotFound 排空记录不存在
+	ErrDrainNotFound = errors.New("排空记录不存在")
+	// ErrDrainExists 目标已被排空
+	ErrDrainExists = errors.New("目标已被排空")
+	// ErrInvalidDrain 排空参数不合法
+	ErrInvalidDrain = errors.New("排空参数错误")
+)
+
+// DrainService 手动排空服务器或单个v2board节点
+type DrainService struct {
+	now func() time.Time
+}
+
+// NewDrainService 创建排空服务
+func NewDrainService() *DrainService {
+	return &DrainService{now: time.Now}
+}
+
+// ActiveDrains 当前生效的排空记录，按服务器和节点索引
+type ActiveDrains struct {
+	Systems map[string]*models.Drain
+	Nodes   map[string]*models.Drain // 键为 tagKey(节点类型, 节点ID)
+}
+
+// System 返回服务器的排空记录，未排空时返回nil
+func (d *ActiveDrains) System(systemID string) *models.Drain {
+	if d == nil {
+		return nil
+	}
+	return d.Systems[systemID]
+}
+
+// Node 返回节点的排空记录，未排空时返回nil
+func (d *ActiveDrains) Node(nodeType string, nodeID int) *models.Drain {
+	if d == nil {
+		return nil
+	}
+	return d.Nodes[tagKey(nodeType, nodeID)]
+}
+
+// List 列出排空记录，按创建时间倒序；includeExpired为false时只返回生效中的
+func (s *DrainService) List(includeExpired bool) ([]*models.Drain, error) {
+	drains, err := database.GetStorage().ListDrains()
+	if err != nil {
+		return nil, fmt.Errorf("获取排空记录失败: %w", err)
+	}
+
+	now := s.now()
+	result := make([]*models.Drain, 0, len(drains))
+	for _, drain := range drains {
+		if includeExpired || drainActive(drain, now) {
+			result = append(result, drain)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// Get 获取排空记录
+func (s *DrainService) Get(id string) (*models.Drain, error) {
+	drain, err := database.GetStorage().GetDrain(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取排空记录失败: %w", err)
+	}
+	if drain == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDrainNotFound, id)
+	}
+	return drain, nil
+}
+
+// Create 排空服务器或节点，同一目标同时只能有一条生效的排空记录
+func (s *DrainService) Create(req *models.DrainRequest, change Change) (*models.Drain, error) {
+	nodeType := strings.TrimSpace(req.NodeType)
+	reason := strings.TrimSpace(req.Reason)
+	switch {
+	case reason == "":
+		return nil, fmt.Errorf("%w: 必须填写原因", ErrInvalidDrain)
+	case req.SystemID == "" && nodeType == "":
+		return nil, fmt.Errorf("%w: 必须指定 system_id 或节点（node_type、node_id）", ErrInvalidDrain)
+	case req.SystemID != "" && nodeType != "":
+		return nil, fmt.Errorf("%w: system_id 和节点只能指定一个", ErrInvalidDrain)
+	case nodeType != "" && req.NodeID <= 0:
+		return nil, fmt.Errorf("%w: node_id 必须是正整数", ErrInvalidDrain)
+	case req.ExpiresInMinutes < 0:
+		return nil, fmt.Errorf("%w: expires_in_minutes 不能为负数", ErrInvalidDrain)
+	case req.ExpiresAt != nil && req.ExpiresInMinutes > 0:
+		return nil, fmt.Errorf("%w: expires_at 和 expires_in_minutes 只能指定一个", ErrInvalidDrain)
+	}
+
+	now := s.now()
+	drain := &models.Drain{
+		SystemID:  req.SystemID,
+		NodeType:  nodeType,
+		NodeID:    req.NodeID,
+		Reason:    reason,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: change.Actor,
+		CreatedAt: now,
+	}
+	if nodeType == "" {
+		drain.NodeID = 0
+	}
+	if req.ExpiresInMinutes > 0 {
+		expires := now.Add(time.Duration(req.ExpiresInMinutes) * time.Minute)
+		drain.ExpiresAt = &expires
+	}
+	if drain.ExpiresAt != nil && !drain.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at 必须晚于当前时间", ErrInvalidDrain)
+	}
+
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, fmt.Errorf("生成排空记录ID失败: %w", err)
+	}
+	drain.ID = hex.EncodeToString(idBytes)
+
+	// 检查重复和写入之间不允许其他排空操作
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	storage := database.GetStorage()
+	existing, err := storage.ListDrains()
+	if err != nil {
+		return nil, fmt.Errorf("获取排空记录失败: %w", err)
+	}
+	for _, other := range existing {
+		if drainActive(other, now) && sameDrainTarget(other, drain) {
+			return nil, fmt.Errorf("%w: %s", ErrDrainExists, other.ID)
+		}
+	}
+
+	if err := storage.CreateDrain(drain); err != nil {
+		return nil, fmt.Errorf("保存排空记录失败: %w", err)
+	}
+	recordAudit(change, "drain", AuditDrain, drain.ID, 0, nil, drain)
+	return drain, nil
+}
+
+// Delete 恢复被排空的服务器或节点
+func (s *DrainService) Delete(id string, change Change) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := database.GetStorage().DeleteDrain(id); err != nil {
+		return fmt.Errorf("删除排空记录失败: %w", err)
+	}
+	recordAudit(change, "undrain", AuditDrain, id, 0, existing, nil)
+	return nil
+}
+
+// Active 返回当前生效的排空记录
+func (s *DrainService) Active(ctx context.Context) (*ActiveDrains, error) {
+	drains, err := database.GetStorageContext(ctx).ListDrains()
+	if err != nil {
+		return nil, fmt.Errorf("获取排空记录失败: %w", err)
+	}
+
+	now := s.now()
+	active := &ActiveDrains{Systems: make(map[string]*models.Drain), Nodes: make(map[string]*models.Drain)}
+	for _, drain := range drains {
+		if !drainActive(drain, now) {
+			continue
+		}
+		if drain.SystemID != "" {
+			active.Systems[drain.SystemID] = drain
+		} else {
+			active.Nodes[tagKey(drain.NodeType, drain.NodeID)] = drain
+		}
+	}
+	return active, nil
+}
+
+// drainActive 排空记录在now时是否生效
+func drainActive(drain *models.Drain, now time.Time) bool {
+	return drain.ExpiresAt == nil || now.Before(*drain.ExpiresAt)
+}
+
+func sameDrainTarget(a, b *models.Drain) bool {
+	return a.SystemID == b.SystemID && a.NodeType == b.NodeType && a.NodeID == b.NodeID
+}
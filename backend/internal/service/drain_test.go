@@ -0,0 +1,133 @@
+package service
+
+import (
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrainValidation(t *testing.T) {
+	setupThresholdStorage(t)
+	s := NewDrainService()
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		req  models.DrainRequest
+	}{
+		{"缺少原因", models.DrainRequest{SystemID: "a"}},
+		{"没有目标", models.DrainRequest{Reason: "下线"}},
+		{"同时指定系统和节点", models.DrainRequest{Reason: "下线", SystemID: "a", NodeType: "v2ray", NodeID: 1}},
+		{"节点ID无效", models.DrainRequest{Reason: "下线", NodeType: "v2ray"}},
+		{"负的有效期", models.DrainRequest{Reason: "下线", SystemID: "a", ExpiresInMinutes: -1}},
+		{"同时指定两种有效期", models.DrainRequest{Reason: "下线", SystemID: "a", ExpiresAt: timePtr(now.Add(time.Hour)), ExpiresInMinutes: 30}},
+		{"已经过期", models.DrainRequest{Reason: "下线", SystemID: "a", ExpiresAt: timePtr(now.Add(-time.Minute))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Create(&tt.req, Change{Actor: "test"}); !errors.Is(err, ErrInvalidDrain) {
+				t.Errorf("期望 ErrInvalidDrain, 得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestActiveDrains(t *testing.T) {
+	setupThresholdStorage(t)
+	s := NewDrainService()
+	change := Change{Actor: "ops"}
+
+	system, err := s.Create(&models.DrainRequest{SystemID: "a", Reason: "即将下线"}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system.CreatedBy != "ops" || system.ExpiresAt != nil {
+		t.Errorf("排空记录: %+v", system)
+	}
+	node, err := s.Create(&models.DrainRequest{NodeType: "v2ray", NodeID: 12, Reason: "上游线路抖动", ExpiresInMinutes: 30}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一目标不能重复排空
+	if _, err := s.Create(&models.DrainRequest{SystemID: "a", Reason: "重复"}, change); !errors.Is(err, ErrDrainExists) {
+		t.Errorf("期望 ErrDrainExists, 得到 %v", err)
+	}
+
+	active, err := s.Active(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.System("a") == nil || active.System("a").ID != system.ID || active.Node("v2ray", 12) == nil {
+		t.Errorf("生效的排空记录: %+v", active)
+	}
+	if active.System("b") != nil || active.Node("ss", 12) != nil {
+		t.Error("未排空的目标不应有排空记录")
+	}
+
+	// 到期后不再生效，但仍可在全部记录中看到
+	s.now = func() time.Time { return time.Now().Add(time.Hour) }
+	active, err = s.Active(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.Node("v2ray", 12) != nil || active.System("a") == nil {
+		t.Errorf("到期后的排空记录: %+v", active)
+	}
+	drains, err := s.List(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.List(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drains) != 1 || len(all) != 2 || all[0].ID != node.ID {
+		t.Errorf("排空记录列表: 生效 %d 条, 全部 %d 条", len(drains), len(all))
+	}
+	// 到期的记录不妨碍再次排空
+	if _, err := s.Create(&models.DrainRequest{NodeType: "v2ray", NodeID: 12, Reason: "仍在抖动"}, change); err != nil {
+		t.Errorf("到期后再次排空失败: %v", err)
+	}
+
+	if err := s.Delete(system.ID, change); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(system.ID); !errors.Is(err, ErrDrainNotFound) {
+		t.Errorf("期望 ErrDrainNotFound, 得到 %v", err)
+	}
+	if err := s.Delete(system.ID, change); !errors.Is(err, ErrDrainNotFound) {
+		t.Errorf("期望 ErrDrainNotFound, 得到 %v", err)
+	}
+}
+
+func TestSystemHighLoadReason(t *testing.T) {
+	drain := &models.Drain{ID: "d", SystemID: "a"}
+	exclude := &models.MaintenanceWindow{Policy: MaintenanceExclude}
+	include := &models.MaintenanceWindow{Policy: MaintenanceInclude}
+
+	tests := []struct {
+		name   string
+		status string
+		system models.SystemWithLoadStatus
+		want   string
+	}{
+		{"正常", "up", models.SystemWithLoadStatus{LoadStatus: "normal"}, ""},
+		{"高负载", "up", models.SystemWithLoadStatus{LoadStatus: "high"}, HighLoadReasonHigh},
+		{"离线", "down", models.SystemWithLoadStatus{LoadStatus: "normal"}, HighLoadReasonOffline},
+		{"排空优先于维护", "up", models.SystemWithLoadStatus{LoadStatus: "high", Drain: drain, Maintenance: exclude}, HighLoadReasonDrained},
+		{"维护中不列出", "down", models.SystemWithLoadStatus{LoadStatus: LoadStatusMaintenance, Maintenance: exclude}, ""},
+		{"维护中列出", "up", models.SystemWithLoadStatus{LoadStatus: LoadStatusMaintenance, Maintenance: include}, HighLoadReasonMaintenance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			system := tt.system
+			system.Status = tt.status
+			if got := systemHighLoadReason(&system); got != tt.want {
+				t.Errorf("reason = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
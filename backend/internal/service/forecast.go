@@ -0,0 +1,366 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// forecastMetrics 参与容量预测的指标
+var forecastMetrics = []string{MetricOnlineUsers, MetricNetUpMbps, MetricNetDownMbps}
+
+// 预测模型使用的周期
+const (
+	SeasonalityWeekly = "weekly"
+	SeasonalityDaily  = "daily"
+	SeasonalityNone   = "none"
+)
+
+// forecastZ80 80%双侧预测区间对应的正态分位数
+const forecastZ80 = 1.2816
+
+// ErrSystemNotFound 系统不存在
+var ErrSystemNotFound = errors.New("系统不存在")
+
+// Forecaster 根据本地负载历史预测在线人数和带宽，给出预计达到阈值的时间
+type Forecaster struct {
+	systemService *SystemService
+	currentConfig func() *config.Config
+	now           func() time.Time
+}
+
+// NewForecaster 创建容量预测服务，currentConfig 返回当前生效的配置
+func NewForecaster(systemService *SystemService, currentConfig func() *config.Config) *Forecaster {
+	return &Forecaster{
+		systemService: systemService,
+		currentConfig: currentConfig,
+		now:           time.Now,
+	}
+}
+
+// SystemForecast 预测单个系统未来days天的负载，days为0时使用 forecast.horizon_days
+func (f *Forecaster) SystemForecast(ctx context.Context, systemID string, days int) (*models.SystemForecast, error) {
+	systems, err := f.systemService.GetSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, system := range systems {
+		if system.ID == systemID {
+			return f.forecast(ctx, system, days, true)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSystemNotFound, systemID)
+}
+
+// CapacityReport 预测所有系统，按预计达到阈值的时间排序
+func (f *Forecaster) CapacityReport(ctx context.Context, days int) (*models.CapacityReport, error) {
+	systems, err := f.systemService.GetSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CapacityReport{Systems: make([]*models.SystemForecast, 0, len(systems))}
+	for _, system := range systems {
+		forecast, err := f.forecast(ctx, system, days, false)
+		if err != nil {
+			return nil, err
+		}
+		report.GeneratedAt, report.HorizonDays = forecast.GeneratedAt, forecast.HorizonDays
+		if forecast.ThresholdAt != nil {
+			report.AtRisk++
+		}
+		report.Systems = append(report.Systems, forecast)
+	}
+
+	sort.SliceStable(report.Systems, func(i, j int) bool {
+		a, b := report.Systems[i], report.Systems[j]
+		if (a.DaysToThreshold == nil) != (b.DaysToThreshold == nil) {
+			return a.DaysToThreshold != nil
+		}
+		if a.DaysToThreshold != nil && *a.DaysToThreshold != *b.DaysToThreshold {
+			return *a.DaysToThreshold < *b.DaysToThreshold
+		}
+		return a.SystemName < b.SystemName
+	})
+	return report, nil
+}
+
+// forecast 读取系统的负载历史并预测各项指标，daily为false时不返回每日预测值
+func (f *Forecaster) forecast(ctx context.Context, system *models.System, days int, daily bool) (*models.SystemForecast, error) {
+	cfg := f.currentConfig()
+	if days <= 0 {
+		days = cfg.Forecast.HorizonDays
+	}
+	now := f.now()
+
+	since := now.Add(-time.Duration(cfg.History.RetentionDays) * 24 * time.Hour)
+	buckets, err := database.GetStorageContext(ctx).ListHistory(system.ID, since)
+	if err != nil {
+		return nil, fmt.Errorf("获取负载历史失败: %w", err)
+	}
+
+	result := &models.SystemForecast{
+		SystemID:     system.ID,
+		SystemName:   system.Name,
+		GeneratedAt:  now,
+		HorizonDays:  days,
+		HistoryHours: len(buckets),
+		Metrics:      make([]*models.MetricForecast, 0, len(forecastMetrics)),
+	}
+	if len(buckets) < cfg.Forecast.MinHistoryHours {
+		result.InsufficientHistory = true
+		return result, nil
+	}
+
+	threshold, err := f.systemService.thresholdService.GetThreshold(ctx, system.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "获取系统阈值配置失败，使用默认配置", "op", "forecast.threshold", "system_id", system.ID, "system", system.Name, logging.Err(err))
+		threshold = DefaultThreshold(system.ID)
+	}
+
+	for _, metric := range forecastMetrics {
+		points := historyPoints(buckets, metric)
+		if len(points) < cfg.Forecast.MinHistoryHours {
+			continue
+		}
+		forecast := forecastMetric(metric, points, metricThreshold(threshold, metric), now, days)
+		if !daily {
+			forecast.Daily = nil
+		}
+		result.Metrics = append(result.Metrics, forecast)
+
+		if forecast.ThresholdAt != nil && (result.ThresholdAt == nil || forecast.ThresholdAt.Before(*result.ThresholdAt)) {
+			result.Metric, result.ThresholdAt, result.DaysToThreshold = metric, forecast.ThresholdAt, forecast.DaysToThreshold
+		}
+	}
+	return result, nil
+}
+
+// metricThreshold 指标判定为高负载的值，与 CalculateLoadStatus 一致；带宽阈值基于当前学习到的极限值。0表示未配置
+func metricThreshold(threshold *models.SystemThreshold, metric string) float64 {
+	switch metric {
+	case MetricOnlineUsers:
+		return float64(threshold.OnlineUsersLimit)
+	case MetricNetUpMbps:
+		return threshold.NetUpMax * (threshold.NetUpAlert / 100)
+	case MetricNetDownMbps:
+		return threshold.NetDownMax * (threshold.NetDownAlert / 100)
+	}
+	return 0
+}
+
+// forecastMetric 按小时预测 [now, now+days天) 内的值，记录首次达到阈值的时间和每日峰值
+func forecastMetric(metric string, points []historyPoint, threshold float64, now time.Time, days int) *models.MetricForecast {
+	model := fitSeasonal(points)
+	result := &models.MetricForecast{
+		Metric:      metric,
+		Seasonality: model.seasonality(),
+		Current:     recentPeak(points, now),
+		TrendPerDay: model.slope * 24,
+		Threshold:   threshold,
+	}
+
+	margin := forecastZ80 * model.residualStd
+	start := now.UTC().Truncate(time.Hour)
+	end := start.Add(time.Duration(days) * 24 * time.Hour)
+	var day *models.ForecastPoint
+	for at := start; at.Before(end); at = at.Add(time.Hour) {
+		value := model.predict(at)
+		upper := value + margin
+
+		if date := at.Format("2006-01-02"); day == nil || day.Date != date {
+			result.Daily = append(result.Daily, models.ForecastPoint{Date: date, Peak: value, Upper: upper})
+			day = &result.Daily[len(result.Daily)-1]
+		}
+		day.Peak = math.Max(day.Peak, value)
+		day.Upper = math.Max(day.Upper, upper)
+
+		if threshold <= 0 {
+			continue
+		}
+		if result.EarliestThresholdAt == nil && upper >= threshold {
+			earliest := at
+			result.EarliestThresholdAt = &earliest
+		}
+		if result.ThresholdAt == nil && value >= threshold {
+			crossing := at
+			daysTo := math.Round(math.Max(at.Sub(now).Hours(), 0)/24*10) / 10
+			result.ThresholdAt, result.DaysToThreshold = &crossing, &daysTo
+		}
+	}
+	return result
+}
+
+// historyPoint 某个指标一小时内的峰值
+type historyPoint struct {
+	at    time.Time
+	value float64
+}
+
+// historyPoints 取出指标每小时的峰值，按时间排序。
+// 容量以高峰时段为准，因此使用每小时的最大值而不是平均值
+func historyPoints(buckets []*models.HistoryBucket, metric string) []historyPoint {
+	points := make([]historyPoint, 0, len(buckets))
+	for _, bucket := range buckets {
+		if value := bucket.Metrics[metric]; value != nil && value.Count > 0 {
+			points = append(points, historyPoint{at: bucket.Hour, value: value.Max})
+		}
+	}
+	return points
+}
+
+// recentPeak 最近24小时的峰值，没有数据时返回最后一个值
+func recentPeak(points []historyPoint, now time.Time) float64 {
+	peak, found := 0.0, false
+	for _, point := range points {
+		if now.Sub(point.at) < 24*time.Hour && (!found || point.value > peak) {
+			peak, found = point.value, true
+		}
+	}
+	if !found && len(points) > 0 {
+		return points[len(points)-1].value
+	}
+	return peak
+}
+
+// seasonalModel 线性趋势加周期项：y = intercept + slope·t + season[slot]，t为距 origin 的小时数
+type seasonalModel struct {
+	origin      time.Time
+	intercept   float64
+	slope       float64 // 每小时的变化量
+	period      int     // 168（按星期几和小时）、24（按小时），0表示没有周期项
+	season      []float64
+	residualStd float64
+}
+
+// fitSeasonal 拟合趋势和周期项。历史覆盖两周且每个时段都有数据时按周建模，
+// 覆盖两天时按天建模，否则只拟合线性趋势
+func fitSeasonal(points []historyPoint) *seasonalModel {
+	m := &seasonalModel{origin: points[0].at}
+	span := points[len(points)-1].at.Sub(m.origin)
+	for _, period := range []int{168, 24} {
+		if span >= time.Duration(2*period)*time.Hour && coversSlots(points, period) {
+			m.period = period
+			break
+		}
+	}
+
+	ts := make([]float64, len(points))
+	for i, point := range points {
+		ts[i] = point.at.Sub(m.origin).Hours()
+	}
+	if m.period > 0 {
+		m.season = make([]float64, m.period)
+	}
+
+	// 交替估计趋势和周期项，几轮即可收敛
+	deseasonalized := make([]float64, len(points))
+	for round := 0; ; round++ {
+		for i, point := range points {
+			deseasonalized[i] = point.value - m.seasonal(point.at)
+		}
+		m.intercept, m.slope = linearFit(ts, deseasonalized)
+		if m.period == 0 || round == 3 {
+			break
+		}
+
+		sums := make([]float64, m.period)
+		counts := make([]int, m.period)
+		for i, point := range points {
+			slot := seasonSlot(point.at, m.period)
+			sums[slot] += point.value - m.intercept - m.slope*ts[i]
+			counts[slot]++
+		}
+		mean := 0.0
+		for slot := range sums {
+			m.season[slot] = sums[slot] / float64(counts[slot])
+			mean += m.season[slot]
+		}
+		mean /= float64(m.period)
+		for slot := range m.season {
+			m.season[slot] -= mean
+		}
+	}
+
+	sumSquares := 0.0
+	for i, point := range points {
+		residual := point.value - m.intercept - m.slope*ts[i] - m.seasonal(point.at)
+		sumSquares += residual * residual
+	}
+	m.residualStd = math.Sqrt(sumSquares / math.Max(float64(len(points)-2), 1))
+	return m
+}
+
+// predict 预测某个整点的值，不小于0
+func (m *seasonalModel) predict(at time.Time) float64 {
+	return math.Max(m.intercept+m.slope*at.Sub(m.origin).Hours()+m.seasonal(at), 0)
+}
+
+func (m *seasonalModel) seasonal(at time.Time) float64 {
+	if m.period == 0 {
+		return 0
+	}
+	return m.season[seasonSlot(at, m.period)]
+}
+
+// seasonSlot 时间在周期内所在的时段（UTC）：周期为168时按星期几和小时，为24时按小时
+func seasonSlot(at time.Time, period int) int {
+	at = at.UTC()
+	if period == 168 {
+		return int(at.Weekday())*24 + at.Hour()
+	}
+	return at.Hour()
+}
+
+// coversSlots 周期内的每个时段是否都有数据
+func coversSlots(points []historyPoint, period int) bool {
+	seen := make([]bool, period)
+	covered := 0
+	for _, point := range points {
+		if slot := seasonSlot(point.at, period); !seen[slot] {
+			seen[slot] = true
+			covered++
+		}
+	}
+	return covered == period
+}
+
+func (m *seasonalModel) seasonality() string {
+	switch m.period {
+	case 168:
+		return SeasonalityWeekly
+	case 24:
+		return SeasonalityDaily
+	}
+	return SeasonalityNone
+}
+
+// linearFit 最小二乘拟合 y = intercept + slope·x
+func linearFit(xs, ys []float64) (intercept, slope float64) {
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return meanY, 0
+	}
+	slope = covariance / variance
+	return meanY - slope*meanX, slope
+}
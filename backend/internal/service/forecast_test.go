@@ -0,0 +1,153 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// forecastOrigin 合成数据的起点（星期日0点）
+var forecastOrigin = time.Date(2026, 9, 27, 0, 0, 0, 0, time.UTC)
+
+// syntheticUsers 每天增长2人，每天13点（UTC）达到高峰，周末高峰再高20人
+func syntheticUsers(at time.Time) float64 {
+	days := at.Sub(forecastOrigin).Hours() / 24
+	value := 100 + 2*days + 50*math.Cos(2*math.Pi*float64(at.Hour()-13)/24)
+	if weekday := at.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		value += 20 * math.Max(math.Cos(2*math.Pi*float64(at.Hour()-13)/24), 0)
+	}
+	return value
+}
+
+func syntheticPoints(from time.Time, hours int) []historyPoint {
+	points := make([]historyPoint, 0, hours)
+	for i := 0; i < hours; i++ {
+		at := from.Add(time.Duration(i) * time.Hour)
+		points = append(points, historyPoint{at: at, value: syntheticUsers(at)})
+	}
+	return points
+}
+
+func TestFitSeasonal(t *testing.T) {
+	tests := []struct {
+		name        string
+		hours       int
+		seasonality string
+	}{
+		{"三周按周建模", 21 * 24, SeasonalityWeekly},
+		{"五天按天建模", 5 * 24, SeasonalityDaily},
+		{"不足两天只拟合趋势", 36, SeasonalityNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := fitSeasonal(syntheticPoints(forecastOrigin, tt.hours))
+			if got := model.seasonality(); got != tt.seasonality {
+				t.Errorf("周期 = %s, 期望 %s", got, tt.seasonality)
+			}
+		})
+	}
+
+	// 按周建模时完整还原趋势和周末高峰
+	model := fitSeasonal(syntheticPoints(forecastOrigin, 21*24))
+	if trend := model.slope * 24; math.Abs(trend-2) > 0.05 {
+		t.Errorf("每天趋势 = %.3f, 期望 2", trend)
+	}
+	if model.residualStd > 0.5 {
+		t.Errorf("残差标准差 = %.3f, 期望接近0", model.residualStd)
+	}
+	saturday := time.Date(2026, 10, 24, 13, 0, 0, 0, time.UTC)
+	if got, want := model.predict(saturday), syntheticUsers(saturday); math.Abs(got-want) > 1 {
+		t.Errorf("周六高峰预测 = %.1f, 期望 %.1f", got, want)
+	}
+}
+
+func TestForecastMetric(t *testing.T) {
+	now := forecastOrigin.Add(21 * 24 * time.Hour)
+	points := syntheticPoints(forecastOrigin, 21*24)
+
+	// 工作日高峰为 150+2d，第35天才达到220；周六的高峰加上20人，第27.5天（距今6.5天）达到
+	forecast := forecastMetric(MetricOnlineUsers, points, 220, now, 30)
+	if len(forecast.Daily) != 30 {
+		t.Fatalf("每日预测 = %d 天, 期望 30", len(forecast.Daily))
+	}
+	if math.Abs(forecast.Current-syntheticUsers(now.Add(-11*time.Hour))) > 0.01 {
+		t.Errorf("最近24小时峰值 = %.1f", forecast.Current)
+	}
+	if forecast.ThresholdAt == nil || forecast.DaysToThreshold == nil {
+		t.Fatal("应预测出达到阈值的时间")
+	}
+	if want := time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC); !forecast.ThresholdAt.Equal(want) {
+		t.Errorf("达到阈值的时间 = %v, 期望 %v", forecast.ThresholdAt, want)
+	}
+	if *forecast.DaysToThreshold != 6.5 {
+		t.Errorf("距离达到阈值 = %.1f 天, 期望 6.5", *forecast.DaysToThreshold)
+	}
+	if forecast.EarliestThresholdAt == nil || forecast.EarliestThresholdAt.After(*forecast.ThresholdAt) {
+		t.Errorf("区间上界达到阈值的时间 %v 不应晚于 %v", forecast.EarliestThresholdAt, forecast.ThresholdAt)
+	}
+
+	// 阈值为0表示未配置，不计算达到阈值的时间
+	if forecast := forecastMetric(MetricOnlineUsers, points, 0, now, 7); forecast.ThresholdAt != nil || len(forecast.Daily) != 7 {
+		t.Errorf("未配置阈值的预测: %+v", forecast)
+	}
+}
+
+func TestForecasterHistory(t *testing.T) {
+	setupThresholdStorage(t)
+	storage := database.GetStorage()
+	cfg := config.Default()
+	cfg.Thresholds.OnlineUsersLimit = 220
+	SetThresholdDefaults(cfg.Thresholds)
+	now := forecastOrigin.Add(21 * 24 * time.Hour)
+
+	f := NewForecaster(&SystemService{thresholdService: NewThresholdService()}, func() *config.Config { return cfg })
+	f.now = func() time.Time { return now }
+	system := &models.System{ID: "sys1", Name: "hk-01"}
+
+	// 每小时采样两次，在线人数取每小时的最大值
+	addHours := func(from time.Time, hours int) {
+		for i := 0; i < hours; i++ {
+			at := from.Add(time.Duration(i) * time.Hour)
+			for _, offset := range []time.Duration{5 * time.Minute, 35 * time.Minute} {
+				values := map[string]float64{MetricOnlineUsers: syntheticUsers(at) - offset.Minutes()}
+				if err := storage.AddHistorySample(system.ID, at.Add(offset), values, 365*24*time.Hour); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	addHours(now.Add(-24*time.Hour), 24)
+	result, err := f.forecast(context.Background(), system, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.InsufficientHistory || result.HistoryHours != 24 || len(result.Metrics) != 0 {
+		t.Fatalf("历史不足时的预测: %+v", result)
+	}
+
+	addHours(forecastOrigin, 20*24)
+	result, err = f.forecast(context.Background(), system, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.InsufficientHistory || result.HorizonDays != cfg.Forecast.HorizonDays || len(result.Metrics) != 1 {
+		t.Fatalf("预测结果: %+v", result)
+	}
+	users := result.Metrics[0]
+	if users.Metric != MetricOnlineUsers || users.Seasonality != SeasonalityWeekly || users.Threshold != float64(cfg.Thresholds.OnlineUsersLimit) {
+		t.Errorf("在线人数预测: %+v", users)
+	}
+	if result.Metric != MetricOnlineUsers || result.ThresholdAt == nil || !result.ThresholdAt.Equal(*users.ThresholdAt) {
+		t.Errorf("最早达到阈值的指标: %s %v", result.Metric, result.ThresholdAt)
+	}
+
+	// 容量报告中不返回每日预测值
+	if result, err = f.forecast(context.Background(), system, 7, false); err != nil || len(result.Metrics[0].Daily) != 0 {
+		t.Errorf("不含每日预测的结果: %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/pocketbase"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 依赖检查状态
+const (
+	CheckUp       = "up"
+	CheckDegraded = "degraded"
+	CheckDown     = "down"
+	CheckDisabled = "disabled"
+)
+
+// 就绪状态
+const (
+	ReadyOK       = "ready"
+	ReadyDegraded = "degraded" // 有非关键依赖异常，仍可接收请求
+	ReadyNotReady = "not_ready"
+)
+
+const (
+	// healthCheckTimeout 单个依赖检查的超时时间，编排系统的探针超时通常为几秒
+	healthCheckTimeout = 2 * time.Second
+	// snapshotMaxAge 系统列表快照超过该时间未更新视为过期
+	snapshotMaxAge = 5 * time.Minute
+)
+
+// healthCheck 一个依赖的检查
+type healthCheck struct {
+	name string
+	run  func(ctx context.Context) checkResult
+}
+
+// checkResult 单次检查的结果，err不为nil时status不应为up
+type checkResult struct {
+	status   string
+	critical bool
+	err      error
+	details  map[string]interface{}
+}
+
+// checkHistory 依赖最近一次成功和失败的记录
+type checkHistory struct {
+	lastError     string
+	lastErrorAt   time.Time
+	lastSuccessAt time.Time
+}
+
+// HealthService 检查PocketBase、Redis、BadgerDB和数据快照，生成就绪检查结果
+type HealthService struct {
+	checks []healthCheck
+	now    func() time.Time
+
+	mu      sync.Mutex
+	history map[string]*checkHistory
+}
+
+// NewHealthService 创建就绪检查服务，currentConfig 返回当前生效的配置（配置重载后可能变化）
+func NewHealthService(systemService *SystemService, redisService *RedisService, currentConfig func() *config.Config) *HealthService {
+	return &HealthService{
+		checks: []healthCheck{
+			{name: "pocketbase", run: systemService.checkPocketBase},
+			{name: "redis", run: func(ctx context.Context) checkResult {
+				return checkRedis(ctx, redisService, &currentConfig().Redis)
+			}},
+			{name: "badger", run: checkBadger},
+			{name: "snapshot", run: systemService.checkSnapshot},
+		},
+		now:     time.Now,
+		history: make(map[string]*checkHistory),
+	}
+}
+
+// Readiness 并发检查所有依赖。关键依赖为down时返回 not_ready，
+// 只有非关键依赖异常时返回 degraded
+func (h *HealthService) Readiness(ctx context.Context) *models.ReadinessReport {
+	report := &models.ReadinessReport{
+		Status:    ReadyOK,
+		CheckedAt: h.now(),
+		Checks:    make(map[string]*models.DependencyCheck, len(h.checks)),
+	}
+
+	results := make([]*models.DependencyCheck, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			results[i] = h.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for i, check := range h.checks {
+		result := results[i]
+		report.Checks[check.name] = result
+		switch {
+		case result.Status == CheckDown && result.Critical:
+			report.Status = ReadyNotReady
+		case result.Status != CheckUp && result.Status != CheckDisabled && report.Status == ReadyOK:
+			report.Status = ReadyDegraded
+		}
+	}
+	return report
+}
+
+// runCheck 执行一次检查，记录耗时并更新该依赖的历史
+func (h *HealthService) runCheck(ctx context.Context, check healthCheck) *models.DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := h.now()
+	result := check.run(ctx)
+	out := &models.DependencyCheck{
+		Status:    result.status,
+		Critical:  result.critical,
+		LatencyMs: float64(h.now().Sub(start).Microseconds()) / 1000,
+		Details:   result.details,
+	}
+	if result.err != nil {
+		out.Error = result.err.Error()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	history := h.history[check.name]
+	if history == nil {
+		history = &checkHistory{}
+		h.history[check.name] = history
+	}
+	if result.err != nil {
+		history.lastError = out.Error
+		history.lastErrorAt = start
+	} else if result.status == CheckUp {
+		history.lastSuccessAt = start
+	}
+	if history.lastError != "" {
+		at := history.lastErrorAt
+		out.LastError, out.LastErrorAt = history.lastError, &at
+	}
+	if !history.lastSuccessAt.IsZero() {
+		at := history.lastSuccessAt
+		out.LastSuccessAt = &at
+	}
+	return out
+}
+
+// checkPocketBase 检查PocketBase是否可达以及是否持有有效token。
+// 有未过期的快照时接口仍可返回降级数据，此时PocketBase不作为关键依赖
+func (s *SystemService) checkPocketBase(ctx context.Context) checkResult {
+	client := s.client()
+	breaker := client.BreakerStatus()
+	result := checkResult{
+		status:   CheckUp,
+		critical: !s.snapshotFresh(),
+		details: map[string]interface{}{
+			"auth_mode":     client.AuthMode(),
+			"authenticated": client.Authenticated(),
+			"circuit":       breaker.State,
+		},
+	}
+	if expireAt := client.TokenExpireAt(); !expireAt.IsZero() {
+		result.details["token_expire_at"] = expireAt
+	}
+
+	if err := client.Ping(ctx); err != nil {
+		result.status, result.err = CheckDown, err
+		return result
+	}
+	if !client.Authenticated() {
+		result.status, result.err = CheckDown, errors.New("未登录PocketBase或token已过期")
+		return result
+	}
+	if breaker.State != pocketbase.BreakerClosed {
+		result.status = CheckDegraded
+		if breaker.LastError != "" {
+			result.err = fmt.Errorf("熔断器%s: %s", breaker.State, breaker.LastError)
+		}
+	}
+	return result
+}
+
+// checkSnapshot 检查最近一次成功获取系统列表的时间
+func (s *SystemService) checkSnapshot(ctx context.Context) checkResult {
+	s.snapshotMu.RLock()
+	at := s.snapshotAt
+	s.snapshotMu.RUnlock()
+
+	if at.IsZero() {
+		return checkResult{status: CheckDegraded, err: errors.New("尚未成功获取过系统列表")}
+	}
+	age := time.Since(at)
+	result := checkResult{
+		status: CheckUp,
+		details: map[string]interface{}{
+			"snapshot_at":          at,
+			"snapshot_age_seconds": age.Seconds(),
+		},
+	}
+	if age > snapshotMaxAge {
+		result.status = CheckDegraded
+		result.err = fmt.Errorf("系统列表快照已过期（%s前）", age.Truncate(time.Second))
+	}
+	return result
+}
+
+// snapshotFresh 是否有未过期的系统列表快照
+func (s *SystemService) snapshotFresh() bool {
+	s.snapshotMu.RLock()
+	defer s.snapshotMu.RUnlock()
+	return !s.snapshotAt.IsZero() && time.Since(s.snapshotAt) <= snapshotMaxAge
+}
+
+// checkRedis 检查Redis连接。未配置Redis时为disabled，连接失败只影响节点查询，不是关键依赖
+func checkRedis(ctx context.Context, redisService *RedisService, cfg *config.RedisConfig) checkResult {
+	if cfg.Host == "" {
+		return checkResult{status: CheckDisabled}
+	}
+	result := checkResult{
+		status:  CheckUp,
+		details: map[string]interface{}{"addr": fmt.Sprintf("%s:%s", cfg.Host, cfg.Port), "db": cfg.DB},
+	}
+	if err := redisService.Ping(ctx); err != nil {
+		result.status, result.err = CheckDown, err
+	}
+	return result
+}
+
+// checkBadger 检查本地BadgerDB，阈值和别名等配置都存储在其中，是关键依赖
+func checkBadger(ctx context.Context) checkResult {
+	result := checkResult{status: CheckUp, critical: true}
+	storage := database.GetStorageContext(ctx)
+	if storage == nil {
+		result.status, result.err = CheckDown, errors.New("数据库未初始化")
+		return result
+	}
+	if err := storage.Ping(); err != nil {
+		result.status, result.err = CheckDown, err
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/pocketbase"
+	"backend/pkg/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	setupThresholdStorage(t)
+
+	var hubDown atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hubDown.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/api/health" {
+			w.Write([]byte(`{"code":200,"message":"API is healthy."}`))
+			return
+		}
+		w.Write([]byte(`{"page":1,"items":[{"id":"sys1","name":"server-1","status":"up"}]}`))
+	}))
+	defer srv.Close()
+
+	client := pocketbase.NewClient(srv.URL)
+	client.SetAuthToken("static-token")
+	client.Retry = pocketbase.RetryPolicy{MaxAttempts: 1}
+	systemService := &SystemService{pbClient: client, statsSnapshot: make(map[string]*models.AverageStats)}
+	cfg := config.Default()
+	cfg.Redis.Host = ""
+	health := NewHealthService(systemService, NewRedisService(), func() *config.Config { return cfg })
+
+	check := func(wantStatus string, want map[string]string) *models.ReadinessReport {
+		t.Helper()
+		report := health.Readiness(context.Background())
+		if report.Status != wantStatus {
+			t.Errorf("status = %s, 期望 %s", report.Status, wantStatus)
+		}
+		for name, status := range want {
+			if got := report.Checks[name]; got == nil || got.Status != status {
+				t.Errorf("%s = %+v, 期望 %s", name, got, status)
+			}
+		}
+		return report
+	}
+
+	// 还没有快照：PocketBase正常，快照缺失只算降级
+	report := check(ReadyDegraded, map[string]string{"pocketbase": CheckUp, "redis": CheckDisabled, "badger": CheckUp, "snapshot": CheckDegraded})
+	if !report.Checks["pocketbase"].Critical {
+		t.Error("没有快照时PocketBase应为关键依赖")
+	}
+
+	// 没有快照时PocketBase不可用，实例不就绪，并记录最近一次错误
+	hubDown.Store(true)
+	report = check(ReadyNotReady, map[string]string{"pocketbase": CheckDown})
+	if pb := report.Checks["pocketbase"]; pb.Error == "" || pb.LastError != pb.Error || pb.LastErrorAt == nil {
+		t.Errorf("PocketBase检查结果缺少错误信息: %+v", pb)
+	}
+
+	// 恢复后保留最近一次错误，同时记录成功时间
+	hubDown.Store(false)
+	if _, err := systemService.GetSystems(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	report = check(ReadyOK, map[string]string{"pocketbase": CheckUp, "snapshot": CheckUp})
+	if pb := report.Checks["pocketbase"]; pb.Error != "" || pb.LastError == "" || pb.LastSuccessAt == nil {
+		t.Errorf("恢复后的PocketBase检查结果: %+v", pb)
+	}
+
+	// 有新鲜快照时PocketBase不可用只算降级，接口仍可返回快照数据
+	hubDown.Store(true)
+	report = check(ReadyDegraded, map[string]string{"pocketbase": CheckDown})
+	if report.Checks["pocketbase"].Critical {
+		t.Error("有快照时PocketBase不应为关键依赖")
+	}
+	hubDown.Store(false)
+
+	// 配置了Redis但未连接
+	cfg.Redis.Host = "127.0.0.1"
+	check(ReadyDegraded, map[string]string{"redis": CheckDown})
+}
+
+func TestReadinessSnapshotExpiry(t *testing.T) {
+	s := &SystemService{snapshotAt: time.Now().Add(-snapshotMaxAge - time.Minute)}
+	if s.snapshotFresh() {
+		t.Error("过期快照不应视为新鲜")
+	}
+	result := s.checkSnapshot(context.Background())
+	if result.status != CheckDegraded || result.err == nil {
+		t.Errorf("过期快照的检查结果: %+v", result)
+	}
+}
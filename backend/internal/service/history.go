@@ -0,0 +1,104 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// 本地负载历史记录的指标，带宽与负载判定一致换算为Mbps
+const (
+	MetricCPU         = "cpu"
+	MetricMemory      = "memory"
+	MetricNetUpMbps   = "net_up_mbps"
+	MetricNetDownMbps = "net_down_mbps"
+	MetricOnlineUsers = "online_users"
+)
+
+// historyStaleAfter 统计数据早于该时间的系统不采样也不做异常检测，避免Beszel agent离线或PocketBase不可用时使用快照数据
+const historyStaleAfter = 10 * time.Minute
+
+// HistoryRecorder 定期采样每个系统的负载并在本地按小时汇总，作为容量预测的数据来源
+type HistoryRecorder struct {
+	systemService *SystemService
+	currentConfig func() *config.Config
+	now           func() time.Time
+}
+
+// NewHistoryRecorder 创建负载历史采样器，currentConfig 返回当前生效的配置，采样配置可以热加载
+func NewHistoryRecorder(systemService *SystemService, currentConfig func() *config.Config) *HistoryRecorder {
+	return &HistoryRecorder{
+		systemService: systemService,
+		currentConfig: currentConfig,
+		now:           time.Now,
+	}
+}
+
+// Run 按配置的间隔采样，直到ctx取消
+func (r *HistoryRecorder) Run(ctx context.Context) {
+	for {
+		cfg := r.currentConfig().History
+		interval := publisherIdleInterval
+		if cfg.Enabled {
+			interval = time.Duration(cfg.IntervalSeconds) * time.Second
+			if err := r.Record(ctx, &cfg); err != nil {
+				slog.WarnContext(ctx, "记录负载历史失败", "op", "history.record", logging.Err(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Record 采样一次所有系统的负载。维护中、已排空、离线或统计数据过期的系统不代表正常负载，不记录
+func (r *HistoryRecorder) Record(ctx context.Context, cfg *config.HistoryConfig) error {
+	systems, err := r.systemService.GetSystemsWithLoadStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("获取系统负载状态失败: %w", err)
+	}
+
+	now := r.now()
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	storage := database.GetStorageContext(ctx)
+	recorded := 0
+	var errs []error
+	for _, system := range systems {
+		if system.Maintenance != nil || system.Drain != nil || !representativeLoad(&system.SystemWithAvgStats, now) {
+			continue
+		}
+		if err := storage.AddHistorySample(system.ID, now, historyValues(&system.SystemWithAvgStats), retention); err != nil {
+			errs = append(errs, fmt.Errorf("系统 %s: %w", system.ID, err))
+			continue
+		}
+		recorded++
+	}
+	slog.DebugContext(ctx, "已记录负载历史", "op", "history.record", "systems", recorded)
+	return errors.Join(errs...)
+}
+
+// historyValues 系统一次采样的各项指标
+func historyValues(system *models.SystemWithAvgStats) map[string]float64 {
+	return map[string]float64{
+		MetricCPU:         system.AvgCPU,
+		MetricMemory:      system.AvgMemPct,
+		MetricNetUpMbps:   system.AvgNetSent * 8,
+		MetricNetDownMbps: system.AvgNetRecv * 8,
+		MetricOnlineUsers: float64(system.OnlineUsers),
+	}
+}
+
+// representativeLoad 负载数据是否反映系统当前的运行情况：系统在线、不是快照数据且统计数据没有过期。
+// 负载历史和异常检测都只使用这样的数据
+func representativeLoad(system *models.SystemWithAvgStats, now time.Time) bool {
+	return system.Status == "up" && !system.Stale && now.Sub(system.LastUpdate) <= historyStaleAfter
+}
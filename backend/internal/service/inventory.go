@@ -0,0 +1,198 @@
+package service
+
+import (
+	"backend/internal/pocketbase"
+	"backend/pkg/models"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// toSystemInfo 将Beszel的info转换为API模型
+func toSystemInfo(info *pocketbase.SystemInfo) models.SystemInfo {
+	return models.SystemInfo{
+		Hostname:      info.Hostname,
+		Kernel:        info.KernelVersion,
+		OS:            info.OSName(),
+		CPUModel:      strings.TrimSpace(info.CPUModel),
+		Cores:         info.Cores,
+		Threads:       info.Threads,
+		UptimeSeconds: info.Uptime,
+		AgentVersion:  info.AgentVersion,
+	}
+}
+
+// SearchSystems 按条件搜索系统，条件为空时返回全部
+func (s *SystemService) SearchSystems(ctx context.Context, filter *models.SystemFilter) ([]*models.System, error) {
+	systems, err := s.GetSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*models.System
+	for _, system := range systems {
+		if matchSystem(system, filter) {
+			result = append(result, system)
+		}
+	}
+	return result, nil
+}
+
+// matchSystem 判断系统是否满足搜索条件（不区分大小写）
+func matchSystem(system *models.System, filter *models.SystemFilter) bool {
+	if filter.Status != "" && !strings.EqualFold(system.Status, filter.Status) {
+		return false
+	}
+	if filter.AgentVersion != "" && !strings.EqualFold(system.Info.AgentVersion, filter.AgentVersion) {
+		return false
+	}
+	if filter.OS != "" && !strings.EqualFold(system.Info.OS, filter.OS) {
+		return false
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(filter.Keyword))
+	if keyword == "" {
+		return true
+	}
+	fields := []string{
+		system.ID,
+		system.Name,
+		system.Host,
+		system.Info.Hostname,
+		system.Info.Kernel,
+		system.Info.OS,
+		system.Info.CPUModel,
+		system.Info.AgentVersion,
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFleetInventory 获取服务器资产清单：按agent版本、操作系统、CPU型号分组，并列出agent过旧的系统
+func (s *SystemService) GetFleetInventory(ctx context.Context) (*models.FleetInventory, error) {
+	systems, err := s.GetSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &models.FleetInventory{
+		Total:          len(systems),
+		OutdatedAgents: []models.InventoryItem{},
+	}
+
+	items := make([]models.InventoryItem, 0, len(systems))
+	for _, system := range systems {
+		item := models.InventoryItem{
+			ID:           system.ID,
+			Name:         system.Name,
+			AgentVersion: system.Info.AgentVersion,
+			OS:           system.Info.OS,
+			CPUModel:     system.Info.CPUModel,
+			Cores:        system.Info.Cores,
+		}
+		items = append(items, item)
+
+		if item.AgentVersion != "" && compareVersions(item.AgentVersion, inventory.LatestAgentVersion) > 0 {
+			inventory.LatestAgentVersion = item.AgentVersion
+		}
+	}
+
+	for _, item := range items {
+		if item.AgentVersion == "" || compareVersions(item.AgentVersion, inventory.LatestAgentVersion) < 0 {
+			inventory.OutdatedAgents = append(inventory.OutdatedAgents, item)
+		}
+	}
+
+	inventory.ByAgentVersion = groupInventory(items, func(item models.InventoryItem) string { return item.AgentVersion })
+	// 版本分组按版本号从新到旧排列
+	sort.SliceStable(inventory.ByAgentVersion, func(i, j int) bool {
+		return compareVersions(inventory.ByAgentVersion[i].Key, inventory.ByAgentVersion[j].Key) > 0
+	})
+	inventory.ByOS = groupInventory(items, func(item models.InventoryItem) string { return item.OS })
+	inventory.ByCPUModel = groupInventory(items, func(item models.InventoryItem) string { return item.CPUModel })
+
+	return inventory, nil
+}
+
+// groupInventory 按key分组，分组按数量从多到少排列，未知值归入 "unknown"
+func groupInventory(items []models.InventoryItem, key func(models.InventoryItem) string) []models.InventoryGroup {
+	index := make(map[string]int)
+	var groups []models.InventoryGroup
+
+	for _, item := range items {
+		k := key(item)
+		if k == "" {
+			k = "unknown"
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, models.InventoryGroup{Key: k})
+		}
+		groups[i].Count++
+		groups[i].Systems = append(groups[i].Systems, item)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// compareVersions 比较点分版本号（如 0.12.3、v0.9.1），返回 -1/0/1；空版本最小
+func compareVersions(a, b string) int {
+	pa := versionParts(a)
+	pb := versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	if a == "" && b != "" {
+		return -1
+	}
+	if a != "" && b == "" {
+		return 1
+	}
+	return 0
+}
+
+// versionParts 提取版本号中的数字段，忽略前缀v和预发布后缀
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return nil
+	}
+
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
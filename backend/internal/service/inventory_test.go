@@ -0,0 +1,148 @@
+package service
+
+import (
+	"backend/internal/pocketbase"
+	"backend/pkg/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"0.12.1", "0.12.1", 0},
+		{"0.12.1", "0.9.9", 1},
+		{"v0.9.0", "0.10.0", -1},
+		{"1.0", "1.0.0", 0},
+		{"0.12.0-beta", "0.12.0", 0},
+		{"", "0.1.0", -1},
+		{"0.1.0", "", 1},
+	}
+	for _, tc := range cases {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestMatchSystem(t *testing.T) {
+	system := &models.System{
+		ID:     "sys1",
+		Name:   "HK-IEPL-01",
+		Status: "up",
+		Info: models.SystemInfo{
+			Hostname:     "hk01.example.net",
+			Kernel:       "6.1.0-18-amd64",
+			OS:           "linux",
+			CPUModel:     "AMD EPYC 7763",
+			AgentVersion: "0.12.1",
+		},
+	}
+
+	cases := []struct {
+		name   string
+		filter models.SystemFilter
+		want   bool
+	}{
+		{"empty", models.SystemFilter{}, true},
+		{"name keyword", models.SystemFilter{Keyword: "iepl"}, true},
+		{"cpu keyword", models.SystemFilter{Keyword: "epyc"}, true},
+		{"hostname keyword", models.SystemFilter{Keyword: "hk01.example"}, true},
+		{"no match", models.SystemFilter{Keyword: "xeon"}, false},
+		{"agent version", models.SystemFilter{AgentVersion: "0.12.1"}, true},
+		{"other agent version", models.SystemFilter{AgentVersion: "0.11.0"}, false},
+		{"os", models.SystemFilter{OS: "Linux"}, true},
+		{"status", models.SystemFilter{Status: "down"}, false},
+	}
+	for _, tc := range cases {
+		if got := matchSystem(system, &tc.filter); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGetFleetInventory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"page":1,"items":[
+			{"id":"a","name":"a","status":"up","info":{"v":"0.12.1","os":0,"m":"AMD EPYC","c":4}},
+			{"id":"b","name":"b","status":"up","info":{"v":"0.11.0","os":0,"m":"AMD EPYC","c":4}},
+			{"id":"c","name":"c","status":"up","info":{"v":"0.12.1","os":2,"m":"Intel Xeon","c":8}},
+			{"id":"d","name":"d","status":"down"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	client := pocketbase.NewClient(srv.URL)
+	client.SetAuthToken("static-token")
+	s := &SystemService{pbClient: client, statsSnapshot: make(map[string]*models.AverageStats)}
+
+	inventory, err := s.GetFleetInventory(context.Background())
+	if err != nil {
+		t.Fatalf("GetFleetInventory failed: %v", err)
+	}
+
+	if inventory.Total != 4 || inventory.LatestAgentVersion != "0.12.1" {
+		t.Errorf("unexpected totals: %+v", inventory)
+	}
+	if len(inventory.OutdatedAgents) != 2 {
+		t.Errorf("expected b (old) and d (unknown) to be outdated, got %+v", inventory.OutdatedAgents)
+	}
+
+	if len(inventory.ByAgentVersion) != 3 {
+		t.Fatalf("expected 3 version groups, got %+v", inventory.ByAgentVersion)
+	}
+	if g := inventory.ByAgentVersion[0]; g.Key != "0.12.1" || g.Count != 2 {
+		t.Errorf("newest version should come first, got %+v", g)
+	}
+	if g := inventory.ByOS[0]; g.Key != "linux" || g.Count != 2 {
+		t.Errorf("expected linux group with 2 systems first, got %+v", g)
+	}
+	if g := inventory.ByCPUModel[0]; g.Key != "AMD EPYC" || g.Count != 2 {
+		t.Errorf("expected AMD EPYC group first, got %+v", g)
+	}
+}
+
+func TestGetFleetInventoryPaginates(t *testing.T) {
+	const total = 1203
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+		if page < 1 || perPage < 1 {
+			t.Errorf("expected paged request, got %s", r.URL.RawQuery)
+			page, perPage = 1, 50
+		}
+		items := []map[string]any{}
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			items = append(items, map[string]any{
+				"id":     fmt.Sprintf("sys%d", i),
+				"name":   fmt.Sprintf("sys%d", i),
+				"status": "up",
+				"info":   map[string]any{"v": "0.12.1", "os": 0},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"page": page, "perPage": perPage, "items": items})
+	}))
+	defer srv.Close()
+
+	client := pocketbase.NewClient(srv.URL)
+	client.SetAuthToken("static-token")
+	s := &SystemService{pbClient: client, statsSnapshot: make(map[string]*models.AverageStats)}
+
+	inventory, err := s.GetFleetInventory(context.Background())
+	if err != nil {
+		t.Fatalf("GetFleetInventory failed: %v", err)
+	}
+	if inventory.Total != total {
+		t.Errorf("expected all %d systems across pages, got %d", total, inventory.Total)
+	}
+	if g := inventory.ByOS[0]; g.Key != "linux" || g.Count != total {
+		t.Errorf("expected every system in the linux group, got %+v", g)
+	}
+}
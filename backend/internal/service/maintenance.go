@@ -0,0 +1,289 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 维护窗口类型
+const (
+	MaintenanceKindWindow  = "window"  // 计划维护，指定开始和结束时间
+	MaintenanceKindSilence = "silence" // 临时静默，从创建时开始
+)
+
+// 维护期间节点在高负载节点列表中的处理方式
+const (
+	// MaintenanceExclude 不出现在高负载节点列表中，面板不会因为维护期间的高负载或离线把节点移出轮换
+	MaintenanceExclude = "exclude"
+	// MaintenanceInclude 始终出现在高负载节点列表中，维护期间节点被移出轮换
+	MaintenanceInclude = "include"
+)
+
+// LoadStatusMaintenance 处于维护窗口或静默中的系统的负载状态
+const LoadStatusMaintenance = "maintenance"
+
+var (
+	// ErrMaintenanceNotFound 维护窗口不存在
+	ErrMaintenanceNotFound = errors.New("维护窗口不存在")
+	// ErrInvalidMaintenance 维护窗口参数不合法
+	ErrInvalidMaintenance = errors.New("维护窗口参数错误")
+)
+
+// MaintenanceService 维护窗口和静默管理
+type MaintenanceService struct {
+	now func() time.Time
+}
+
+// NewMaintenanceService 创建维护窗口服务
+func NewMaintenanceService() *MaintenanceService {
+	return &MaintenanceService{now: time.Now}
+}
+
+// List 列出维护窗口，按开始时间排序。systemID不为空时只返回作用于该系统的窗口（含按标签匹配的），
+// activeOnly为true时只返回当前生效的窗口
+func (s *MaintenanceService) List(systemID string, activeOnly bool) ([]*models.MaintenanceWindow, error) {
+	storage := database.GetStorage()
+	windows, err := storage.ListMaintenance()
+	if err != nil {
+		return nil, fmt.Errorf("获取维护窗口失败: %w", err)
+	}
+
+	var systemTags map[string]bool
+	if systemID != "" {
+		tags, err := storage.GetNodeTags(systemID)
+		if err != nil {
+			return nil, fmt.Errorf("获取节点标签失败: %w", err)
+		}
+		systemTags = make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			systemTags[tagKey(tag.TagType, tag.TagID)] = true
+		}
+	}
+
+	now := s.now()
+	result := make([]*models.MaintenanceWindow, 0, len(windows))
+	for _, window := range windows {
+		if activeOnly && !maintenanceActive(window, now) {
+			continue
+		}
+		if systemID != "" && window.SystemID != systemID && !systemTags[tagKey(window.TagType, window.TagID)] {
+			continue
+		}
+		result = append(result, window)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartsAt.Before(result[j].StartsAt) })
+	return result, nil
+}
+
+// Get 获取维护窗口
+func (s *MaintenanceService) Get(id string) (*models.MaintenanceWindow, error) {
+	window, err := database.GetStorage().GetMaintenance(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取维护窗口失败: %w", err)
+	}
+	if window == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMaintenanceNotFound, id)
+	}
+	return window, nil
+}
+
+// Create 创建维护窗口或静默
+func (s *MaintenanceService) Create(req *models.MaintenanceRequest, change Change) (*models.MaintenanceWindow, error) {
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, fmt.Errorf("生成维护窗口ID失败: %w", err)
+	}
+	window := &models.MaintenanceWindow{ID: hex.EncodeToString(idBytes), CreatedBy: change.Actor}
+	if err := s.apply(window, req); err != nil {
+		return nil, err
+	}
+
+	if err := database.GetStorage().CreateOrUpdateMaintenance(window); err != nil {
+		return nil, fmt.Errorf("保存维护窗口失败: %w", err)
+	}
+	recordAudit(change, "create", AuditMaintenance, window.ID, 0, nil, window)
+	return window, nil
+}
+
+// Update 修改维护窗口，如延长或提前结束。静默未指定 starts_at 时保留原开始时间
+func (s *MaintenanceService) Update(id string, req *models.MaintenanceRequest, change Change) (*models.MaintenanceWindow, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	window := *existing
+	if req.StartsAt == nil {
+		startsAt := existing.StartsAt
+		req.StartsAt = &startsAt
+	}
+	if err := s.apply(&window, req); err != nil {
+		return nil, err
+	}
+
+	if err := database.GetStorage().CreateOrUpdateMaintenance(&window); err != nil {
+		return nil, fmt.Errorf("保存维护窗口失败: %w", err)
+	}
+	recordAudit(change, "update", AuditMaintenance, id, 0, existing, &window)
+	return &window, nil
+}
+
+// Delete 删除维护窗口，生效中的窗口删除后立即结束
+func (s *MaintenanceService) Delete(id string, change Change) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := database.GetStorage().DeleteMaintenance(id); err != nil {
+		return fmt.Errorf("删除维护窗口失败: %w", err)
+	}
+	recordAudit(change, "delete", AuditMaintenance, id, 0, existing, nil)
+	return nil
+}
+
+// apply 校验请求并写入窗口的可修改字段
+func (s *MaintenanceService) apply(window *models.MaintenanceWindow, req *models.MaintenanceRequest) error {
+	kind := req.Kind
+	if kind == "" {
+		kind = MaintenanceKindWindow
+	}
+	policy := req.Policy
+	if policy == "" {
+		policy = MaintenanceExclude
+	}
+	reason := strings.TrimSpace(req.Reason)
+	tagType := strings.TrimSpace(req.TagType)
+
+	switch {
+	case kind != MaintenanceKindWindow && kind != MaintenanceKindSilence:
+		return fmt.Errorf("%w: kind 只能是 %s 或 %s", ErrInvalidMaintenance, MaintenanceKindWindow, MaintenanceKindSilence)
+	case policy != MaintenanceExclude && policy != MaintenanceInclude:
+		return fmt.Errorf("%w: policy 只能是 %s 或 %s", ErrInvalidMaintenance, MaintenanceExclude, MaintenanceInclude)
+	case reason == "":
+		return fmt.Errorf("%w: 必须填写原因", ErrInvalidMaintenance)
+	case req.SystemID == "" && tagType == "":
+		return fmt.Errorf("%w: 必须指定 system_id 或节点标签（tag_type、tag_id）", ErrInvalidMaintenance)
+	case req.SystemID != "" && tagType != "":
+		return fmt.Errorf("%w: system_id 和节点标签只能指定一个", ErrInvalidMaintenance)
+	case tagType != "" && req.TagID <= 0:
+		return fmt.Errorf("%w: tag_id 必须是正整数", ErrInvalidMaintenance)
+	case req.DurationMinutes < 0:
+		return fmt.Errorf("%w: duration_minutes 不能为负数", ErrInvalidMaintenance)
+	case req.EndsAt != nil && req.DurationMinutes > 0:
+		return fmt.Errorf("%w: ends_at 和 duration_minutes 只能指定一个", ErrInvalidMaintenance)
+	}
+
+	now := s.now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	} else if kind == MaintenanceKindWindow {
+		return fmt.Errorf("%w: 计划维护必须指定 starts_at", ErrInvalidMaintenance)
+	}
+	var endsAt time.Time
+	switch {
+	case req.EndsAt != nil:
+		endsAt = *req.EndsAt
+	case req.DurationMinutes > 0:
+		endsAt = startsAt.Add(time.Duration(req.DurationMinutes) * time.Minute)
+	default:
+		return fmt.Errorf("%w: 必须指定 ends_at 或 duration_minutes", ErrInvalidMaintenance)
+	}
+	if !endsAt.After(startsAt) {
+		return fmt.Errorf("%w: ends_at 必须晚于 starts_at", ErrInvalidMaintenance)
+	}
+	if !endsAt.After(now) {
+		return fmt.Errorf("%w: ends_at 必须晚于当前时间", ErrInvalidMaintenance)
+	}
+
+	window.Kind = kind
+	window.Policy = policy
+	window.Reason = reason
+	window.SystemID = req.SystemID
+	window.TagType, window.TagID = tagType, req.TagID
+	if tagType == "" {
+		window.TagID = 0
+	}
+	window.StartsAt, window.EndsAt = startsAt, endsAt
+	return nil
+}
+
+// Active 返回当前处于维护中的系统及其生效的窗口，节点标签按本地的标签配置对应到系统。
+// 一个系统有多个生效窗口时，policy 为 include 的优先，其次取结束时间最晚的
+func (s *MaintenanceService) Active(ctx context.Context) (map[string]*models.MaintenanceWindow, error) {
+	storage := database.GetStorageContext(ctx)
+	windows, err := storage.ListMaintenance()
+	if err != nil {
+		return nil, fmt.Errorf("获取维护窗口失败: %w", err)
+	}
+
+	now := s.now()
+	active := make(map[string]*models.MaintenanceWindow)
+	var byTag map[string][]string
+	for _, window := range windows {
+		if !maintenanceActive(window, now) {
+			continue
+		}
+		if window.SystemID != "" {
+			mergeMaintenance(active, window.SystemID, window)
+			continue
+		}
+
+		// 只有存在按标签的窗口时才读取标签
+		if byTag == nil {
+			tags, err := storage.ListNodeTags()
+			if err != nil {
+				return nil, fmt.Errorf("获取节点标签失败: %w", err)
+			}
+			byTag = make(map[string][]string)
+			for _, tag := range tags {
+				key := tagKey(tag.TagType, tag.TagID)
+				byTag[key] = append(byTag[key], tag.SystemID)
+			}
+		}
+		for _, systemID := range byTag[tagKey(window.TagType, window.TagID)] {
+			mergeMaintenance(active, systemID, window)
+		}
+	}
+	return active, nil
+}
+
+// mergeMaintenance 按优先级记录系统的生效窗口
+func mergeMaintenance(active map[string]*models.MaintenanceWindow, systemID string, window *models.MaintenanceWindow) {
+	current := active[systemID]
+	if current == nil {
+		active[systemID] = window
+		return
+	}
+	if current.Policy != window.Policy {
+		if window.Policy == MaintenanceInclude {
+			active[systemID] = window
+		}
+		return
+	}
+	if window.EndsAt.After(current.EndsAt) {
+		active[systemID] = window
+	}
+}
+
+// maintenanceActive 窗口在now时是否生效
+func maintenanceActive(window *models.MaintenanceWindow, now time.Time) bool {
+	return !now.Before(window.StartsAt) && now.Before(window.EndsAt)
+}
+
+func tagKey(tagType string, tagID int) string {
+	return fmt.Sprintf("%s:%d", tagType, tagID)
+}
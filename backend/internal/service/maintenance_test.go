@@ -0,0 +1,121 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func TestMaintenanceValidation(t *testing.T) {
+	setupThresholdStorage(t)
+	s := NewMaintenanceService()
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		req  models.MaintenanceRequest
+	}{
+		{"缺少原因", models.MaintenanceRequest{Kind: MaintenanceKindSilence, SystemID: "a", DurationMinutes: 30}},
+		{"没有目标", models.MaintenanceRequest{Kind: MaintenanceKindSilence, Reason: "迁移", DurationMinutes: 30}},
+		{"同时指定系统和标签", models.MaintenanceRequest{Kind: MaintenanceKindSilence, Reason: "迁移", SystemID: "a", TagType: "ss", TagID: 1, DurationMinutes: 30}},
+		{"标签ID无效", models.MaintenanceRequest{Kind: MaintenanceKindSilence, Reason: "迁移", TagType: "ss", DurationMinutes: 30}},
+		{"计划维护缺少开始时间", models.MaintenanceRequest{Reason: "迁移", SystemID: "a", EndsAt: timePtr(now.Add(time.Hour))}},
+		{"缺少结束时间", models.MaintenanceRequest{Kind: MaintenanceKindSilence, Reason: "迁移", SystemID: "a"}},
+		{"已经结束", models.MaintenanceRequest{Reason: "迁移", SystemID: "a", StartsAt: timePtr(now.Add(-2 * time.Hour)), EndsAt: timePtr(now.Add(-time.Hour))}},
+		{"未知policy", models.MaintenanceRequest{Kind: MaintenanceKindSilence, Reason: "迁移", SystemID: "a", DurationMinutes: 30, Policy: "drop"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Create(&tt.req, Change{Actor: "test"}); !errors.Is(err, ErrInvalidMaintenance) {
+				t.Errorf("期望 ErrInvalidMaintenance, 得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestActiveMaintenance(t *testing.T) {
+	setupThresholdStorage(t)
+	if err := database.GetStorage().CreateNodeTag(&models.NodeTag{SystemID: "b", TagType: "v2ray", TagID: 12}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMaintenanceService()
+	now := time.Now()
+	change := Change{Actor: "test"}
+
+	silence, err := s.Create(&models.MaintenanceRequest{Kind: MaintenanceKindSilence, SystemID: "a", Reason: "升级内核", DurationMinutes: 30}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if silence.Policy != MaintenanceExclude || !silence.EndsAt.After(silence.StartsAt) {
+		t.Errorf("静默的默认值: %+v", silence)
+	}
+	byTag, err := s.Create(&models.MaintenanceRequest{
+		TagType: "v2ray", TagID: 12, Reason: "机房迁移", Policy: MaintenanceExclude,
+		StartsAt: timePtr(now.Add(-time.Minute)), EndsAt: timePtr(now.Add(time.Hour)),
+	}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drain, err := s.Create(&models.MaintenanceRequest{
+		SystemID: "b", Reason: "下线前排空", Policy: MaintenanceInclude,
+		StartsAt: timePtr(now.Add(-time.Minute)), EndsAt: timePtr(now.Add(30 * time.Minute)),
+	}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(&models.MaintenanceRequest{
+		SystemID: "c", Reason: "下周维护",
+		StartsAt: timePtr(now.Add(24 * time.Hour)), EndsAt: timePtr(now.Add(25 * time.Hour)),
+	}, change); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := s.Active(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 || active["a"].ID != silence.ID {
+		t.Errorf("生效的维护窗口: %+v", active)
+	}
+	// 按标签和按系统的窗口同时生效时，include优先
+	if active["b"] == nil || active["b"].ID != drain.ID {
+		t.Errorf("系统b的维护窗口 = %+v, 期望 %s", active["b"], drain.ID)
+	}
+
+	windows, err := s.List("b", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 2 || windows[0].ID != byTag.ID && windows[1].ID != byTag.ID {
+		t.Errorf("系统b的维护窗口列表: %+v", windows)
+	}
+
+	// 提前结束：删除后不再生效
+	if err := s.Delete(drain.ID, change); err != nil {
+		t.Fatal(err)
+	}
+	active, err = s.Active(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active["b"] == nil || active["b"].ID != byTag.ID {
+		t.Errorf("删除后系统b的维护窗口 = %+v, 期望 %s", active["b"], byTag.ID)
+	}
+
+	// 静默延长后保留原开始时间
+	updated, err := s.Update(silence.ID, &models.MaintenanceRequest{Kind: MaintenanceKindSilence, SystemID: "a", Reason: "升级内核", DurationMinutes: 90}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.StartsAt.Equal(silence.StartsAt) || !updated.EndsAt.Equal(silence.StartsAt.Add(90*time.Minute)) {
+		t.Errorf("延长后的静默: %+v", updated)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, ErrMaintenanceNotFound) {
+		t.Errorf("期望 ErrMaintenanceNotFound, 得到 %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/internal/metrics"
+	"backend/internal/pocketbase"
+	"backend/internal/tracing"
+	"backend/pkg/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsCacheTTL 负载数据的缓存时间。计算一次需要逐个系统请求PocketBase，
+// 多个Prometheus副本或较短的抓取间隔不会放大上游请求
+const metricsCacheTTL = 15 * time.Second
+
+// loadStatuses 负载状态的所有取值，每个状态导出一条0/1序列
+var loadStatuses = []string{"normal", "high", LoadStatusMaintenance, LoadStatusUnknown}
+
+// anomalyStatuses 异常检测状态的所有取值，未检测的系统不导出
+var anomalyStatuses = []string{AnomalyStatusNormal, AnomalyStatusAnomaly, AnomalyStatusUnknown}
+
+var (
+	systemLabels = []string{"system_id", "system", "alias", "tags"}
+	nodeLabels   = []string{"system_id", "alias", "node_type", "node_id", "node_name"}
+
+	systemDescs = struct {
+		up, cpu, mem, disk, swap, load1, netSent, netRecv, netUpMax, netDownMax, online, status, anomaly, updated *prometheus.Desc
+	}{
+		up:         systemDesc("up", "Beszel报告的系统状态，1为up"),
+		cpu:        systemDesc("cpu_percent", "最近5条1分钟记录的平均CPU使用率"),
+		mem:        systemDesc("memory_percent", "平均内存使用率"),
+		disk:       systemDesc("disk_percent", "平均根分区使用率"),
+		swap:       systemDesc("swap_percent", "平均交换分区使用率"),
+		load1:      systemDesc("load1", "最新的1分钟负载均值"),
+		netSent:    systemDesc("network_sent_mbps", "平均上行带宽（Mbps）"),
+		netRecv:    systemDesc("network_received_mbps", "平均下行带宽（Mbps）"),
+		netUpMax:   systemDesc("network_up_max_mbps", "学习到的上行带宽历史极限值（Mbps）"),
+		netDownMax: systemDesc("network_down_max_mbps", "学习到的下行带宽历史极限值（Mbps）"),
+		online:     systemDesc("online_users", "系统上所有节点的在线人数之和"),
+		status:     prometheus.NewDesc(metrics.Namespace+"_system_load_status", "负载状态，当前状态的序列为1", append(systemLabels, "status"), nil),
+		anomaly:    prometheus.NewDesc(metrics.Namespace+"_system_anomaly_status", "与历史基线比较的异常检测状态，当前状态的序列为1", append(systemLabels, "status"), nil),
+		updated:    systemDesc("last_update_timestamp_seconds", "最新一条统计记录的时间"),
+	}
+
+	nodeOnlineDesc = prometheus.NewDesc(metrics.Namespace+"_node_online_users", "节点在线人数（来自v2board Redis）", nodeLabels, nil)
+
+	loadAgeDesc     = prometheus.NewDesc(metrics.Namespace+"_load_cache_age_seconds", "导出的负载数据距上次计算的时间", nil, nil)
+	loadSuccessDesc = prometheus.NewDesc(metrics.Namespace+"_load_collect_success", "最近一次计算负载数据是否成功", nil, nil)
+	snapshotAgeDesc = prometheus.NewDesc(metrics.Namespace+"_pocketbase_snapshot_age_seconds", "距最近一次成功从PocketBase获取系统列表的时间", nil, nil)
+	circuitDesc     = prometheus.NewDesc(metrics.Namespace+"_pocketbase_circuit_open", "PocketBase熔断器是否打开（半开也记为1）", nil, nil)
+)
+
+func systemDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(metrics.Namespace+"_system_"+name, help, systemLabels, nil)
+}
+
+// MetricsCollector 在Prometheus抓取时导出按系统的负载数据和按节点的在线人数
+type MetricsCollector struct {
+	loadSystems func(context.Context) ([]*models.SystemWithLoadStatus, error)
+	listNodes   func(context.Context) ([]models.V2boardNode, error)
+	health      func() *models.UpstreamHealth
+	thresholds  *ThresholdService
+	ttl         time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	cached    *loadSnapshot
+	attempted time.Time // 上一次计算的时间，失败后同样等待ttl再重试
+	lastErr   error
+}
+
+// loadSnapshot 一次计算得到的负载数据
+type loadSnapshot struct {
+	at      time.Time
+	systems []systemSample
+	nodes   []nodeSample
+}
+
+type systemSample struct {
+	system     *models.SystemWithLoadStatus
+	labels     []string
+	netUpMax   float64
+	netDownMax float64
+}
+
+type nodeSample struct {
+	labels []string
+	online int
+}
+
+// NewMetricsCollector 创建负载指标收集器，nodeService 为nil时不导出节点指标
+func NewMetricsCollector(systemService *SystemService, nodeService *NodeService) *MetricsCollector {
+	c := &MetricsCollector{
+		loadSystems: systemService.GetSystemsWithLoadStatus,
+		health:      systemService.PocketBaseHealth,
+		thresholds:  NewThresholdService(),
+		ttl:         metricsCacheTTL,
+		now:         time.Now,
+	}
+	if nodeService != nil {
+		c.listNodes = func(ctx context.Context) ([]models.V2boardNode, error) {
+			if !nodeService.Available() {
+				return nil, nil
+			}
+			return nodeService.redisService.GetAllNodes(ctx)
+		}
+	}
+	return c
+}
+
+// Describe 实现 prometheus.Collector
+func (c *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		systemDescs.up, systemDescs.cpu, systemDescs.mem, systemDescs.disk, systemDescs.swap, systemDescs.load1,
+		systemDescs.netSent, systemDescs.netRecv, systemDescs.netUpMax, systemDescs.netDownMax,
+		systemDescs.online, systemDescs.status, systemDescs.anomaly, systemDescs.updated,
+		nodeOnlineDesc, loadAgeDesc, loadSuccessDesc, snapshotAgeDesc, circuitDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect 实现 prometheus.Collector。计算失败时继续导出上一次的数据，并把 load_collect_success 置0
+func (c *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot, err := c.snapshot()
+
+	success := 1.0
+	if err != nil {
+		success = 0
+	}
+	ch <- prometheus.MustNewConstMetric(loadSuccessDesc, prometheus.GaugeValue, success)
+
+	if snapshot != nil {
+		ch <- prometheus.MustNewConstMetric(loadAgeDesc, prometheus.GaugeValue, c.now().Sub(snapshot.at).Seconds())
+		for _, sample := range snapshot.systems {
+			collectSystem(ch, sample)
+		}
+		for _, node := range snapshot.nodes {
+			ch <- prometheus.MustNewConstMetric(nodeOnlineDesc, prometheus.GaugeValue, float64(node.online), node.labels...)
+		}
+	}
+
+	if c.health != nil {
+		health := c.health()
+		circuit := 0.0
+		if health.Circuit != pocketbase.BreakerClosed {
+			circuit = 1
+		}
+		ch <- prometheus.MustNewConstMetric(circuitDesc, prometheus.GaugeValue, circuit)
+		if health.SnapshotAt != nil {
+			ch <- prometheus.MustNewConstMetric(snapshotAgeDesc, prometheus.GaugeValue, health.SnapshotAgeSeconds)
+		}
+	}
+}
+
+func collectSystem(ch chan<- prometheus.Metric, sample systemSample) {
+	s := sample.system
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, sample.labels...)
+	}
+
+	up := 0.0
+	if s.Status == "up" {
+		up = 1
+	}
+	gauge(systemDescs.up, up)
+	gauge(systemDescs.cpu, s.AvgCPU)
+	gauge(systemDescs.mem, s.AvgMemPct)
+	gauge(systemDescs.disk, s.AvgDiskPct)
+	gauge(systemDescs.swap, s.AvgSwapPct)
+	gauge(systemDescs.load1, s.LoadAvg1)
+	gauge(systemDescs.netSent, s.AvgNetSent*8)
+	gauge(systemDescs.netRecv, s.AvgNetRecv*8)
+	gauge(systemDescs.netUpMax, sample.netUpMax)
+	gauge(systemDescs.netDownMax, sample.netDownMax)
+	gauge(systemDescs.online, float64(s.OnlineUsers))
+	if !s.LastUpdate.IsZero() {
+		gauge(systemDescs.updated, float64(s.LastUpdate.Unix()))
+	}
+
+	for _, status := range loadStatuses {
+		value := 0.0
+		if s.LoadStatus == status {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(systemDescs.status, prometheus.GaugeValue, value, append(sample.labels, status)...)
+	}
+	if s.AnomalyStatus == "" {
+		return
+	}
+	for _, status := range anomalyStatuses {
+		value := 0.0
+		if s.AnomalyStatus == status {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(systemDescs.anomaly, prometheus.GaugeValue, value, append(sample.labels, status)...)
+	}
+}
+
+// snapshot 返回缓存的负载数据，过期时重新计算；计算失败时返回上一次的数据和错误
+func (c *MetricsCollector) snapshot() (*loadSnapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.attempted.IsZero() && c.now().Sub(c.attempted) < c.ttl {
+		return c.cached, c.lastErr
+	}
+
+	c.attempted = c.now()
+	snapshot, err := c.compute()
+	if err != nil {
+		slog.Error("计算Prometheus负载指标失败", "op", "metrics.compute", logging.Err(err))
+		c.lastErr = err
+		return c.cached, err
+	}
+	c.cached, c.lastErr = snapshot, nil
+	return snapshot, nil
+}
+
+// compute 计算所有系统的负载数据，别名、标签和节点列表各只读取一次。
+// Prometheus抓取没有上游trace，本次计算的上游调用都归到同一个根span下
+func (c *MetricsCollector) compute() (_ *loadSnapshot, err error) {
+	ctx, span := tracing.Start(context.Background(), "MetricsCollector.compute")
+	defer func() { tracing.End(span, err) }()
+
+	systems, err := c.loadSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := database.GetStorageContext(ctx)
+	aliases := make(map[string]string)
+	if list, err := storage.GetAllSystemAliases(); err != nil {
+		slog.WarnContext(ctx, "获取别名失败，指标中不包含别名", "op", "metrics.compute", logging.Err(err))
+	} else {
+		for _, alias := range list {
+			aliases[alias.SystemID] = alias.Alias
+		}
+	}
+	tags := make(map[string][]string)
+	if list, err := storage.ListNodeTags(); err != nil {
+		slog.WarnContext(ctx, "获取节点标签失败，指标中不包含标签", "op", "metrics.compute", logging.Err(err))
+	} else {
+		for _, tag := range list {
+			tags[tag.SystemID] = append(tags[tag.SystemID], fmt.Sprintf("%s:%d", tag.TagType, tag.TagID))
+		}
+	}
+
+	snapshot := &loadSnapshot{at: c.now()}
+	for _, system := range systems {
+		systemTags := tags[system.ID]
+		sort.Strings(systemTags)
+		sample := systemSample{
+			system: system,
+			labels: []string{system.ID, system.Name, aliases[system.ID], strings.Join(systemTags, ",")},
+		}
+		if threshold, err := c.thresholds.GetThreshold(ctx, system.ID); err == nil {
+			sample.netUpMax, sample.netDownMax = threshold.NetUpMax, threshold.NetDownMax
+		}
+		snapshot.systems = append(snapshot.systems, sample)
+	}
+
+	if c.listNodes != nil {
+		nodes, err := c.listNodes(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "获取节点列表失败，指标中不包含节点", "op", "metrics.compute", logging.Err(err))
+		}
+		// 与 NodeService.GetSystemNodeInfo 相同的匹配规则：节点名称包含系统别名
+		for _, system := range systems {
+			alias := aliases[system.ID]
+			if alias == "" {
+				continue
+			}
+			for _, node := range nodes {
+				if strings.Contains(node.Name, alias) {
+					snapshot.nodes = append(snapshot.nodes, nodeSample{
+						labels: []string{system.ID, alias, node.Type, strconv.Itoa(node.ID), node.Name},
+						online: node.Online,
+					})
+				}
+			}
+		}
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	setupThresholdStorage(t)
+	storage := database.GetStorage()
+	if err := storage.SetSystemAlias(&models.SystemAlias{SystemID: "a", Alias: "东京1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []*models.NodeTag{{SystemID: "a", TagType: "v2ray", TagID: 12}, {SystemID: "a", TagType: "ss", TagID: 3}} {
+		if err := storage.CreateNodeTag(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := 0
+	var loadErr error
+	now := time.Now()
+	c := &MetricsCollector{
+		loadSystems: func(context.Context) ([]*models.SystemWithLoadStatus, error) {
+			calls++
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			return []*models.SystemWithLoadStatus{{
+				SystemWithAvgStats: models.SystemWithAvgStats{
+					System:      models.System{ID: "a", Name: "tokyo-1", Status: "up"},
+					AvgCPU:      95,
+					AvgNetSent:  2,
+					OnlineUsers: 30,
+				},
+				LoadStatus:    "high",
+				AnomalyStatus: AnomalyStatusAnomaly,
+			}}, nil
+		},
+		listNodes: func(context.Context) ([]models.V2boardNode, error) {
+			return []models.V2boardNode{
+				{Name: "东京1-v2ray", Type: "v2ray", ID: 12, Online: 20},
+				{Name: "香港2-ss", Type: "ss", ID: 4, Online: 7},
+			}, nil
+		},
+		thresholds: NewThresholdService(),
+		ttl:        time.Minute,
+		now:        func() time.Time { return now },
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+
+	expected := `
+# HELP beszel_system_cpu_percent 最近5条1分钟记录的平均CPU使用率
+# TYPE beszel_system_cpu_percent gauge
+beszel_system_cpu_percent{alias="东京1",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 95
+# HELP beszel_system_load_status 负载状态，当前状态的序列为1
+# TYPE beszel_system_load_status gauge
+beszel_system_load_status{alias="东京1",status="high",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 1
+beszel_system_load_status{alias="东京1",status="maintenance",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 0
+beszel_system_load_status{alias="东京1",status="normal",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 0
+beszel_system_load_status{alias="东京1",status="unknown",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 0
+# HELP beszel_system_anomaly_status 与历史基线比较的异常检测状态，当前状态的序列为1
+# TYPE beszel_system_anomaly_status gauge
+beszel_system_anomaly_status{alias="东京1",status="anomaly",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 1
+beszel_system_anomaly_status{alias="东京1",status="normal",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 0
+beszel_system_anomaly_status{alias="东京1",status="unknown",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 0
+# HELP beszel_system_network_sent_mbps 平均上行带宽（Mbps）
+# TYPE beszel_system_network_sent_mbps gauge
+beszel_system_network_sent_mbps{alias="东京1",system="tokyo-1",system_id="a",tags="ss:3,v2ray:12"} 16
+# HELP beszel_node_online_users 节点在线人数（来自v2board Redis）
+# TYPE beszel_node_online_users gauge
+beszel_node_online_users{alias="东京1",node_id="12",node_name="东京1-v2ray",node_type="v2ray",system_id="a"} 20
+# HELP beszel_load_collect_success 最近一次计算负载数据是否成功
+# TYPE beszel_load_collect_success gauge
+beszel_load_collect_success 1
+`
+	names := []string{"beszel_system_cpu_percent", "beszel_system_load_status", "beszel_system_anomaly_status", "beszel_system_network_sent_mbps",
+		"beszel_node_online_users", "beszel_load_collect_success"}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), names...); err != nil {
+		t.Fatal(err)
+	}
+
+	// 缓存期内不重新计算
+	testutil.GatherAndCount(reg)
+	if calls != 1 {
+		t.Errorf("loadSystems 调用 %d 次, 期望缓存期内只调用1次", calls)
+	}
+
+	// 过期后计算失败：继续导出旧数据，success为0
+	now = now.Add(2 * time.Minute)
+	loadErr = errors.New("pocketbase down")
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP beszel_load_collect_success 最近一次计算负载数据是否成功
+# TYPE beszel_load_collect_success gauge
+beszel_load_collect_success 0
+# HELP beszel_load_cache_age_seconds 导出的负载数据距上次计算的时间
+# TYPE beszel_load_cache_age_seconds gauge
+beszel_load_cache_age_seconds 120
+`), "beszel_load_collect_success", "beszel_load_cache_age_seconds"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := testutil.GatherAndCount(reg, "beszel_system_cpu_percent"); n != 1 {
+		t.Errorf("计算失败时应继续导出旧数据, 得到 %d 条", n)
+	}
+}
@@ -1,29 +1,54 @@
 package service
 
 import (
+	"backend/internal/logging"
+	"backend/internal/tracing"
 	"backend/pkg/models"
+	"context"
 	"fmt"
+	"log/slog"
 	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // NodeService 节点服务
 type NodeService struct {
 	redisService *RedisService
 	aliasService *AliasService
+	drains       *DrainService
 }
 
+// 节点出现在高负载节点列表中的原因
+const (
+	HighLoadReasonHigh        = "high"
+	HighLoadReasonOffline     = "offline"
+	HighLoadReasonMaintenance = "maintenance"
+	HighLoadReasonDrained     = "drained"
+	HighLoadReasonUnknown     = "unknown"
+)
+
 // NewNodeService 创建节点服务
 func NewNodeService(redisService *RedisService) *NodeService {
 	return &NodeService{
 		redisService: redisService,
 		aliasService: NewAliasService(),
+		drains:       NewDrainService(),
 	}
 }
 
+// Available 节点数据来源（Redis）是否可用
+func (s *NodeService) Available() bool {
+	return s.redisService != nil && s.redisService.Available()
+}
+
 // GetSystemNodeInfo 获取系统的节点信息
-func (s *NodeService) GetSystemNodeInfo(systemID, systemName string) (*models.SystemNodeInfo, error) {
+func (s *NodeService) GetSystemNodeInfo(ctx context.Context, systemID, systemName string) (_ *models.SystemNodeInfo, err error) {
+	ctx, span := tracing.Start(ctx, "NodeService.GetSystemNodeInfo", attribute.String("system.id", systemID))
+	defer func() { tracing.End(span, err) }()
+
 	// 获取系统别名
-	alias, err := s.aliasService.GetAlias(systemID)
+	alias, err := s.aliasService.GetAlias(ctx, systemID)
 	if err != nil {
 		return nil, fmt.Errorf("获取系统别名失败: %w", err)
 	}
@@ -42,7 +67,7 @@ func (s *NodeService) GetSystemNodeInfo(systemID, systemName string) (*models.Sy
 	result.Alias = alias.Alias
 
 	// 根据别名模糊匹配节点
-	nodes, err := s.redisService.GetNodesByAlias(alias.Alias)
+	nodes, err := s.redisService.GetNodesByAlias(ctx, alias.Alias)
 	if err != nil {
 		return nil, fmt.Errorf("查询节点信息失败: %w", err)
 	}
@@ -60,14 +85,14 @@ func (s *NodeService) GetSystemNodeInfo(systemID, systemName string) (*models.Sy
 }
 
 // GetAllSystemsNodeInfo 获取所有系统的节点信息
-func (s *NodeService) GetAllSystemsNodeInfo(systems []*models.System) ([]*models.SystemNodeInfo, error) {
+func (s *NodeService) GetAllSystemsNodeInfo(ctx context.Context, systems []*models.System) ([]*models.SystemNodeInfo, error) {
 	var results []*models.SystemNodeInfo
 
 	for _, system := range systems {
-		nodeInfo, err := s.GetSystemNodeInfo(system.ID, system.Name)
+		nodeInfo, err := s.GetSystemNodeInfo(ctx, system.ID, system.Name)
 		if err != nil {
 			// 记录错误但继续处理其他系统
-			fmt.Printf("获取系统 %s 节点信息失败: %v\n", system.ID, err)
+			slog.WarnContext(ctx, "获取系统节点信息失败", "op", "nodes.list", "system_id", system.ID, "system", system.Name, logging.Err(err))
 			continue
 		}
 		results = append(results, nodeInfo)
@@ -77,15 +102,107 @@ func (s *NodeService) GetAllSystemsNodeInfo(systems []*models.System) ([]*models
 }
 
 // SearchNodesByKeyword 根据关键词搜索节点
-func (s *NodeService) SearchNodesByKeyword(keyword string) ([]models.V2boardNode, error) {
+func (s *NodeService) SearchNodesByKeyword(ctx context.Context, keyword string) ([]models.V2boardNode, error) {
 	if strings.TrimSpace(keyword) == "" {
 		return []models.V2boardNode{}, nil
 	}
 
-	nodes, err := s.redisService.GetNodesByAlias(keyword)
+	nodes, err := s.redisService.GetNodesByAlias(ctx, keyword)
 	if err != nil {
 		return nil, fmt.Errorf("搜索节点失败: %w", err)
 	}
 
 	return nodes, nil
-}
\ No newline at end of file
+}
+
+// GetHighLoadNodes 根据系统负载状态列出应移出轮换的节点：被排空的服务器和节点、
+// 高负载、离线或负载未知的服务器上的节点，以及policy为include的维护中服务器上的节点
+func (s *NodeService) GetHighLoadNodes(ctx context.Context, systems []*models.SystemWithLoadStatus) ([]models.HighLoadNode, error) {
+	drains, err := s.drains.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化为空数组而不是nil slice，确保JSON返回[]而不是null
+	result := make([]models.HighLoadNode, 0)
+	listed := make(map[string]bool)
+	add := func(node models.V2boardNode, systemID, reason string) {
+		key := tagKey(node.Type, node.ID)
+		if listed[key] {
+			return
+		}
+		listed[key] = true
+		// 单独排空的节点即使所在服务器已因其他原因列出，也以排空作为原因
+		if drains.Node(node.Type, node.ID) != nil {
+			reason = HighLoadReasonDrained
+		}
+		result = append(result, models.HighLoadNode{
+			Name:     node.Name,
+			Type:     node.Type,
+			ID:       node.ID,
+			Online:   node.Online,
+			SystemID: systemID,
+			Reason:   reason,
+		})
+	}
+
+	for _, system := range systems {
+		reason := systemHighLoadReason(system)
+		if reason == "" {
+			continue
+		}
+		nodeInfo, err := s.GetSystemNodeInfo(ctx, system.ID, system.Name)
+		if err != nil {
+			// 跳过获取失败的系统
+			slog.WarnContext(ctx, "获取系统节点信息失败", "op", "nodes.high_load", "system_id", system.ID, "system", system.Name, logging.Err(err))
+			continue
+		}
+		for _, node := range nodeInfo.Nodes {
+			add(node, system.ID, reason)
+		}
+	}
+
+	// 单独排空的节点不一定在上面列出的服务器上，从全部节点中补充
+	pending := false
+	for key := range drains.Nodes {
+		if !listed[key] {
+			pending = true
+			break
+		}
+	}
+	if pending {
+		nodes, err := s.redisService.GetAllNodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取节点列表失败: %w", err)
+		}
+		for _, node := range nodes {
+			if drains.Node(node.Type, node.ID) != nil {
+				add(node, "", HighLoadReasonDrained)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// systemHighLoadReason 返回服务器上的节点应列为高负载的原因，不需要列出时返回空字符串。
+// 维护中的服务器按维护窗口的policy决定是否列出
+func systemHighLoadReason(system *models.SystemWithLoadStatus) string {
+	switch {
+	case system.Drain != nil:
+		return HighLoadReasonDrained
+	case system.Maintenance != nil:
+		if system.Maintenance.Policy == MaintenanceInclude {
+			return HighLoadReasonMaintenance
+		}
+		return ""
+	case system.LoadStatus == "high":
+		return HighLoadReasonHigh
+	case system.Status != "up":
+		return HighLoadReasonOffline
+	case system.LoadStatus == LoadStatusUnknown:
+		return HighLoadReasonUnknown
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNodeTagNotFound 节点标签不存在
+	ErrNodeTagNotFound = errors.New("节点标签不存在")
+	// ErrInvalidNodeTag 节点标签参数不合法
+	ErrInvalidNodeTag = errors.New("节点标签参数不合法")
+)
+
+// NodeTagService 节点标签服务：把v2board节点（类型+ID）关联到服务器，
+// 按标签的维护窗口和指标的 tags 标签都通过它找到服务器
+type NodeTagService struct{}
+
+// NewNodeTagService 创建节点标签服务
+func NewNodeTagService() *NodeTagService {
+	return &NodeTagService{}
+}
+
+// List 获取服务器的节点标签
+func (s *NodeTagService) List(ctx context.Context, systemID string) ([]*models.NodeTag, error) {
+	tags, err := database.GetStorageContext(ctx).GetNodeTags(systemID)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点标签失败: %w", err)
+	}
+	if tags == nil {
+		tags = []*models.NodeTag{}
+	}
+	return tags, nil
+}
+
+// Add 为服务器添加节点标签，标签已存在时直接返回已有的标签
+func (s *NodeTagService) Add(systemID string, request *models.NodeTagRequest, change Change) (*models.NodeTag, error) {
+	tagType := strings.TrimSpace(request.Type)
+	if tagType == "" {
+		return nil, fmt.Errorf("%w: 节点类型不能为空", ErrInvalidNodeTag)
+	}
+	if request.ID <= 0 {
+		return nil, fmt.Errorf("%w: 节点ID必须大于0", ErrInvalidNodeTag)
+	}
+
+	storage := database.GetStorage()
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := s.find(storage, systemID, tagType, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	tag := &models.NodeTag{SystemID: systemID, TagType: tagType, TagID: request.ID}
+	if err := storage.CreateNodeTag(tag); err != nil {
+		return nil, fmt.Errorf("添加节点标签失败: %w", err)
+	}
+	recordAudit(change, "create", AuditNodeTag, systemID, 0, nil, tag)
+	return tag, nil
+}
+
+// Remove 删除服务器的节点标签
+func (s *NodeTagService) Remove(systemID string, request *models.NodeTagRequest, change Change) error {
+	tagType := strings.TrimSpace(request.Type)
+	storage := database.GetStorage()
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := s.find(storage, systemID, tagType, request.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrNodeTagNotFound, nodeTagName(models.NodeTagRef{Type: tagType, ID: request.ID}))
+	}
+
+	if err := storage.DeleteNodeTag(systemID, tagType, request.ID); err != nil {
+		return fmt.Errorf("删除节点标签失败: %w", err)
+	}
+	recordAudit(change, "delete", AuditNodeTag, systemID, 0, existing, nil)
+	return nil
+}
+
+// find 查找服务器上的指定标签，不存在时返回nil
+func (s *NodeTagService) find(storage database.Storage, systemID, tagType string, tagID int) (*models.NodeTag, error) {
+	tags, err := storage.GetNodeTags(systemID)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点标签失败: %w", err)
+	}
+	for _, tag := range tags {
+		if tag.TagType == tagType && tag.TagID == tagID {
+			return tag, nil
+		}
+	}
+	return nil, nil
+}
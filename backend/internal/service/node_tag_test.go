@@ -0,0 +1,61 @@
+package service
+
+import (
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNodeTagsScopeMaintenance(t *testing.T) {
+	setupThresholdStorage(t)
+	tags := NewNodeTagService()
+	maintenance := NewMaintenanceService()
+	ctx := context.Background()
+	change := Change{Actor: "test"}
+
+	if _, err := tags.Add("b", &models.NodeTagRequest{Type: " ", ID: 12}, change); !errors.Is(err, ErrInvalidNodeTag) {
+		t.Fatalf("空类型: err = %v, 期望 ErrInvalidNodeTag", err)
+	}
+	tag, err := tags.Add("b", &models.NodeTagRequest{Type: "v2ray", ID: 12}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重复添加返回已有的标签
+	again, err := tags.Add("b", &models.NodeTagRequest{Type: "v2ray", ID: 12}, change)
+	if err != nil || again.ID != tag.ID {
+		t.Fatalf("重复添加: %+v, err = %v", again, err)
+	}
+	list, err := tags.List(ctx, "b")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("标签列表: %+v, err = %v", list, err)
+	}
+
+	// 通过接口添加的标签即可让按标签的维护窗口生效
+	now := time.Now()
+	window, err := maintenance.Create(&models.MaintenanceRequest{
+		TagType: "v2ray", TagID: 12, Reason: "机房迁移",
+		StartsAt: timePtr(now.Add(-time.Minute)), EndsAt: timePtr(now.Add(time.Hour)),
+	}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := maintenance.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active["b"] == nil || active["b"].ID != window.ID {
+		t.Fatalf("系统b的维护窗口 = %+v, 期望 %s", active["b"], window.ID)
+	}
+
+	if err := tags.Remove("b", &models.NodeTagRequest{Type: "v2ray", ID: 12}, change); err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.Remove("b", &models.NodeTagRequest{Type: "v2ray", ID: 12}, change); !errors.Is(err, ErrNodeTagNotFound) {
+		t.Fatalf("删除不存在的标签: err = %v, 期望 ErrNodeTagNotFound", err)
+	}
+	if active, _ = maintenance.Active(ctx); active["b"] != nil {
+		t.Errorf("删除标签后系统b不应再处于维护中: %+v", active["b"])
+	}
+}
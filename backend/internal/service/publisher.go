@@ -0,0 +1,210 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/pkg/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 写回Redis的节点负载状态
+const (
+	NodeLoadNormal = "normal"
+	NodeLoadHigh   = "high"
+)
+
+// publisherIdleInterval 发布关闭时重新检查配置的间隔
+const publisherIdleInterval = 5 * time.Second
+
+// LoadPublisher 定期把每个节点的负载状态写回Redis，并在状态变化时通过pub/sub发布事件。
+// 节点是否为高负载与 GET /api/nodes/load-status 的结果一致
+type LoadPublisher struct {
+	systemService *SystemService
+	nodeService   *NodeService
+	redisService  *RedisService
+	currentConfig func() *config.Config
+	now           func() time.Time
+
+	mu sync.Mutex
+	// last 上次成功写入的状态，键为 tagKey(节点类型, 节点ID)
+	last map[string]*models.NodeLoadState
+}
+
+// NewLoadPublisher 创建负载状态发布器，currentConfig 返回当前生效的配置，发布配置可以热加载
+func NewLoadPublisher(systemService *SystemService, nodeService *NodeService, redisService *RedisService, currentConfig func() *config.Config) *LoadPublisher {
+	return &LoadPublisher{
+		systemService: systemService,
+		nodeService:   nodeService,
+		redisService:  redisService,
+		currentConfig: currentConfig,
+		now:           time.Now,
+	}
+}
+
+// Run 按配置的间隔发布，直到ctx取消
+func (p *LoadPublisher) Run(ctx context.Context) {
+	for {
+		cfg := p.currentConfig().Publish
+		interval := publisherIdleInterval
+		if cfg.Enabled {
+			interval = time.Duration(cfg.IntervalSeconds) * time.Second
+			if err := p.Publish(ctx, &cfg); err != nil {
+				slog.WarnContext(ctx, "发布节点负载状态失败", "op", "publish.run", logging.Err(err))
+			}
+		} else {
+			// 重新开启后所有非正常状态的节点都会重新发布一次变化事件
+			p.reset()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Publish 计算所有节点的负载状态，写入Redis并发布与上次相比的变化。
+// 写入失败时不更新上次的状态，变化事件在下次发布时重试
+func (p *LoadPublisher) Publish(ctx context.Context, cfg *config.PublishConfig) error {
+	if !p.redisService.Available() {
+		return ErrRedisUnavailable
+	}
+
+	systems, err := p.systemService.GetSystemsWithLoadStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("获取系统负载状态失败: %w", err)
+	}
+	listed, err := p.nodeService.GetHighLoadNodes(ctx, systems)
+	if err != nil {
+		return fmt.Errorf("获取高负载节点失败: %w", err)
+	}
+	nodes, err := p.redisService.GetAllNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("获取节点列表失败: %w", err)
+	}
+
+	now := p.now()
+	states := nodeLoadStates(nodes, listed, now)
+	// 快照数据照常发布，由插件决定是否采用
+	if stale, asOf := staleLoadData(systems); stale {
+		for _, state := range states {
+			state.Stale, state.AsOf = true, asOf
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := diffNodeLoad(p.last, states, now)
+	values := make(map[string][]byte, len(states))
+	for _, state := range states {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("序列化节点负载状态失败: %w", err)
+		}
+		values[nodeLoadKey(cfg.KeyTemplate, state.Type, state.ID)] = data
+	}
+	messages := make([][]byte, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("序列化节点负载事件失败: %w", err)
+		}
+		messages = append(messages, data)
+	}
+
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	if err := p.redisService.WriteNodeLoad(ctx, values, ttl, cfg.Channel, messages); err != nil {
+		return err
+	}
+	p.last = states
+
+	for _, event := range events {
+		slog.InfoContext(ctx, "节点负载状态变化", "op", "publish.transition",
+			"node_type", event.Type, "node_id", event.ID, "node", event.Name,
+			"from", event.From, "to", event.To, "reason", event.Reason)
+	}
+	slog.DebugContext(ctx, "已发布节点负载状态", "op", "publish.run", "nodes", len(states), "events", len(events))
+	return nil
+}
+
+// reset 清空上次的状态
+func (p *LoadPublisher) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = nil
+}
+
+// nodeLoadStates 合并全部节点和高负载节点列表，未列出的节点为正常
+func nodeLoadStates(nodes []models.V2boardNode, listed []models.HighLoadNode, now time.Time) map[string]*models.NodeLoadState {
+	states := make(map[string]*models.NodeLoadState, len(nodes))
+	for _, node := range nodes {
+		states[tagKey(node.Type, node.ID)] = &models.NodeLoadState{
+			Type:      node.Type,
+			ID:        node.ID,
+			Name:      node.Name,
+			Status:    NodeLoadNormal,
+			Online:    node.Online,
+			UpdatedAt: now,
+		}
+	}
+	for _, node := range listed {
+		states[tagKey(node.Type, node.ID)] = &models.NodeLoadState{
+			Type:      node.Type,
+			ID:        node.ID,
+			Name:      node.Name,
+			Status:    NodeLoadHigh,
+			Reason:    node.Reason,
+			SystemID:  node.SystemID,
+			Online:    node.Online,
+			UpdatedAt: now,
+		}
+	}
+	return states
+}
+
+// diffNodeLoad 返回状态或原因发生变化的节点，按节点类型和ID排序。
+// 没有上次状态的节点视为正常（与Redis中没有对应key时插件看到的一致），因此新出现的正常节点不产生事件
+func diffNodeLoad(last, states map[string]*models.NodeLoadState, now time.Time) []models.NodeLoadEvent {
+	events := make([]models.NodeLoadEvent, 0)
+	for key, state := range states {
+		from, fromReason := NodeLoadNormal, ""
+		if previous := last[key]; previous != nil {
+			from, fromReason = previous.Status, previous.Reason
+		}
+		if from == state.Status && fromReason == state.Reason {
+			continue
+		}
+		events = append(events, models.NodeLoadEvent{
+			Type:     state.Type,
+			ID:       state.ID,
+			Name:     state.Name,
+			From:     from,
+			To:       state.Status,
+			Reason:   state.Reason,
+			SystemID: state.SystemID,
+			At:       now,
+		})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		return events[i].ID < events[j].ID
+	})
+	return events
+}
+
+// nodeLoadKey 按模板生成节点状态的key
+func nodeLoadKey(template, nodeType string, nodeID int) string {
+	return strings.NewReplacer("{type}", nodeType, "{id}", strconv.Itoa(nodeID)).Replace(template)
+}
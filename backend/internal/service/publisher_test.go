@@ -0,0 +1,77 @@
+package service
+
+import (
+	"backend/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestNodeLoadKey(t *testing.T) {
+	if got := nodeLoadKey("beszel:node_load:{type}:{id}", "v2ray", 12); got != "beszel:node_load:v2ray:12" {
+		t.Errorf("key = %s", got)
+	}
+	if got := nodeLoadKey("xboard_{id}_{type}_load", "ss", 3); got != "xboard_3_ss_load" {
+		t.Errorf("key = %s", got)
+	}
+}
+
+func TestNodeLoadTransitions(t *testing.T) {
+	now := time.Now()
+	nodes := []models.V2boardNode{
+		{Type: "v2ray", ID: 1, Name: "hk-01", Online: 10},
+		{Type: "v2ray", ID: 2, Name: "hk-02", Online: 20},
+		{Type: "ss", ID: 1, Name: "jp-01", Online: 5},
+	}
+
+	// 首次发布：正常节点不产生事件，高负载节点产生 normal -> high
+	first := nodeLoadStates(nodes, []models.HighLoadNode{
+		{Type: "v2ray", ID: 2, Name: "hk-02", Online: 20, SystemID: "a", Reason: HighLoadReasonHigh},
+	}, now)
+	if len(first) != 3 || first["v2ray:1"].Status != NodeLoadNormal || first["v2ray:2"].SystemID != "a" {
+		t.Fatalf("节点状态: %+v", first)
+	}
+	events := diffNodeLoad(nil, first, now)
+	if len(events) != 1 || events[0].ID != 2 || events[0].From != NodeLoadNormal || events[0].To != NodeLoadHigh {
+		t.Fatalf("首次发布的事件: %+v", events)
+	}
+
+	// 状态不变时不产生事件
+	if events := diffNodeLoad(first, nodeLoadStates(nodes, []models.HighLoadNode{
+		{Type: "v2ray", ID: 2, Name: "hk-02", SystemID: "a", Reason: HighLoadReasonHigh},
+	}, now), now); len(events) != 0 {
+		t.Errorf("状态不变时的事件: %+v", events)
+	}
+
+	// 恢复正常、原因变化和新的高负载节点，按类型和ID排序
+	second := nodeLoadStates(nodes, []models.HighLoadNode{
+		{Type: "v2ray", ID: 1, Name: "hk-01", SystemID: "b", Reason: HighLoadReasonOffline},
+		{Type: "ss", ID: 1, Name: "jp-01", Reason: HighLoadReasonDrained},
+	}, now)
+	events = diffNodeLoad(first, second, now)
+	want := []struct {
+		nodeType string
+		id       int
+		from, to string
+	}{
+		{"ss", 1, NodeLoadNormal, NodeLoadHigh},
+		{"v2ray", 1, NodeLoadNormal, NodeLoadHigh},
+		{"v2ray", 2, NodeLoadHigh, NodeLoadNormal},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("事件: %+v", events)
+	}
+	for i, w := range want {
+		if e := events[i]; e.Type != w.nodeType || e.ID != w.id || e.From != w.from || e.To != w.to {
+			t.Errorf("事件%d = %+v, 期望 %+v", i, e, w)
+		}
+	}
+
+	third := nodeLoadStates(nodes, []models.HighLoadNode{
+		{Type: "v2ray", ID: 1, Name: "hk-01", SystemID: "b", Reason: HighLoadReasonDrained},
+		{Type: "ss", ID: 1, Name: "jp-01", Reason: HighLoadReasonDrained},
+	}, now)
+	events = diffNodeLoad(second, third, now)
+	if len(events) != 1 || events[0].ID != 1 || events[0].From != NodeLoadHigh || events[0].Reason != HighLoadReasonDrained {
+		t.Errorf("原因变化的事件: %+v", events)
+	}
+}
@@ -2,76 +2,174 @@ package service
 
 import (
 	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/metrics"
+	"backend/internal/tracing"
 	"backend/pkg/models"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ErrRedisUnavailable Redis未配置或连接失败
+var ErrRedisUnavailable = errors.New("Redis未连接")
+
+// redisCloseDelay 重连后延迟关闭旧连接，让仍在使用旧连接的请求完成
+const redisCloseDelay = 30 * time.Second
+
 // RedisService Redis服务
 type RedisService struct {
+	mu     sync.RWMutex
 	client *redis.Client
+	addr   string
 	ctx    context.Context
 }
 
-// NewRedisService 创建Redis服务
-func NewRedisService(cfg *config.Config) (*RedisService, error) {
-	addr := fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port)
+// NewRedisService 创建未连接的Redis服务，通过 Connect 建立连接
+func NewRedisService() *RedisService {
+	return &RedisService{ctx: context.Background()}
+}
+
+// Connect 按配置建立新连接并立即替换旧连接，失败时保留旧连接
+func (r *RedisService) Connect(cfg *config.RedisConfig) error {
+	swap, err := r.Prepare(cfg)
+	if err != nil {
+		return err
+	}
+	swap.Commit()
+	return nil
+}
+
+// Prepare 按配置建立并验证新连接，但暂不替换，由调用方决定 Commit 或 Abort。
+// Host为空表示不使用Redis，Commit后断开现有连接。
+func (r *RedisService) Prepare(cfg *config.RedisConfig) (*PendingSwap, error) {
+	if cfg.Host == "" {
+		return &PendingSwap{commit: func() {
+			r.swap(nil, "")
+			slog.Info("未配置Redis，已断开Redis连接", "op", "redis.connect")
+		}}, nil
+	}
+
+	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
-		Password: cfg.Redis.Password, // 支持空密码
-		DB:       cfg.Redis.DB,
+		Password: cfg.Password, // 支持空密码
+		DB:       cfg.DB,
 	})
-
-	ctx := context.Background()
+	rdb.AddHook(metrics.RedisHook{})
+	// 每条命令（包括SCAN的每一页和逐个GET）记录为请求span的子span
+	if err := redisotel.InstrumentTracing(rdb, redisotel.WithDBStatement(false)); err != nil {
+		slog.Warn("Redis链路追踪初始化失败", "op", "redis.connect", logging.Err(err))
+	}
 
 	// 测试Redis连接
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		log.Printf("❌ Redis连接失败 [%s] 数据库:%d - %v", addr, cfg.Redis.DB, err)
+	ctx, cancel := context.WithTimeout(r.ctx, 5*time.Second)
+	defer cancel()
+	if _, err := rdb.Ping(ctx).Result(); err != nil {
+		slog.Error("Redis连接失败", "op", "redis.connect", "addr", addr, "db", cfg.DB, logging.Err(err))
 		rdb.Close()
 		return nil, fmt.Errorf("Redis连接失败: %w", err)
 	}
 
-	log.Printf("✅ Redis连接成功 [%s] 数据库:%d", addr, cfg.Redis.DB)
-
-	return &RedisService{
-		client: rdb,
-		ctx:    ctx,
+	slog.Info("Redis连接成功", "op", "redis.connect", "addr", addr, "db", cfg.DB)
+	return &PendingSwap{
+		commit: func() { r.swap(rdb, addr) },
+		abort:  func() { rdb.Close() },
 	}, nil
 }
 
+// swap 替换当前连接，旧连接延迟关闭
+func (r *RedisService) swap(rdb *redis.Client, addr string) {
+	r.mu.Lock()
+	old := r.client
+	r.client = rdb
+	r.addr = addr
+	r.mu.Unlock()
+
+	if old != nil {
+		time.AfterFunc(redisCloseDelay, func() { old.Close() })
+	}
+}
+
+// conn 返回当前连接
+func (r *RedisService) conn() (*redis.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.client == nil {
+		return nil, ErrRedisUnavailable
+	}
+	return r.client, nil
+}
+
+// Available 是否已连接Redis
+func (r *RedisService) Available() bool {
+	_, err := r.conn()
+	return err == nil
+}
+
+// Ping 检查当前连接，未连接时返回 ErrRedisUnavailable
+func (r *RedisService) Ping(ctx context.Context) error {
+	client, err := r.conn()
+	if err != nil {
+		return err
+	}
+	return client.Ping(ctx).Err()
+}
+
 // Close 关闭Redis连接
 func (r *RedisService) Close() error {
-	return r.client.Close()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		return nil
+	}
+	err := r.client.Close()
+	r.client = nil
+	return err
 }
 
 // GetNodesByAlias 根据别名模糊匹配获取节点信息
-func (r *RedisService) GetNodesByAlias(alias string) ([]models.V2boardNode, error) {
-	var nodes []models.V2boardNode
+func (r *RedisService) GetNodesByAlias(ctx context.Context, alias string) (nodes []models.V2boardNode, err error) {
+	ctx, span := tracing.Start(ctx, "RedisService.GetNodesByAlias", attribute.String("node.alias", alias))
+	keys := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("redis.keys", keys), attribute.Int("nodes.count", len(nodes)))
+		tracing.End(span, err)
+	}()
+
+	client, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
 
 	// 使用SCAN命令获取所有v2board_database_AGENT_*的key
 	pattern := "v2board_database_AGENT_*"
-	iter := r.client.Scan(r.ctx, 0, pattern, 0).Iterator()
+	iter := client.Scan(ctx, 0, pattern, 0).Iterator()
 
-	for iter.Next(r.ctx) {
+	for iter.Next(ctx) {
 		key := iter.Val()
+		keys++
 		
 		// 获取key的值
-		val, err := r.client.Get(r.ctx, key).Result()
+		val, err := client.Get(ctx, key).Result()
 		if err != nil {
-			log.Printf("获取Redis key %s 失败: %v", key, err)
+			slog.WarnContext(ctx, "获取Redis key失败", "op", "redis.scan", "key", key, logging.Err(err))
 			continue
 		}
 
 		// 解析JSON
 		var node models.V2boardNode
 		if err := json.Unmarshal([]byte(val), &node); err != nil {
-			log.Printf("解析Redis key %s 的JSON失败: %v", key, err)
+			slog.WarnContext(ctx, "解析Redis key的JSON失败", "op", "redis.scan", "key", key, logging.Err(err))
 			continue
 		}
 
@@ -89,24 +187,33 @@ func (r *RedisService) GetNodesByAlias(alias string) ([]models.V2boardNode, erro
 }
 
 // GetAllNodes 获取所有节点信息（用于调试）
-func (r *RedisService) GetAllNodes() ([]models.V2boardNode, error) {
-	var nodes []models.V2boardNode
+func (r *RedisService) GetAllNodes(ctx context.Context) (nodes []models.V2boardNode, err error) {
+	ctx, span := tracing.Start(ctx, "RedisService.GetAllNodes")
+	defer func() {
+		span.SetAttributes(attribute.Int("nodes.count", len(nodes)))
+		tracing.End(span, err)
+	}()
+
+	client, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
 
 	pattern := "v2board_database_AGENT_*"
-	iter := r.client.Scan(r.ctx, 0, pattern, 0).Iterator()
+	iter := client.Scan(ctx, 0, pattern, 0).Iterator()
 
-	for iter.Next(r.ctx) {
+	for iter.Next(ctx) {
 		key := iter.Val()
 		
-		val, err := r.client.Get(r.ctx, key).Result()
+		val, err := client.Get(ctx, key).Result()
 		if err != nil {
-			log.Printf("获取Redis key %s 失败: %v", key, err)
+			slog.WarnContext(ctx, "获取Redis key失败", "op", "redis.scan", "key", key, logging.Err(err))
 			continue
 		}
 
 		var node models.V2boardNode
 		if err := json.Unmarshal([]byte(val), &node); err != nil {
-			log.Printf("解析Redis key %s 的JSON失败: %v", key, err)
+			slog.WarnContext(ctx, "解析Redis key的JSON失败", "op", "redis.scan", "key", key, logging.Err(err))
 			continue
 		}
 
@@ -118,4 +225,33 @@ func (r *RedisService) GetAllNodes() ([]models.V2boardNode, error) {
 	}
 
 	return nodes, nil
-}
\ No newline at end of file
+}
+
+// WriteNodeLoad 写入节点负载状态并向channel发布状态变化，所有命令在一个pipeline中发送。
+// channel为空时只写入状态
+func (r *RedisService) WriteNodeLoad(ctx context.Context, values map[string][]byte, ttl time.Duration, channel string, events [][]byte) (err error) {
+	ctx, span := tracing.Start(ctx, "RedisService.WriteNodeLoad",
+		attribute.Int("redis.keys", len(values)), attribute.Int("events.count", len(events)))
+	defer func() { tracing.End(span, err) }()
+
+	client, err := r.conn()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			pipe.Set(ctx, key, value, ttl)
+		}
+		if channel != "" {
+			for _, event := range events {
+				pipe.Publish(ctx, channel, event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入节点负载状态失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+// PendingSwap 已准备好但尚未生效的客户端替换。
+// 配置重载时先准备所有新客户端，全部成功后再统一 Commit，任一失败则 Abort 已准备的部分。
+type PendingSwap struct {
+	commit func()
+	abort  func()
+}
+
+// Commit 使新客户端生效
+func (p *PendingSwap) Commit() {
+	if p != nil && p.commit != nil {
+		p.commit()
+	}
+}
+
+// Abort 放弃新客户端并释放其资源
+func (p *PendingSwap) Abort() {
+	if p != nil && p.abort != nil {
+		p.abort()
+	}
+}
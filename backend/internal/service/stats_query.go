@@ -0,0 +1,335 @@
+package service
+
+import (
+	"backend/internal/pocketbase"
+	"backend/pkg/models"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// statsTypes Beszel记录的统计粒度及各自的保留时长，用于自动选择粒度
+var statsTypes = []struct {
+	name      string
+	retention time.Duration
+}{
+	{"1m", time.Hour},
+	{"10m", 12 * time.Hour},
+	{"20m", 24 * time.Hour},
+	{"120m", 7 * 24 * time.Hour},
+	{"480m", 30 * 24 * time.Hour},
+}
+
+// ValidStatsType 判断是否为Beszel支持的统计粒度
+func ValidStatsType(statsType string) bool {
+	for _, t := range statsTypes {
+		if t.name == statsType {
+			return true
+		}
+	}
+	return false
+}
+
+// pickStatsType 按查询跨度选择仍保留完整数据的最细粒度
+func pickStatsType(from, to time.Time) string {
+	span := to.Sub(from)
+	for _, t := range statsTypes {
+		if span <= t.retention {
+			return t.name
+		}
+	}
+	return statsTypes[len(statsTypes)-1].name
+}
+
+// GetSystemStatsRange 按时间范围获取系统统计数据，可选降采样
+func (s *SystemService) GetSystemStatsRange(ctx context.Context, query *models.StatsRangeQuery) (*models.StatsRangeResult, error) {
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-24 * time.Hour)
+	}
+	if query.From.After(query.To) {
+		return nil, fmt.Errorf("起始时间不能晚于结束时间")
+	}
+	if query.Type == "" {
+		query.Type = pickStatsType(query.From, query.To)
+	} else if !ValidStatsType(query.Type) {
+		return nil, fmt.Errorf("不支持的统计类型: %s", query.Type)
+	}
+
+	if err := ValidateStatFields(query.Fields); err != nil {
+		return nil, err
+	}
+
+	pbStats, truncated, err := s.client().ListSystemStats(ctx, pocketbase.StatsQuery{
+		SystemID: query.SystemID,
+		Type:     query.Type,
+		From:     query.From,
+		To:       query.To,
+		Fields:   statSources(query.Fields),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取系统统计数据失败: %w", err)
+	}
+
+	stats := make([]*models.SystemStat, 0, len(pbStats))
+	for i := range pbStats {
+		stats = append(stats, toSystemStat(&pbStats[i]))
+	}
+
+	return &models.StatsRangeResult{
+		Type:      query.Type,
+		From:      query.From,
+		To:        query.To,
+		RawCount:  len(stats),
+		Truncated: truncated,
+		Stats:     downsampleStats(stats, query.MaxPoints),
+	}, nil
+}
+
+// downsampleStats 将按时间升序的记录分成至多maxPoints组，每组取平均值，时间取组内最后一条
+func downsampleStats(stats []*models.SystemStat, maxPoints int) []*models.SystemStat {
+	if maxPoints <= 0 || len(stats) <= maxPoints {
+		return stats
+	}
+
+	size := (len(stats) + maxPoints - 1) / maxPoints
+	result := make([]*models.SystemStat, 0, maxPoints)
+	for start := 0; start < len(stats); start += size {
+		end := start + size
+		if end > len(stats) {
+			end = len(stats)
+		}
+		result = append(result, averageStatGroup(stats[start:end]))
+	}
+	return result
+}
+
+// averageStatGroup 计算一组记录的平均值；map类字段按key分别平均，缺失的key不参与计数
+func averageStatGroup(group []*models.SystemStat) *models.SystemStat {
+	last := group[len(group)-1]
+	avg := &models.SystemStat{
+		ID:        last.ID,
+		SystemID:  last.SystemID,
+		Type:      last.Type,
+		CreatedAt: last.CreatedAt,
+	}
+
+	n := float64(len(group))
+	temps := newFloatAverager()
+	extraFs := newFsAverager()
+	gpus := newGPUAverager()
+	nics := newInterfaceAverager()
+
+	for _, st := range group {
+		avg.CPU += st.CPU / n
+		avg.Mem += st.Mem / n
+		avg.MemUsed += st.MemUsed / n
+		avg.MemPct += st.MemPct / n
+		avg.NetSent += st.NetSent / n
+		avg.NetRecv += st.NetRecv / n
+		avg.MemBuffCache += st.MemBuffCache / n
+		avg.Swap += st.Swap / n
+		avg.SwapUsed += st.SwapUsed / n
+		avg.SwapPct += st.SwapPct / n
+		avg.DiskTotal += st.DiskTotal / n
+		avg.DiskUsed += st.DiskUsed / n
+		avg.DiskPct += st.DiskPct / n
+		avg.DiskRead += st.DiskRead / n
+		avg.DiskWrite += st.DiskWrite / n
+		avg.LoadAvg1 += st.LoadAvg1 / n
+		avg.LoadAvg5 += st.LoadAvg5 / n
+		avg.LoadAvg15 += st.LoadAvg15 / n
+
+		for k, v := range st.Temperatures {
+			temps.add(k, v)
+		}
+		for k, v := range st.ExtraFs {
+			extraFs.add(k, v)
+		}
+		for k, v := range st.GPUs {
+			gpus.add(k, v)
+		}
+		for k, v := range st.Interfaces {
+			nics.add(k, v)
+		}
+	}
+
+	avg.Temperatures = temps.result()
+	avg.ExtraFs = extraFs.result()
+	avg.GPUs = gpus.result()
+	avg.Interfaces = nics.result()
+	return avg
+}
+
+// mapAverager 按key分别求平均，缺失的key不参与计数
+type mapAverager[T any] struct {
+	sums   map[string]T
+	counts map[string]int
+	sum    func(total, value T) T
+	scale  func(total T, factor float64) T
+}
+
+func newMapAverager[T any](sum func(total, value T) T, scale func(total T, factor float64) T) *mapAverager[T] {
+	return &mapAverager[T]{sums: make(map[string]T), counts: make(map[string]int), sum: sum, scale: scale}
+}
+
+func (m *mapAverager[T]) add(key string, value T) {
+	m.sums[key] = m.sum(m.sums[key], value)
+	m.counts[key]++
+}
+
+func (m *mapAverager[T]) result() map[string]T {
+	if len(m.sums) == 0 {
+		return nil
+	}
+	result := make(map[string]T, len(m.sums))
+	for key, total := range m.sums {
+		result[key] = m.scale(total, 1/float64(m.counts[key]))
+	}
+	return result
+}
+
+func newFloatAverager() *mapAverager[float64] {
+	return newMapAverager(
+		func(total, value float64) float64 { return total + value },
+		func(total, factor float64) float64 { return total * factor },
+	)
+}
+
+func newFsAverager() *mapAverager[models.FsStat] {
+	return newMapAverager(
+		func(total, value models.FsStat) models.FsStat {
+			return models.FsStat{
+				DiskTotal: total.DiskTotal + value.DiskTotal,
+				DiskUsed:  total.DiskUsed + value.DiskUsed,
+				DiskPct:   total.DiskPct + value.DiskPct,
+				DiskRead:  total.DiskRead + value.DiskRead,
+				DiskWrite: total.DiskWrite + value.DiskWrite,
+			}
+		},
+		func(total models.FsStat, factor float64) models.FsStat {
+			return models.FsStat{
+				DiskTotal: total.DiskTotal * factor,
+				DiskUsed:  total.DiskUsed * factor,
+				DiskPct:   total.DiskPct * factor,
+				DiskRead:  total.DiskRead * factor,
+				DiskWrite: total.DiskWrite * factor,
+			}
+		},
+	)
+}
+
+// newGPUAverager GPU名称取最后一条记录的值
+func newGPUAverager() *mapAverager[models.GPUStat] {
+	return newMapAverager(
+		func(total, value models.GPUStat) models.GPUStat {
+			return models.GPUStat{
+				Name:        value.Name,
+				Usage:       total.Usage + value.Usage,
+				MemoryUsed:  total.MemoryUsed + value.MemoryUsed,
+				MemoryTotal: total.MemoryTotal + value.MemoryTotal,
+				Power:       total.Power + value.Power,
+			}
+		},
+		func(total models.GPUStat, factor float64) models.GPUStat {
+			return models.GPUStat{
+				Name:        total.Name,
+				Usage:       total.Usage * factor,
+				MemoryUsed:  total.MemoryUsed * factor,
+				MemoryTotal: total.MemoryTotal * factor,
+				Power:       total.Power * factor,
+			}
+		},
+	)
+}
+
+func newInterfaceAverager() *mapAverager[models.NetInterfaceStat] {
+	return newMapAverager(
+		func(total, value models.NetInterfaceStat) models.NetInterfaceStat {
+			return models.NetInterfaceStat{NetSent: total.NetSent + value.NetSent, NetRecv: total.NetRecv + value.NetRecv}
+		},
+		func(total models.NetInterfaceStat, factor float64) models.NetInterfaceStat {
+			return models.NetInterfaceStat{NetSent: total.NetSent * factor, NetRecv: total.NetRecv * factor}
+		},
+	)
+}
+
+// statField 可以通过fields参数选择的字段：计算该字段需要的PocketBase stats key，以及从SystemStat取值的方法。
+// id、system_id、type、created_at 来自记录本身，始终从PocketBase获取
+type statField struct {
+	sources []string
+	value   func(stat *models.SystemStat) interface{}
+}
+
+// statFields SystemStat可选择返回的字段（JSON名）
+var statFields = map[string]statField{
+	"id":             {nil, func(s *models.SystemStat) interface{} { return s.ID }},
+	"system_id":      {nil, func(s *models.SystemStat) interface{} { return s.SystemID }},
+	"type":           {nil, func(s *models.SystemStat) interface{} { return s.Type }},
+	"created_at":     {nil, func(s *models.SystemStat) interface{} { return s.CreatedAt }},
+	"cpu":            {[]string{"cpu"}, func(s *models.SystemStat) interface{} { return s.CPU }},
+	"mem":            {[]string{"m"}, func(s *models.SystemStat) interface{} { return s.Mem }},
+	"mem_used":       {[]string{"mu"}, func(s *models.SystemStat) interface{} { return s.MemUsed }},
+	"mem_pct":        {[]string{"mp", "m", "mu"}, func(s *models.SystemStat) interface{} { return s.MemPct }},
+	"net_sent":       {[]string{"ns"}, func(s *models.SystemStat) interface{} { return s.NetSent }},
+	"net_recv":       {[]string{"nr"}, func(s *models.SystemStat) interface{} { return s.NetRecv }},
+	"mem_buff_cache": {[]string{"mb"}, func(s *models.SystemStat) interface{} { return s.MemBuffCache }},
+	"swap":           {[]string{"s"}, func(s *models.SystemStat) interface{} { return s.Swap }},
+	"swap_used":      {[]string{"su"}, func(s *models.SystemStat) interface{} { return s.SwapUsed }},
+	"swap_pct":       {[]string{"s", "su"}, func(s *models.SystemStat) interface{} { return s.SwapPct }},
+	"disk_total":     {[]string{"d"}, func(s *models.SystemStat) interface{} { return s.DiskTotal }},
+	"disk_used":      {[]string{"du"}, func(s *models.SystemStat) interface{} { return s.DiskUsed }},
+	"disk_pct":       {[]string{"dp", "d", "du"}, func(s *models.SystemStat) interface{} { return s.DiskPct }},
+	"disk_read":      {[]string{"dr"}, func(s *models.SystemStat) interface{} { return s.DiskRead }},
+	"disk_write":     {[]string{"dw"}, func(s *models.SystemStat) interface{} { return s.DiskWrite }},
+	"load_avg_1":     {[]string{"la", "l1"}, func(s *models.SystemStat) interface{} { return s.LoadAvg1 }},
+	"load_avg_5":     {[]string{"la", "l5"}, func(s *models.SystemStat) interface{} { return s.LoadAvg5 }},
+	"load_avg_15":    {[]string{"la", "l15"}, func(s *models.SystemStat) interface{} { return s.LoadAvg15 }},
+	"temperatures":   {[]string{"t"}, func(s *models.SystemStat) interface{} { return s.Temperatures }},
+	"extra_fs":       {[]string{"efs"}, func(s *models.SystemStat) interface{} { return s.ExtraFs }},
+	"gpus":           {[]string{"g"}, func(s *models.SystemStat) interface{} { return s.GPUs }},
+	"interfaces":     {[]string{"ni"}, func(s *models.SystemStat) interface{} { return s.Interfaces }},
+}
+
+// ValidateStatFields 检查字段名是否为SystemStat的JSON字段
+func ValidateStatFields(fields []string) error {
+	for _, field := range fields {
+		if _, ok := statFields[field]; !ok {
+			return fmt.Errorf("未知的字段: %s", field)
+		}
+	}
+	return nil
+}
+
+// statSources 计算所选字段需要向PocketBase请求的stats key，去重并保持顺序
+func statSources(fields []string) []string {
+	var sources []string
+	for _, field := range fields {
+		for _, source := range statFields[field].sources {
+			if !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources
+}
+
+// SelectStatFields 只保留指定字段，created_at始终保留；字段名无效时返回错误
+func SelectStatFields(stats []*models.SystemStat, fields []string) ([]map[string]interface{}, error) {
+	if err := ValidateStatFields(fields); err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		selected := map[string]interface{}{"created_at": stat.CreatedAt}
+		for _, field := range fields {
+			selected[field] = statFields[field].value(stat)
+		}
+		result = append(result, selected)
+	}
+	return result, nil
+}
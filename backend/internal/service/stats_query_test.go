@@ -0,0 +1,103 @@
+package service
+
+import (
+	"backend/pkg/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPickStatsType(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		span time.Duration
+		want string
+	}{
+		{30 * time.Minute, "1m"},
+		{time.Hour, "1m"},
+		{6 * time.Hour, "10m"},
+		{18 * time.Hour, "20m"},
+		{3 * 24 * time.Hour, "120m"},
+		{30 * 24 * time.Hour, "480m"},
+		{90 * 24 * time.Hour, "480m"},
+	}
+	for _, tc := range cases {
+		if got := pickStatsType(now.Add(-tc.span), now); got != tc.want {
+			t.Errorf("pickStatsType(%s) = %s, want %s", tc.span, got, tc.want)
+		}
+	}
+}
+
+func TestDownsampleStats(t *testing.T) {
+	base := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	var stats []*models.SystemStat
+	for i := 0; i < 10; i++ {
+		stat := &models.SystemStat{
+			CPU:          float64(i),
+			CreatedAt:    base.Add(time.Duration(i) * time.Minute),
+			Temperatures: map[string]float64{"cpu": float64(i * 10)},
+			GPUs:         map[string]models.GPUStat{"0": {Name: "gpu", Usage: float64(i)}},
+		}
+		// 只有部分记录包含nvme传感器，平均值只按出现次数计算
+		if i%2 == 0 {
+			stat.Temperatures["nvme"] = 40
+		}
+		stats = append(stats, stat)
+	}
+
+	if got := downsampleStats(stats, 0); len(got) != 10 {
+		t.Fatalf("maxPoints=0 should not downsample, got %d points", len(got))
+	}
+	if got := downsampleStats(stats, 20); len(got) != 10 {
+		t.Fatalf("maxPoints above length should not downsample, got %d points", len(got))
+	}
+
+	got := downsampleStats(stats, 4)
+	// 10条记录按每组3条分成4组：[0,1,2] [3,4,5] [6,7,8] [9]
+	if len(got) != 4 {
+		t.Fatalf("expected 4 points, got %d", len(got))
+	}
+	if got[0].CPU != 1 || got[3].CPU != 9 {
+		t.Errorf("unexpected cpu averages: %v, %v", got[0].CPU, got[3].CPU)
+	}
+	if !got[0].CreatedAt.Equal(stats[2].CreatedAt) {
+		t.Errorf("bucket should take the last timestamp, got %v", got[0].CreatedAt)
+	}
+	if got[0].Temperatures["cpu"] != 10 || got[0].Temperatures["nvme"] != 40 {
+		t.Errorf("unexpected temperature averages: %v", got[0].Temperatures)
+	}
+	if gpu := got[1].GPUs["0"]; gpu.Usage != 4 || gpu.Name != "gpu" {
+		t.Errorf("unexpected gpu average: %+v", gpu)
+	}
+	if got[3].ExtraFs != nil {
+		t.Errorf("expected nil extra fs when no record has it, got %v", got[3].ExtraFs)
+	}
+}
+
+func TestSelectStatFields(t *testing.T) {
+	stats := []*models.SystemStat{{ID: "a", CPU: 12.5, MemPct: 40, CreatedAt: time.Now()}}
+
+	selected, err := SelectStatFields(stats, []string{"cpu", "mem_pct"})
+	if err != nil {
+		t.Fatalf("SelectStatFields failed: %v", err)
+	}
+	if len(selected[0]) != 3 {
+		t.Errorf("expected created_at, cpu and mem_pct only, got %v", selected[0])
+	}
+	if selected[0]["cpu"] != 12.5 {
+		t.Errorf("unexpected cpu value: %v", selected[0]["cpu"])
+	}
+
+	if _, err := SelectStatFields(stats, []string{"cpu", "bogus"}); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestStatSources(t *testing.T) {
+	// 派生字段需要计算所用的原始字段，重复的key只请求一次
+	got := statSources([]string{"cpu", "mem_pct", "mem_used", "created_at", "load_avg_1"})
+	want := []string{"cpu", "mp", "m", "mu", "la", "l1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("statSources = %v, want %v", got, want)
+	}
+}
@@ -2,96 +2,290 @@ package service
 
 import (
 	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/metrics"
 	"backend/internal/pocketbase"
+	"backend/internal/tracing"
 	"backend/pkg/models"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// minTokenRefreshInterval 两次后台token刷新之间的最小间隔
+const minTokenRefreshInterval = time.Minute
+
 // SystemService 系统服务
 type SystemService struct {
+	// pbClient 配置重载时整体替换，读取请使用 client()
+	pbMu             sync.RWMutex
 	pbClient         *pocketbase.Client
 	config           *config.Config
 	thresholdService *ThresholdService
+	maintenance      *MaintenanceService
+	drains           *DrainService
 	nodeService      *NodeService
+	anomalies        *AnomalyDetector
+
+	// 最近一次成功从PocketBase获取的数据，PocketBase不可用时作为降级数据返回
+	snapshotMu      sync.RWMutex
+	systemsSnapshot []*models.System
+	snapshotAt      time.Time
+	statsSnapshot   map[string]*models.AverageStats
 }
 
 // NewSystemService 创建系统服务
 func NewSystemService(cfg *config.Config) *SystemService {
-	client := pocketbase.NewClient(cfg.PocketBase.BaseURL)
-	
-	// 登录认证
-	if err := client.Login(cfg.PocketBase.Email, cfg.PocketBase.Password); err != nil {
-		log.Printf("PocketBase 登录失败: %v", err)
-	} else {
-		log.Printf("PocketBase 登录成功，连接到: %s", cfg.PocketBase.BaseURL)
+	client := newPocketBaseClient(&cfg.PocketBase)
+	if err := authenticate(client, &cfg.PocketBase); err != nil {
+		slog.Error("PocketBase 登录失败", "op", "pocketbase.login", "base_url", cfg.PocketBase.BaseURL, logging.Err(err))
 	}
+	SetThresholdDefaults(cfg.Thresholds)
 	
 	service := &SystemService{
 		pbClient:         client,
 		config:           cfg,
 		thresholdService: NewThresholdService(),
+		maintenance:      NewMaintenanceService(),
+		drains:           NewDrainService(),
+		statsSnapshot:    make(map[string]*models.AverageStats),
 	}
 	
-	// 启动token刷新定时器（每12天刷新一次）
+	// 启动token刷新定时器（按token的实际过期时间提前刷新）
 	go service.startTokenRefreshTimer()
 	
 	return service
 }
 
+// newPocketBaseClient 按配置创建PocketBase客户端（超时、重试、熔断）
+func newPocketBaseClient(cfg *config.PocketBaseConfig) *pocketbase.Client {
+	client := pocketbase.NewClient(cfg.BaseURL)
+	if cfg.AuthCollection != "" {
+		client.AuthCollection = cfg.AuthCollection
+	}
+	if cfg.TimeoutSeconds > 0 {
+		client.HTTPClient = &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second}
+	}
+	client.Retry = pocketbase.RetryPolicy{
+		MaxAttempts: cfg.RetryAttempts,
+		BaseDelay:   time.Duration(cfg.RetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.RetryMaxDelayMs) * time.Millisecond,
+	}
+	client.Breaker = pocketbase.NewCircuitBreaker(cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldownSeconds)*time.Second)
+	client.OnRequest = metrics.ObservePocketBaseRequest
+	return client
+}
+
+// authenticate 认证：优先使用预签发token，密码凭据作为后备
+func authenticate(client *pocketbase.Client, cfg *config.PocketBaseConfig) error {
+	if cfg.Token != "" {
+		client.SetCredentials(cfg.Email, cfg.Password)
+		client.SetAuthToken(cfg.Token)
+		slog.Info("已连接PocketBase", "op", "pocketbase.auth", "base_url", cfg.BaseURL, "auth_mode", client.AuthMode())
+		return nil
+	}
+	if err := client.Login(cfg.Email, cfg.Password); err != nil {
+		return err
+	}
+	slog.Info("已连接PocketBase", "op", "pocketbase.auth", "base_url", cfg.BaseURL, "auth_mode", client.AuthMode())
+	return nil
+}
+
+// client 返回当前使用的PocketBase客户端
+func (s *SystemService) client() *pocketbase.Client {
+	s.pbMu.RLock()
+	defer s.pbMu.RUnlock()
+	return s.pbClient
+}
+
+// PreparePocketBase 按新配置创建并认证PocketBase客户端，Commit后才替换旧客户端；
+// 认证失败时返回错误，旧客户端继续使用，正在进行的请求不受影响
+func (s *SystemService) PreparePocketBase(cfg *config.PocketBaseConfig) (*PendingSwap, error) {
+	client := newPocketBaseClient(cfg)
+	if err := authenticate(client, cfg); err != nil {
+		return nil, fmt.Errorf("新PocketBase配置认证失败: %w", err)
+	}
+
+	return &PendingSwap{commit: func() {
+		s.pbMu.Lock()
+		old := s.pbClient
+		s.pbClient = client
+		s.pbMu.Unlock()
+
+		if old != nil && old.HTTPClient != nil {
+			old.HTTPClient.CloseIdleConnections()
+		}
+	}}, nil
+}
+
 // SetNodeService 设置节点服务（避免循环依赖）
 func (s *SystemService) SetNodeService(nodeService *NodeService) {
 	s.nodeService = nodeService
 }
 
+// SetAnomalyDetector 设置异常检测器，未设置时不检测
+func (s *SystemService) SetAnomalyDetector(detector *AnomalyDetector) {
+	s.anomalies = detector
+}
+
 // startTokenRefreshTimer 启动token刷新定时器
+// 在token寿命过去80%时调用auth-refresh续期，刷新失败时客户端会自动回退到密码登录；
+// 尚未登录成功时按最小间隔重试登录。
 func (s *SystemService) startTokenRefreshTimer() {
-	ticker := time.NewTicker(12 * 24 * time.Hour) // 每12天刷新一次
-	defer ticker.Stop()
-	
-	for range ticker.C {
-		if err := s.pbClient.RefreshAuth(); err != nil {
-			log.Printf("刷新PocketBase认证失败: %v", err)
-			// 如果刷新失败，尝试重新登录
-			if err := s.pbClient.Login(s.config.PocketBase.Email, s.config.PocketBase.Password); err != nil {
-				log.Printf("重新登录PocketBase失败: %v", err)
-			} else {
-				log.Printf("成功重新登录PocketBase")
-			}
+	for {
+		wait := time.Until(s.client().NextRefreshAt())
+		if wait < minTokenRefreshInterval {
+			wait = minTokenRefreshInterval
+		}
+		time.Sleep(wait)
+
+		if err := s.client().RefreshAuth(); err != nil {
+			slog.Error("刷新PocketBase认证失败", "op", "pocketbase.refresh", logging.Err(err))
 		} else {
-			log.Printf("成功刷新PocketBase认证token")
+			slog.Info("成功刷新PocketBase认证token", "op", "pocketbase.refresh", "next_refresh_at", s.client().NextRefreshAt())
 		}
 	}
 }
 
 // GetSystems 获取所有系统
-func (s *SystemService) GetSystems() ([]*models.System, error) {
-	pbSystems, err := s.pbClient.ListSystems()
+func (s *SystemService) GetSystems(ctx context.Context) ([]*models.System, error) {
+	pbSystems, err := s.client().ListSystems(ctx)
 	if err != nil {
+		if systems, at, ok := s.snapshotSystems(); ok {
+			slog.WarnContext(ctx, "获取系统列表失败，返回快照数据", "op", "systems.list", "snapshot_at", at, logging.Err(err))
+			for _, system := range systems {
+				markStale(system, &at)
+			}
+			return systems, nil
+		}
 		return nil, fmt.Errorf("获取系统列表失败: %w", err)
 	}
 	
 	var systems []*models.System
-	for _, pbSystem := range pbSystems.Items {
+	for _, pbSystem := range pbSystems {
 		system := &models.System{
 			ID:     pbSystem.ID,
 			Name:   pbSystem.Name,
 			Host:   pbSystem.Host,
 			Port:   pbSystem.Port,
 			Status: pbSystem.Status,
+			Info:   toSystemInfo(&pbSystem.Info),
 			CreatedAt: parseTime(pbSystem.Created),
 			UpdatedAt: parseTime(pbSystem.Updated),
 		}
 		systems = append(systems, system)
 	}
 	
+	s.saveSystemsSnapshot(systems)
 	return systems, nil
 }
 
+// markStale 把系统标记为快照数据，as_of 取已有值和at中较早的一个；at为空表示没有可用的快照
+func markStale(system *models.System, at *time.Time) {
+	if at != nil && (system.AsOf == nil || at.Before(*system.AsOf)) {
+		asOf := *at
+		system.AsOf = &asOf
+	}
+	system.Stale = true
+}
+
+// staleLoadData 返回负载数据中是否有快照数据，以及其中最早的快照时间
+func staleLoadData(systems []*models.SystemWithLoadStatus) (bool, *time.Time) {
+	var stale bool
+	var asOf *time.Time
+	for _, system := range systems {
+		if !system.Stale {
+			continue
+		}
+		stale = true
+		if system.AsOf != nil && (asOf == nil || system.AsOf.Before(*asOf)) {
+			asOf = system.AsOf
+		}
+	}
+	return stale, asOf
+}
+
+// saveSystemsSnapshot 保存系统列表快照
+func (s *SystemService) saveSystemsSnapshot(systems []*models.System) {
+	snapshot := make([]*models.System, 0, len(systems))
+	for _, system := range systems {
+		copied := *system
+		snapshot = append(snapshot, &copied)
+	}
+
+	s.snapshotMu.Lock()
+	s.systemsSnapshot = snapshot
+	s.snapshotAt = time.Now()
+	s.snapshotMu.Unlock()
+}
+
+// snapshotSystems 返回系统列表快照的副本
+func (s *SystemService) snapshotSystems() ([]*models.System, time.Time, bool) {
+	s.snapshotMu.RLock()
+	defer s.snapshotMu.RUnlock()
+	if s.systemsSnapshot == nil {
+		return nil, time.Time{}, false
+	}
+
+	systems := make([]*models.System, 0, len(s.systemsSnapshot))
+	for _, system := range s.systemsSnapshot {
+		copied := *system
+		systems = append(systems, &copied)
+	}
+	return systems, s.snapshotAt, true
+}
+
+// saveStatsSnapshot 保存系统平均统计快照
+func (s *SystemService) saveStatsSnapshot(systemID string, stats *models.AverageStats) {
+	copied := *stats
+	s.snapshotMu.Lock()
+	s.statsSnapshot[systemID] = &copied
+	s.snapshotMu.Unlock()
+}
+
+// snapshotStats 返回系统平均统计快照
+func (s *SystemService) snapshotStats(systemID string) (*models.AverageStats, bool) {
+	s.snapshotMu.RLock()
+	defer s.snapshotMu.RUnlock()
+	stats, ok := s.statsSnapshot[systemID]
+	if !ok {
+		return nil, false
+	}
+	copied := *stats
+	return &copied, true
+}
+
+// PocketBaseHealth 返回PocketBase连接健康状态（熔断器与快照）
+func (s *SystemService) PocketBaseHealth() *models.UpstreamHealth {
+	status := s.client().BreakerStatus()
+	health := &models.UpstreamHealth{
+		Circuit:             status.State,
+		ConsecutiveFailures: status.ConsecutiveFailures,
+		LastError:           status.LastError,
+	}
+	if !status.OpenedAt.IsZero() {
+		health.OpenedAt = &status.OpenedAt
+	}
+
+	s.snapshotMu.RLock()
+	if !s.snapshotAt.IsZero() {
+		at := s.snapshotAt
+		health.SnapshotAt = &at
+		health.SnapshotAgeSeconds = time.Since(at).Seconds()
+	}
+	s.snapshotMu.RUnlock()
+
+	return health
+}
+
 // GetSystemSummary 获取系统摘要
-func (s *SystemService) GetSystemSummary() (*models.SystemSummary, error) {
-	systems, err := s.GetSystems()
+func (s *SystemService) GetSystemSummary(ctx context.Context) (*models.SystemSummary, error) {
+	systems, err := s.GetSystems(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -115,8 +309,8 @@ func (s *SystemService) GetSystemSummary() (*models.SystemSummary, error) {
 }
 
 // GetSystemsWithAvgStats 获取所有系统及其平均统计数据
-func (s *SystemService) GetSystemsWithAvgStats() ([]*models.SystemWithAvgStats, error) {
-	systems, err := s.GetSystems()
+func (s *SystemService) GetSystemsWithAvgStats(ctx context.Context) ([]*models.SystemWithAvgStats, error) {
+	systems, err := s.GetSystems(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -125,25 +319,37 @@ func (s *SystemService) GetSystemsWithAvgStats() ([]*models.SystemWithAvgStats,
 	
 	for _, system := range systems {
 		// 获取最近5条1分钟数据
-		pbStats, err := s.pbClient.GetSystemLoadAverage(system.ID, 5)
+		pbStats, err := s.client().GetSystemLoadAverage(ctx, system.ID, 5)
 		if err != nil {
-			log.Printf("获取系统 %s 统计数据失败: %v", system.Name, err)
-			// 如果获取失败，仍然添加系统信息，但统计数据为0
+			slog.WarnContext(ctx, "获取系统统计数据失败", "op", "load.stats", "system_id", system.ID, "system", system.Name, logging.Err(err))
 			onlineUsers := 0
-			if s.nodeService != nil {
-				if nodeInfo, err := s.nodeService.GetSystemNodeInfo(system.ID, system.Name); err == nil {
+			if s.nodeService != nil && s.nodeService.Available() {
+				if nodeInfo, err := s.nodeService.GetSystemNodeInfo(ctx, system.ID, system.Name); err == nil {
 					onlineUsers = nodeInfo.TotalOnline
 				}
 			}
 			
+			// 有快照时使用最近一次成功的统计数据，否则仍然添加系统信息，但统计数据为0、
+			// 更新时间为空，负载状态为unknown
+			avgStats, ok := s.snapshotStats(system.ID)
+			if ok {
+				markStale(system, &avgStats.LastUpdate)
+			} else {
+				avgStats = &models.AverageStats{}
+				markStale(system, nil)
+			}
+			
 			systemWithStats := &models.SystemWithAvgStats{
 				System:      *system,
-				AvgCPU:      0,
-				AvgMemPct:   0,
-				AvgNetSent:  0,
-				AvgNetRecv:  0,
+				AvgCPU:      avgStats.AvgCPU,
+				AvgMemPct:   avgStats.AvgMemPct,
+				AvgNetSent:  avgStats.AvgNetSent,
+				AvgNetRecv:  avgStats.AvgNetRecv,
+				AvgDiskPct:  avgStats.AvgDiskPct,
+				AvgSwapPct:  avgStats.AvgSwapPct,
+				LoadAvg1:    avgStats.LoadAvg1,
 				OnlineUsers: onlineUsers,
-				LastUpdate:  time.Now(),
+				LastUpdate:  avgStats.LastUpdate,
 			}
 			result = append(result, systemWithStats)
 			continue
@@ -151,11 +357,12 @@ func (s *SystemService) GetSystemsWithAvgStats() ([]*models.SystemWithAvgStats,
 		
 		// 计算平均值
 		avgStats := calculateAverageStats(pbStats.Items)
+		s.saveStatsSnapshot(system.ID, avgStats)
 		
 		// 获取在线人数
 		onlineUsers := 0
-		if s.nodeService != nil {
-			if nodeInfo, err := s.nodeService.GetSystemNodeInfo(system.ID, system.Name); err == nil {
+		if s.nodeService != nil && s.nodeService.Available() {
+			if nodeInfo, err := s.nodeService.GetSystemNodeInfo(ctx, system.ID, system.Name); err == nil {
 				onlineUsers = nodeInfo.TotalOnline
 			}
 		}
@@ -166,6 +373,9 @@ func (s *SystemService) GetSystemsWithAvgStats() ([]*models.SystemWithAvgStats,
 			AvgMemPct:   avgStats.AvgMemPct,
 			AvgNetSent:  avgStats.AvgNetSent,
 			AvgNetRecv:  avgStats.AvgNetRecv,
+			AvgDiskPct:  avgStats.AvgDiskPct,
+			AvgSwapPct:  avgStats.AvgSwapPct,
+			LoadAvg1:    avgStats.LoadAvg1,
 			OnlineUsers: onlineUsers,
 			LastUpdate:  avgStats.LastUpdate,
 		}
@@ -176,40 +386,84 @@ func (s *SystemService) GetSystemsWithAvgStats() ([]*models.SystemWithAvgStats,
 	return result, nil
 }
 
+// LoadStatusUnknown 获取统计数据失败且没有快照、无法判断负载的系统的负载状态
+const LoadStatusUnknown = "unknown"
+
 // GetSystemsWithLoadStatus 获取带负载状态的系统列表
-func (s *SystemService) GetSystemsWithLoadStatus() ([]*models.SystemWithLoadStatus, error) {
-	systems, err := s.GetSystemsWithAvgStats()
+func (s *SystemService) GetSystemsWithLoadStatus(ctx context.Context) (result []*models.SystemWithLoadStatus, err error) {
+	ctx, span := tracing.Start(ctx, "SystemService.GetSystemsWithLoadStatus")
+	defer func() {
+		span.SetAttributes(attribute.Int("systems.count", len(result)))
+		tracing.End(span, err)
+	}()
+
+	systems, err := s.GetSystemsWithAvgStats(ctx)
 	if err != nil {
 		return nil, err
 	}
-	
-	var result []*models.SystemWithLoadStatus
+
+	// 读取维护窗口或排空记录失败时按正常情况计算负载状态
+	var maintenance map[string]*models.MaintenanceWindow
+	if s.maintenance != nil {
+		if maintenance, err = s.maintenance.Active(ctx); err != nil {
+			slog.WarnContext(ctx, "获取维护窗口失败", "op", "load.maintenance", logging.Err(err))
+		}
+	}
+	var drains *ActiveDrains
+	if s.drains != nil {
+		if drains, err = s.drains.Active(ctx); err != nil {
+			slog.WarnContext(ctx, "获取排空记录失败", "op", "load.drain", logging.Err(err))
+		}
+	}
 	
 	for _, system := range systems {
+		// 手动排空优先于维护窗口，不论负载如何都按高负载上报
+		if drain := drains.System(system.ID); drain != nil {
+			result = append(result, &models.SystemWithLoadStatus{
+				SystemWithAvgStats: *system,
+				LoadStatus:         "high",
+				Drain:              drain,
+			})
+			continue
+		}
+		if window := maintenance[system.ID]; window != nil {
+			// 维护期间的负载和流量不代表正常情况，不参与网络最大值的学习
+			result = append(result, &models.SystemWithLoadStatus{
+				SystemWithAvgStats: *system,
+				LoadStatus:         LoadStatusMaintenance,
+				Maintenance:        window,
+			})
+			continue
+		}
+
 		// 获取阈值配置
-		threshold, err := s.thresholdService.GetThreshold(system.ID)
+		threshold, err := s.thresholdService.GetThreshold(ctx, system.ID)
 		if err != nil {
-			log.Printf("获取系统 %s 阈值配置失败: %v", system.Name, err)
+			slog.WarnContext(ctx, "获取系统阈值配置失败，使用默认配置", "op", "load.threshold", "system_id", system.ID, "system", system.Name, logging.Err(err))
 			// 使用默认配置继续处理
-			threshold = &models.SystemThreshold{
-				SystemID:        system.ID,
-				CPUAlertLimit:   90.0,
-				MemAlertLimit:   90.0,
-				NetUpMax:        0,
-				NetDownMax:      0,
-				NetUpAlert:      80.0,
-				NetDownAlert:    80.0,
-			}
+			threshold = DefaultThreshold(system.ID)
 		}
 		
-		// 计算负载状态
-		loadStatus := s.CalculateLoadStatus(system, threshold)
+		// 计算负载状态，没有任何统计数据时不能按0值判定为正常
+		loadStatus := LoadStatusUnknown
+		if !system.LastUpdate.IsZero() {
+			loadStatus = s.CalculateLoadStatus(system, threshold)
+		}
 		
+		// 快照数据不代表当前情况，不参与网络最大值的学习和异常检测
+		if system.Stale {
+			result = append(result, &models.SystemWithLoadStatus{
+				SystemWithAvgStats: *system,
+				LoadStatus:         loadStatus,
+			})
+			continue
+		}
+
 		// 更新网络最大值（动态更新历史极限值）
 		netUpMbps := system.AvgNetSent * 8  // 转换为 Mbps
 		netDownMbps := system.AvgNetRecv * 8 // 转换为 Mbps
-		if err := s.thresholdService.UpdateNetworkMax(system.ID, netUpMbps, netDownMbps); err != nil {
-			log.Printf("更新系统 %s 网络最大值失败: %v", system.Name, err)
+		if err := s.thresholdService.UpdateNetworkMax(ctx, system.ID, netUpMbps, netDownMbps); err != nil {
+			slog.WarnContext(ctx, "更新系统网络最大值失败", "op", "load.network_max", "system_id", system.ID, "system", system.Name, logging.Err(err))
 		}
 		
 		systemWithLoadStatus := &models.SystemWithLoadStatus{
@@ -217,6 +471,21 @@ func (s *SystemService) GetSystemsWithLoadStatus() ([]*models.SystemWithLoadStat
 			LoadStatus:         loadStatus,
 		}
 		
+		// 异常检测与阈值判定相互独立，只标记偏离历史基线的系统，不改变负载状态
+		if s.anomalies != nil && representativeLoad(system, time.Now()) {
+			anomaly, err := s.anomalies.Evaluate(ctx, system)
+			if err != nil {
+				slog.WarnContext(ctx, "异常检测失败", "op", "load.anomaly", "system_id", system.ID, "system", system.Name, logging.Err(err))
+			} else if anomaly != nil {
+				systemWithLoadStatus.AnomalyStatus = anomaly.Status
+				for _, metric := range anomaly.Metrics {
+					if metric.Anomalous {
+						systemWithLoadStatus.Anomalies = append(systemWithLoadStatus.Anomalies, metric)
+					}
+				}
+			}
+		}
+		
 		result = append(result, systemWithLoadStatus)
 	}
 	
@@ -227,13 +496,25 @@ func (s *SystemService) GetSystemsWithLoadStatus() ([]*models.SystemWithLoadStat
 func (s *SystemService) CalculateLoadStatus(system *models.SystemWithAvgStats, threshold *models.SystemThreshold) string {
 	// 检查CPU使用率
 	if system.AvgCPU >= threshold.CPUAlertLimit {
-		log.Printf("系统 %s CPU负载过高: %.2f%% >= %.2f%%", system.Name, system.AvgCPU, threshold.CPUAlertLimit)
+		logHighLoad(system, "cpu", system.AvgCPU, threshold.CPUAlertLimit)
 		return "high"
 	}
 	
 	// 检查内存使用率
 	if system.AvgMemPct >= threshold.MemAlertLimit {
-		log.Printf("系统 %s 内存负载过高: %.2f%% >= %.2f%%", system.Name, system.AvgMemPct, threshold.MemAlertLimit)
+		logHighLoad(system, "memory", system.AvgMemPct, threshold.MemAlertLimit)
+		return "high"
+	}
+	
+	// 检查根分区使用率 - 阈值为0表示不检查
+	if threshold.DiskAlertLimit > 0 && system.AvgDiskPct >= threshold.DiskAlertLimit {
+		logHighLoad(system, "disk", system.AvgDiskPct, threshold.DiskAlertLimit)
+		return "high"
+	}
+	
+	// 检查交换分区使用率 - 阈值为0表示不检查
+	if threshold.SwapAlertLimit > 0 && system.AvgSwapPct >= threshold.SwapAlertLimit {
+		logHighLoad(system, "swap", system.AvgSwapPct, threshold.SwapAlertLimit)
 		return "high"
 	}
 	
@@ -242,8 +523,7 @@ func (s *SystemService) CalculateLoadStatus(system *models.SystemWithAvgStats, t
 		netUpMbps := system.AvgNetSent * 8 // 转换为 Mbps
 		upThreshold := threshold.NetUpMax * (threshold.NetUpAlert / 100)
 		if netUpMbps >= upThreshold {
-			log.Printf("系统 %s 上行网络负载过高: %.2f Mbps >= %.2f Mbps (%.1f%% of %.2f Mbps)", 
-				system.Name, netUpMbps, upThreshold, threshold.NetUpAlert, threshold.NetUpMax)
+			logHighLoad(system, "network_up_mbps", netUpMbps, upThreshold)
 			return "high"
 		}
 	}
@@ -253,54 +533,116 @@ func (s *SystemService) CalculateLoadStatus(system *models.SystemWithAvgStats, t
 		netDownMbps := system.AvgNetRecv * 8 // 转换为 Mbps
 		downThreshold := threshold.NetDownMax * (threshold.NetDownAlert / 100)
 		if netDownMbps >= downThreshold {
-			log.Printf("系统 %s 下行网络负载过高: %.2f Mbps >= %.2f Mbps (%.1f%% of %.2f Mbps)", 
-				system.Name, netDownMbps, downThreshold, threshold.NetDownAlert, threshold.NetDownMax)
+			logHighLoad(system, "network_down_mbps", netDownMbps, downThreshold)
 			return "high"
 		}
 	}
 	
 	// 检查在线人数 - 如果设置了阈值且大于0
 	if threshold.OnlineUsersLimit > 0 && system.OnlineUsers >= threshold.OnlineUsersLimit {
-		log.Printf("系统 %s 在线人数过多: %d >= %d", system.Name, system.OnlineUsers, threshold.OnlineUsersLimit)
+		logHighLoad(system, "online_users", float64(system.OnlineUsers), float64(threshold.OnlineUsersLimit))
 		return "high"
 	}
 	
 	return "normal"
 }
 
+// logHighLoad 记录判定为高负载的原因。每次查询负载状态都会对所有系统判定一次，只在debug级别输出
+func logHighLoad(system *models.SystemWithAvgStats, metric string, value, limit float64) {
+	slog.Debug("系统负载过高", "op", "load.evaluate", "system_id", system.ID, "system", system.Name,
+		"metric", metric, "value", value, "limit", limit)
+}
+
 // GetSystemStats 获取指定系统的统计数据
-func (s *SystemService) GetSystemStats(systemID string, limit int) ([]*models.SystemStat, error) {
-	pbStats, err := s.pbClient.GetSystemLoadAverage(systemID, limit)
+func (s *SystemService) GetSystemStats(ctx context.Context, systemID string, limit int) ([]*models.SystemStat, error) {
+	pbStats, err := s.client().GetSystemLoadAverage(ctx, systemID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("获取系统统计数据失败: %w", err)
 	}
 	
 	var stats []*models.SystemStat
 	for _, pbStat := range pbStats.Items {
-		// 计算内存使用百分比
-		memPct := pbStat.Stats.MemPct
-		if memPct == 0 && pbStat.Stats.Mem > 0 && pbStat.Stats.MemUsed > 0 {
-			memPct = (pbStat.Stats.MemUsed / pbStat.Stats.Mem) * 100
-		}
-		
-		stat := &models.SystemStat{
-			ID:       pbStat.ID,
-			SystemID: pbStat.System,
-			Type:     pbStat.Type,
-			CPU:      pbStat.Stats.CPU,
-			Mem:      pbStat.Stats.Mem,
-			MemUsed:  pbStat.Stats.MemUsed,
-			MemPct:   memPct,
-			NetSent:  pbStat.Stats.NetworkSent,
-			NetRecv:  pbStat.Stats.NetworkRecv,
-			CreatedAt: parseTime(pbStat.Created),
-		}
-		stats = append(stats, stat)
+		stats = append(stats, toSystemStat(&pbStat))
 	}
 	
 	return stats, nil
 }
 
+// toSystemStat 将PocketBase统计记录转换为API模型
+func toSystemStat(pbStat *pocketbase.SystemStats) *models.SystemStat {
+	data := &pbStat.Stats
+	loadAvg := data.LoadAverages()
+
+	stat := &models.SystemStat{
+		ID:        pbStat.ID,
+		SystemID:  pbStat.System,
+		Type:      pbStat.Type,
+		CPU:       data.CPU,
+		Mem:       data.Mem,
+		MemUsed:   data.MemUsed,
+		MemPct:    data.MemPercent(),
+		NetSent:   data.NetworkSent,
+		NetRecv:   data.NetworkRecv,
+		CreatedAt: parseTime(pbStat.Created),
+
+		MemBuffCache: data.MemBuffCache,
+		Swap:         data.Swap,
+		SwapUsed:     data.SwapUsed,
+		SwapPct:      data.SwapPercent(),
+
+		DiskTotal: data.DiskTotal,
+		DiskUsed:  data.DiskUsed,
+		DiskPct:   data.DiskPercent(),
+		DiskRead:  data.DiskReadPs,
+		DiskWrite: data.DiskWritePs,
+
+		LoadAvg1:  loadAvg[0],
+		LoadAvg5:  loadAvg[1],
+		LoadAvg15: loadAvg[2],
+
+		Temperatures: data.Temperatures,
+	}
+
+	if len(data.ExtraFs) > 0 {
+		stat.ExtraFs = make(map[string]models.FsStat, len(data.ExtraFs))
+		for name, fs := range data.ExtraFs {
+			var pct float64
+			if fs.DiskTotal > 0 {
+				pct = (fs.DiskUsed / fs.DiskTotal) * 100
+			}
+			stat.ExtraFs[name] = models.FsStat{
+				DiskTotal: fs.DiskTotal,
+				DiskUsed:  fs.DiskUsed,
+				DiskPct:   pct,
+				DiskRead:  fs.DiskReadPs,
+				DiskWrite: fs.DiskWritePs,
+			}
+		}
+	}
+
+	if len(data.GPUs) > 0 {
+		stat.GPUs = make(map[string]models.GPUStat, len(data.GPUs))
+		for id, gpu := range data.GPUs {
+			stat.GPUs[id] = models.GPUStat{
+				Name:        gpu.Name,
+				Usage:       gpu.Usage,
+				MemoryUsed:  gpu.MemoryUsed,
+				MemoryTotal: gpu.MemoryTotal,
+				Power:       gpu.Power,
+			}
+		}
+	}
+
+	if len(data.Interfaces) > 0 {
+		stat.Interfaces = make(map[string]models.NetInterfaceStat, len(data.Interfaces))
+		for name, nic := range data.Interfaces {
+			stat.Interfaces[name] = models.NetInterfaceStat{NetSent: nic.Sent, NetRecv: nic.Recv}
+		}
+	}
+
+	return stat
+}
+
 // calculateAverageStats 计算平均统计数据
 func calculateAverageStats(pbStats []pocketbase.SystemStats) *models.AverageStats {
 	if len(pbStats) == 0 {
@@ -309,27 +651,24 @@ func calculateAverageStats(pbStats []pocketbase.SystemStats) *models.AverageStat
 		}
 	}
 	
-	var avgCPU, avgMemPct, avgNetSent, avgNetRecv float64
+	var avgCPU, avgMemPct, avgNetSent, avgNetRecv, avgDiskPct, avgSwapPct, loadAvg1 float64
 	var lastUpdate time.Time
 	
 	for _, stat := range pbStats {
 		avgCPU += stat.Stats.CPU
-		
-		// 计算内存使用百分比
-		memPct := stat.Stats.MemPct
-		if memPct == 0 && stat.Stats.Mem > 0 && stat.Stats.MemUsed > 0 {
-			memPct = (stat.Stats.MemUsed / stat.Stats.Mem) * 100
-		}
-		avgMemPct += memPct
+		avgMemPct += stat.Stats.MemPercent()
+		avgDiskPct += stat.Stats.DiskPercent()
+		avgSwapPct += stat.Stats.SwapPercent()
 		
 		// 网络数据转换为 MB/s
 		avgNetSent += stat.Stats.NetworkSent
 		avgNetRecv += stat.Stats.NetworkRecv
 		
-		// 记录最新时间
+		// 记录最新时间，负载均值本身已是平滑值，取最新一条
 		statTime := parseTime(stat.Created)
 		if statTime.After(lastUpdate) {
 			lastUpdate = statTime
+			loadAvg1 = stat.Stats.LoadAverages()[0]
 		}
 	}
 	
@@ -339,6 +678,9 @@ func calculateAverageStats(pbStats []pocketbase.SystemStats) *models.AverageStat
 		AvgMemPct:  avgMemPct / count,
 		AvgNetSent: avgNetSent / count,
 		AvgNetRecv: avgNetRecv / count,
+		AvgDiskPct: avgDiskPct / count,
+		AvgSwapPct: avgSwapPct / count,
+		LoadAvg1:   loadAvg1,
 		LastUpdate: lastUpdate,
 	}
 }
@@ -1,7 +1,13 @@
 package service
 
 import (
+	"backend/internal/pocketbase"
 	"backend/pkg/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -31,7 +37,7 @@ func TestCalculateLoadStatus(t *testing.T) {
 		NetDownAlert:  80.0,
 	}
 	
-	result1 := s.calculateLoadStatus(system1, threshold1)
+	result1 := s.CalculateLoadStatus(system1, threshold1)
 	if result1 != "high" {
 		t.Errorf("测试用例1失败: 期望 'high', 得到 '%s'", result1)
 	}
@@ -49,7 +55,7 @@ func TestCalculateLoadStatus(t *testing.T) {
 		LastUpdate: time.Now(),
 	}
 	
-	result2 := s.calculateLoadStatus(system2, threshold1)
+	result2 := s.CalculateLoadStatus(system2, threshold1)
 	if result2 != "high" {
 		t.Errorf("测试用例2失败: 期望 'high', 得到 '%s'", result2)
 	}
@@ -67,7 +73,7 @@ func TestCalculateLoadStatus(t *testing.T) {
 		LastUpdate: time.Now(),
 	}
 	
-	result3 := s.calculateLoadStatus(system3, threshold1)
+	result3 := s.CalculateLoadStatus(system3, threshold1)
 	if result3 != "normal" {
 		t.Errorf("测试用例3失败: 期望 'normal', 得到 '%s'", result3)
 	}
@@ -94,7 +100,7 @@ func TestCalculateLoadStatus(t *testing.T) {
 		NetDownAlert:  80.0,  // 80%阈值 = 80 Mbps
 	}
 	
-	result4 := s.calculateLoadStatus(system4, threshold4)
+	result4 := s.CalculateLoadStatus(system4, threshold4)
 	if result4 != "high" {
 		t.Errorf("测试用例4失败: 期望 'high', 得到 '%s'", result4)
 	}
@@ -112,8 +118,152 @@ func TestCalculateLoadStatus(t *testing.T) {
 		LastUpdate: time.Now(),
 	}
 	
-	result5 := s.calculateLoadStatus(system5, threshold4)
+	result5 := s.CalculateLoadStatus(system5, threshold4)
 	if result5 != "normal" {
 		t.Errorf("测试用例5失败: 期望 'normal', 得到 '%s'", result5)
 	}
-}
\ No newline at end of file
+}
+func TestGetSystemsServesSnapshotWhenHubDown(t *testing.T) {
+	var hubDown atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hubDown.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"page":1,"items":[{"id":"sys1","name":"server-1","status":"up"}]}`))
+	}))
+	defer srv.Close()
+
+	client := pocketbase.NewClient(srv.URL)
+	// 预签发token，避免测试依赖登录流程
+	client.SetAuthToken("static-token")
+	client.Retry = pocketbase.RetryPolicy{MaxAttempts: 1}
+	client.Breaker = pocketbase.NewCircuitBreaker(1, time.Hour)
+	s := &SystemService{pbClient: client, statsSnapshot: make(map[string]*models.AverageStats)}
+
+	live, err := s.GetSystems(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystems failed: %v", err)
+	}
+	if live[0].Stale || live[0].AsOf != nil {
+		t.Errorf("live data should not be marked stale: %+v", live[0])
+	}
+
+	hubDown.Store(true)
+	systems, err := s.GetSystems(context.Background())
+	if err != nil {
+		t.Fatalf("expected snapshot while hub is down, got error: %v", err)
+	}
+	if len(systems) != 1 || systems[0].ID != "sys1" {
+		t.Fatalf("unexpected snapshot contents: %+v", systems)
+	}
+	if !systems[0].Stale || systems[0].AsOf == nil {
+		t.Errorf("snapshot data should be marked stale with as_of: %+v", systems[0])
+	}
+
+	health := s.PocketBaseHealth()
+	if health.Circuit != pocketbase.BreakerOpen {
+		t.Errorf("expected open circuit, got %s", health.Circuit)
+	}
+	if health.SnapshotAt == nil {
+		t.Error("expected snapshot time in health report")
+	}
+}
+
+func TestSystemWithoutStatsIsUnknown(t *testing.T) {
+	setupThresholdStorage(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "system_stats") {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"page":1,"items":[{"id":"sys1","name":"server-1","status":"up"}]}`))
+	}))
+	defer srv.Close()
+
+	client := pocketbase.NewClient(srv.URL)
+	client.SetAuthToken("static-token")
+	client.Retry = pocketbase.RetryPolicy{MaxAttempts: 1}
+	s := &SystemService{
+		pbClient:         client,
+		thresholdService: NewThresholdService(),
+		statsSnapshot:    make(map[string]*models.AverageStats),
+	}
+
+	// 获取统计数据失败且没有快照时，不能按0值判定为正常
+	systems, err := s.GetSystemsWithLoadStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(systems) != 1 {
+		t.Fatalf("系统列表: %+v", systems)
+	}
+	system := systems[0]
+	if system.LoadStatus != LoadStatusUnknown {
+		t.Errorf("负载状态 = %s, 期望 %s", system.LoadStatus, LoadStatusUnknown)
+	}
+	if !system.LastUpdate.IsZero() || !system.Stale {
+		t.Errorf("没有统计数据时更新时间应为空并标记为快照: %+v", system)
+	}
+	if reason := systemHighLoadReason(system); reason != HighLoadReasonUnknown {
+		t.Errorf("高负载原因 = %q, 期望 %q", reason, HighLoadReasonUnknown)
+	}
+}
+
+func TestCalculateLoadStatusDiskAndSwap(t *testing.T) {
+	s := &SystemService{}
+	threshold := &models.SystemThreshold{
+		CPUAlertLimit:  90.0,
+		MemAlertLimit:  90.0,
+		DiskAlertLimit: 90.0,
+		SwapAlertLimit: 50.0,
+	}
+
+	cases := []struct {
+		name    string
+		diskPct float64
+		swapPct float64
+		want    string
+	}{
+		{"正常", 40, 10, "normal"},
+		{"磁盘超过阈值", 95, 10, "high"},
+		{"交换分区超过阈值", 40, 60, "high"},
+	}
+	for _, tc := range cases {
+		system := &models.SystemWithAvgStats{
+			System:     models.System{ID: "disk", Name: "DiskServer"},
+			AvgCPU:     10,
+			AvgMemPct:  10,
+			AvgDiskPct: tc.diskPct,
+			AvgSwapPct: tc.swapPct,
+		}
+		if got := s.CalculateLoadStatus(system, threshold); got != tc.want {
+			t.Errorf("%s: 期望 '%s', 得到 '%s'", tc.name, tc.want, got)
+		}
+	}
+
+	// 阈值为0时不检查
+	disabled := &models.SystemThreshold{CPUAlertLimit: 90.0, MemAlertLimit: 90.0}
+	system := &models.SystemWithAvgStats{AvgDiskPct: 99, AvgSwapPct: 99}
+	if got := s.CalculateLoadStatus(system, disabled); got != "normal" {
+		t.Errorf("阈值为0时不应检查磁盘和交换分区，得到 '%s'", got)
+	}
+}
+
+func TestCalculateAverageStatsExtendedFields(t *testing.T) {
+	stats := []pocketbase.SystemStats{
+		{Created: "2026-10-18 08:00:00.000Z", Stats: pocketbase.StatsData{DiskPct: 80, Swap: 4, SwapUsed: 1, LoadAvg: [3]float64{2, 1, 1}}},
+		{Created: "2026-10-18 08:01:00.000Z", Stats: pocketbase.StatsData{DiskPct: 90, Swap: 4, SwapUsed: 3, LoadAvg: [3]float64{3, 1, 1}}},
+	}
+
+	avg := calculateAverageStats(stats)
+	if avg.AvgDiskPct != 85 {
+		t.Errorf("期望磁盘平均 85, 得到 %v", avg.AvgDiskPct)
+	}
+	if avg.AvgSwapPct != 50 {
+		t.Errorf("期望交换分区平均 50, 得到 %v", avg.AvgSwapPct)
+	}
+	if avg.LoadAvg1 != 3 {
+		t.Errorf("期望取最新的负载均值 3, 得到 %v", avg.LoadAvg1)
+	}
+}
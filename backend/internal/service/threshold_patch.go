@@ -0,0 +1,144 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// 部分更新时忽略的只读字段：以存储为准，GET的响应原样提交回来也不会报错
+var thresholdReadOnlyFields = map[string]bool{
+	"id":         true,
+	"system_id":  true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+	"sources":    true,
+	"overrides":  true,
+}
+
+// thresholdPercentFields 百分比阈值字段
+var thresholdPercentFields = map[string]func(v *models.ThresholdValues) **float64{
+	"cpu_alert_limit":  func(v *models.ThresholdValues) **float64 { return &v.CPUAlertLimit },
+	"mem_alert_limit":  func(v *models.ThresholdValues) **float64 { return &v.MemAlertLimit },
+	"net_up_alert":     func(v *models.ThresholdValues) **float64 { return &v.NetUpAlert },
+	"net_down_alert":   func(v *models.ThresholdValues) **float64 { return &v.NetDownAlert },
+	"disk_alert_limit": func(v *models.ThresholdValues) **float64 { return &v.DiskAlertLimit },
+	"swap_alert_limit": func(v *models.ThresholdValues) **float64 { return &v.SwapAlertLimit },
+}
+
+// PatchThreshold 部分更新系统阈值，只修改请求中出现的字段。
+// 阈值字段设置为系统级覆盖，值为null时清除覆盖、恢复继承模板或全局默认值；
+// profile_id 为null或空串时不使用模板；net_up_max/net_down_max 为null时重置历史极限值。
+// 所有字段校验通过后才会写入，校验失败返回 FieldErrors。
+func (s *ThresholdService) PatchThreshold(systemID string, patch map[string]json.RawMessage, change Change) (*models.SystemThreshold, error) {
+	storage := database.GetStorage()
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	stored, err := storage.GetThreshold(systemID)
+	if err != nil {
+		return nil, fmt.Errorf("获取阈值配置失败: %w", err)
+	}
+	if err := checkIfMatch(change.IfMatch, thresholdVersion(stored)); err != nil {
+		return nil, err
+	}
+
+	record := &models.SystemThreshold{
+		SystemID:  systemID,
+		Overrides: &models.ThresholdValues{},
+		Version:   thresholdVersion(stored) + 1,
+	}
+	if stored != nil {
+		record.ProfileID = stored.ProfileID
+		*record.Overrides = *systemOverrides(stored)
+		record.NetUpMax = stored.NetUpMax
+		record.NetDownMax = stored.NetDownMax
+	}
+
+	errs := FieldErrors{}
+	for field, raw := range patch {
+		null := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		switch {
+		case thresholdReadOnlyFields[field]:
+			continue
+		case thresholdPercentFields[field] != nil:
+			target := thresholdPercentFields[field](record.Overrides)
+			if null {
+				*target = nil
+				continue
+			}
+			var v float64
+			if err := json.Unmarshal(raw, &v); err != nil {
+				errs[field] = "必须是数字或null"
+				continue
+			}
+			errs.checkPercent(field, v)
+			*target = &v
+		case field == "online_users_limit":
+			if null {
+				record.Overrides.OnlineUsersLimit = nil
+				continue
+			}
+			var v float64
+			if err := json.Unmarshal(raw, &v); err != nil || v != math.Trunc(v) || math.Abs(v) > math.MaxInt32 {
+				errs[field] = "必须是整数或null"
+				continue
+			}
+			limit := int(v)
+			errs.checkNonNegative(field, v)
+			record.Overrides.OnlineUsersLimit = &limit
+		case field == "net_up_max" || field == "net_down_max":
+			var v float64
+			if !null {
+				if err := json.Unmarshal(raw, &v); err != nil {
+					errs[field] = "必须是数字或null"
+					continue
+				}
+				errs.checkNonNegative(field, v)
+			}
+			if field == "net_up_max" {
+				record.NetUpMax = v
+			} else {
+				record.NetDownMax = v
+			}
+		case field == "profile_id":
+			var id string
+			if !null {
+				if err := json.Unmarshal(raw, &id); err != nil {
+					errs[field] = "必须是字符串或null"
+					continue
+				}
+			}
+			if id == DefaultProfileID {
+				id = ""
+			}
+			if id != "" {
+				profile, err := storage.GetThresholdProfile(id)
+				if err != nil {
+					return nil, fmt.Errorf("获取阈值模板失败: %w", err)
+				}
+				if profile == nil {
+					errs[field] = fmt.Sprintf("阈值模板 %q 不存在", id)
+					continue
+				}
+			}
+			record.ProfileID = id
+		default:
+			errs[field] = "未知字段"
+		}
+	}
+	if err := errs.orNil(); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveAudited(record, stored, change); err != nil {
+		return nil, err
+	}
+	return s.GetThreshold(context.Background(), systemID)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultProfileID 全局默认阈值模板的ID
+const DefaultProfileID = "default"
+
+var (
+	// ErrProfileNotFound 阈值模板不存在
+	ErrProfileNotFound = errors.New("阈值模板不存在")
+	// ErrProfileExists 阈值模板ID已存在
+	ErrProfileExists = errors.New("阈值模板已存在")
+	// ErrProfileInUse 阈值模板仍被系统使用
+	ErrProfileInUse = errors.New("阈值模板正在被使用")
+	// ErrInvalidProfile 阈值模板参数不合法
+	ErrInvalidProfile = errors.New("阈值模板参数错误")
+)
+
+// profileIDPattern 模板ID：小写字母、数字、连字符和下划线
+var profileIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ListThresholdProfiles 列出所有阈值模板，全局默认模板始终排在第一个
+func (s *ThresholdService) ListThresholdProfiles() ([]*models.ThresholdProfile, error) {
+	stored, err := database.GetStorage().ListThresholdProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("获取阈值模板失败: %w", err)
+	}
+
+	profiles := []*models.ThresholdProfile{defaultProfile()}
+	for _, profile := range stored {
+		if profile.ID == DefaultProfileID {
+			profiles[0] = profile
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// GetThresholdProfile 获取阈值模板，全局默认模板未保存过时返回空模板
+func (s *ThresholdService) GetThresholdProfile(id string) (*models.ThresholdProfile, error) {
+	profile, err := database.GetStorage().GetThresholdProfile(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取阈值模板失败: %w", err)
+	}
+	if profile == nil {
+		if id == DefaultProfileID {
+			return defaultProfile(), nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, id)
+	}
+	return profile, nil
+}
+
+// CreateThresholdProfile 创建阈值模板，未指定ID时由名称生成
+func (s *ThresholdService) CreateThresholdProfile(profile *models.ThresholdProfile, change Change) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("%w: 模板名称不能为空", ErrInvalidProfile)
+	}
+	if profile.ID == "" {
+		profile.ID = profileIDFromName(profile.Name)
+	}
+	if !profileIDPattern.MatchString(profile.ID) {
+		return fmt.Errorf("%w: 模板ID只能包含小写字母、数字、连字符和下划线: %q", ErrInvalidProfile, profile.ID)
+	}
+	if err := ValidateThresholdValues(&profile.Values); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	// 检查ID是否已存在和写入之间不允许其他模板修改
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	storage := database.GetStorage()
+	existing, err := storage.GetThresholdProfile(profile.ID)
+	if err != nil {
+		return fmt.Errorf("获取阈值模板失败: %w", err)
+	}
+	if existing != nil || profile.ID == DefaultProfileID {
+		return fmt.Errorf("%w: %s", ErrProfileExists, profile.ID)
+	}
+
+	profile.CreatedAt = time.Time{}
+	if err := storage.CreateOrUpdateThresholdProfile(profile); err != nil {
+		return fmt.Errorf("保存阈值模板失败: %w", err)
+	}
+	recordAudit(change, "create", AuditThresholdProfile, profile.ID, 0, nil, profile)
+	return nil
+}
+
+// UpdateThresholdProfile 更新阈值模板；全局默认模板不存在时直接创建
+func (s *ThresholdService) UpdateThresholdProfile(id string, profile *models.ThresholdProfile, change Change) error {
+	if err := ValidateThresholdValues(&profile.Values); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	// 检查模板是否存在和写入之间不允许删除该模板
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := s.GetThresholdProfile(id)
+	if err != nil {
+		return err
+	}
+
+	profile.ID = id
+	profile.CreatedAt = existing.CreatedAt
+	if strings.TrimSpace(profile.Name) == "" {
+		profile.Name = existing.Name
+	}
+	if err := database.GetStorage().CreateOrUpdateThresholdProfile(profile); err != nil {
+		return fmt.Errorf("保存阈值模板失败: %w", err)
+	}
+	recordAudit(change, "update", AuditThresholdProfile, id, 0, existing, profile)
+	return nil
+}
+
+// DeleteThresholdProfile 删除阈值模板。
+// 删除全局默认模板等同于恢复为配置文件中的默认阈值；仍有系统使用的模板不能删除。
+func (s *ThresholdService) DeleteThresholdProfile(id string, change Change) error {
+	storage := database.GetStorage()
+
+	// 检查引用和删除之间不允许系统改用该模板
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	existing, err := storage.GetThresholdProfile(id)
+	if err != nil {
+		return fmt.Errorf("获取阈值模板失败: %w", err)
+	}
+	if id != DefaultProfileID {
+		if existing == nil {
+			return fmt.Errorf("%w: %s", ErrProfileNotFound, id)
+		}
+
+		thresholds, err := storage.ListThresholds()
+		if err != nil {
+			return fmt.Errorf("获取阈值配置失败: %w", err)
+		}
+		var users []string
+		for _, threshold := range thresholds {
+			if threshold.ProfileID == id {
+				users = append(users, threshold.SystemID)
+			}
+		}
+		if len(users) > 0 {
+			return fmt.Errorf("%w: %s", ErrProfileInUse, strings.Join(users, ", "))
+		}
+	}
+
+	if err := storage.DeleteThresholdProfile(id); err != nil {
+		return fmt.Errorf("删除阈值模板失败: %w", err)
+	}
+	if existing != nil {
+		recordAudit(change, "delete", AuditThresholdProfile, id, 0, existing, nil)
+	}
+	return nil
+}
+
+// defaultProfile 未保存过的全局默认模板：不设置任何字段，全部继承配置文件
+func defaultProfile() *models.ThresholdProfile {
+	return &models.ThresholdProfile{ID: DefaultProfileID, Name: "全局默认"}
+}
+
+// profileIDFromName 由模板名称生成ID，如 "IEPL relay" → "iepl-relay"；名称中没有可用字符时按时间生成
+func profileIDFromName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	id := strings.TrimSuffix(b.String(), "-")
+	if len(id) > 64 {
+		id = strings.TrimSuffix(id[:64], "-")
+	}
+	if id == "" {
+		id = fmt.Sprintf("profile-%d", time.Now().UnixNano())
+	}
+	return id
+}
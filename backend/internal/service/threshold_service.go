@@ -1,11 +1,229 @@
 package service
 
 import (
+	"backend/internal/config"
 	"backend/internal/database"
 	"backend/pkg/models"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 )
 
+// thresholdDefaults 当前生效的默认阈值，配置重载时整体替换
+var thresholdDefaults atomic.Pointer[config.ThresholdConfig]
+
+// SetThresholdDefaults 设置默认阈值（启动和配置重载时调用）
+func SetThresholdDefaults(defaults config.ThresholdConfig) {
+	thresholdDefaults.Store(&defaults)
+}
+
+// 阈值来源，按优先级从高到低
+const (
+	SourceSystem  = "system"  // 系统级覆盖
+	SourceProfile = "profile" // 系统所属的阈值模板
+	SourceGlobal  = "global"  // 全局默认模板
+	SourceConfig  = "config"  // 配置文件中的默认阈值
+)
+
+// legacyDefaults 引入阈值模板之前自动保存到每个系统的默认值，迁移时与之相同的字段视为未覆盖
+var legacyDefaults = config.ThresholdConfig{
+	CPUAlertLimit:  90,
+	MemAlertLimit:  90,
+	NetUpAlert:     80,
+	NetDownAlert:   80,
+	DiskAlertLimit: 90,
+}
+
+// DefaultThreshold 只按配置文件中的默认阈值生成系统的阈值配置（不读取存储）
+func DefaultThreshold(systemID string) *models.SystemThreshold {
+	threshold := &models.SystemThreshold{SystemID: systemID, Sources: make(map[string]string)}
+	applyThresholdLayer(threshold, configThresholdValues(), SourceConfig)
+	return threshold
+}
+
+// configThresholdValues 当前配置文件中的默认阈值，所有字段都有值
+func configThresholdValues() *models.ThresholdValues {
+	defaults := thresholdDefaults.Load()
+	if defaults == nil {
+		defaults = &config.Default().Thresholds
+	}
+	return thresholdConfigValues(*defaults)
+}
+
+// thresholdConfigValues 将完整的阈值配置转换为分层字段
+func thresholdConfigValues(d config.ThresholdConfig) *models.ThresholdValues {
+	return &models.ThresholdValues{
+		CPUAlertLimit:    &d.CPUAlertLimit,
+		MemAlertLimit:    &d.MemAlertLimit,
+		NetUpAlert:       &d.NetUpAlert,
+		NetDownAlert:     &d.NetDownAlert,
+		DiskAlertLimit:   &d.DiskAlertLimit,
+		SwapAlertLimit:   &d.SwapAlertLimit,
+		OnlineUsersLimit: &d.OnlineUsersLimit,
+	}
+}
+
+// applyThresholdLayer 将一层阈值覆盖到结果上，并记录每个字段的来源
+func applyThresholdLayer(t *models.SystemThreshold, values *models.ThresholdValues, source string) {
+	if values == nil {
+		return
+	}
+	set := func(name string, dst, src *float64) {
+		if src != nil {
+			*dst = *src
+			t.Sources[name] = source
+		}
+	}
+	set("cpu_alert_limit", &t.CPUAlertLimit, values.CPUAlertLimit)
+	set("mem_alert_limit", &t.MemAlertLimit, values.MemAlertLimit)
+	set("net_up_alert", &t.NetUpAlert, values.NetUpAlert)
+	set("net_down_alert", &t.NetDownAlert, values.NetDownAlert)
+	set("disk_alert_limit", &t.DiskAlertLimit, values.DiskAlertLimit)
+	set("swap_alert_limit", &t.SwapAlertLimit, values.SwapAlertLimit)
+	if values.OnlineUsersLimit != nil {
+		t.OnlineUsersLimit = *values.OnlineUsersLimit
+		t.Sources["online_users_limit"] = source
+	}
+}
+
+// diffThresholdValues 返回 t 中与 base 不同的字段
+func diffThresholdValues(t, base *models.SystemThreshold) *models.ThresholdValues {
+	diff := &models.ThresholdValues{}
+	pick := func(value, baseValue float64) *float64 {
+		if value == baseValue {
+			return nil
+		}
+		return &value
+	}
+	diff.CPUAlertLimit = pick(t.CPUAlertLimit, base.CPUAlertLimit)
+	diff.MemAlertLimit = pick(t.MemAlertLimit, base.MemAlertLimit)
+	diff.NetUpAlert = pick(t.NetUpAlert, base.NetUpAlert)
+	diff.NetDownAlert = pick(t.NetDownAlert, base.NetDownAlert)
+	diff.DiskAlertLimit = pick(t.DiskAlertLimit, base.DiskAlertLimit)
+	diff.SwapAlertLimit = pick(t.SwapAlertLimit, base.SwapAlertLimit)
+	if t.OnlineUsersLimit != base.OnlineUsersLimit {
+		limit := t.OnlineUsersLimit
+		diff.OnlineUsersLimit = &limit
+	}
+	return diff
+}
+
+// overlayThresholdValues 返回 base 的副本，top 中设置了的字段覆盖 base
+func overlayThresholdValues(base, top *models.ThresholdValues) *models.ThresholdValues {
+	result := *base
+	pick := func(dst **float64, src *float64) {
+		if src != nil {
+			*dst = src
+		}
+	}
+	pick(&result.CPUAlertLimit, top.CPUAlertLimit)
+	pick(&result.MemAlertLimit, top.MemAlertLimit)
+	pick(&result.NetUpAlert, top.NetUpAlert)
+	pick(&result.NetDownAlert, top.NetDownAlert)
+	pick(&result.DiskAlertLimit, top.DiskAlertLimit)
+	pick(&result.SwapAlertLimit, top.SwapAlertLimit)
+	if top.OnlineUsersLimit != nil {
+		result.OnlineUsersLimit = top.OnlineUsersLimit
+	}
+	return &result
+}
+
+// systemOverrides 返回存储记录中的系统级覆盖；旧记录没有overrides，与旧默认值不同的字段视为覆盖
+func systemOverrides(stored *models.SystemThreshold) *models.ThresholdValues {
+	if stored == nil {
+		return nil
+	}
+	if stored.Overrides != nil {
+		return stored.Overrides
+	}
+	legacy := &models.SystemThreshold{Sources: make(map[string]string)}
+	applyThresholdLayer(legacy, thresholdConfigValues(legacyDefaults), SourceConfig)
+	return diffThresholdValues(stored, legacy)
+}
+
+// FieldErrors 按字段汇总的校验错误，键为JSON字段名
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, field := range sortedKeys(e) {
+		parts = append(parts, field+" "+e[field])
+	}
+	return "参数校验失败: " + strings.Join(parts, "; ")
+}
+
+// orNil 没有错误时返回nil，避免返回非nil的空FieldErrors
+func (e FieldErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e FieldErrors) checkPercent(field string, value float64) {
+	if value < 0 || value > 100 {
+		e[field] = "必须在0-100之间"
+	}
+}
+
+func (e FieldErrors) checkNonNegative(field string, value float64) {
+	if value < 0 {
+		e[field] = "不能为负数"
+	}
+}
+
+// checkValues 校验分层阈值字段，prefix用于嵌套字段的名称
+func (e FieldErrors) checkValues(prefix string, values *models.ThresholdValues) {
+	if values == nil {
+		return
+	}
+	percents := []struct {
+		name  string
+		value *float64
+	}{
+		{"cpu_alert_limit", values.CPUAlertLimit},
+		{"mem_alert_limit", values.MemAlertLimit},
+		{"net_up_alert", values.NetUpAlert},
+		{"net_down_alert", values.NetDownAlert},
+		{"disk_alert_limit", values.DiskAlertLimit},
+		{"swap_alert_limit", values.SwapAlertLimit},
+	}
+	for _, p := range percents {
+		if p.value != nil {
+			e.checkPercent(prefix+p.name, *p.value)
+		}
+	}
+	if values.OnlineUsersLimit != nil {
+		e.checkNonNegative(prefix+"online_users_limit", float64(*values.OnlineUsersLimit))
+	}
+}
+
+// ValidateThresholdValues 校验分层阈值字段的取值范围，返回 FieldErrors
+func ValidateThresholdValues(values *models.ThresholdValues) error {
+	errs := FieldErrors{}
+	errs.checkValues("", values)
+	return errs.orNil()
+}
+
+// ValidateThreshold 校验完整的系统阈值配置（顶层字段、overrides以及网络最大值），返回 FieldErrors
+func ValidateThreshold(t *models.SystemThreshold) error {
+	errs := FieldErrors{}
+	errs.checkPercent("cpu_alert_limit", t.CPUAlertLimit)
+	errs.checkPercent("mem_alert_limit", t.MemAlertLimit)
+	errs.checkPercent("net_up_alert", t.NetUpAlert)
+	errs.checkPercent("net_down_alert", t.NetDownAlert)
+	errs.checkPercent("disk_alert_limit", t.DiskAlertLimit)
+	errs.checkPercent("swap_alert_limit", t.SwapAlertLimit)
+	errs.checkNonNegative("online_users_limit", float64(t.OnlineUsersLimit))
+	errs.checkNonNegative("net_up_max", t.NetUpMax)
+	errs.checkNonNegative("net_down_max", t.NetDownMax)
+	errs.checkValues("overrides.", t.Overrides)
+	return errs.orNil()
+}
+
 // ThresholdService 阈值配置服务
 type ThresholdService struct{}
 
@@ -14,92 +232,292 @@ func NewThresholdService() *ThresholdService {
 	return &ThresholdService{}
 }
 
-// GetThreshold 获取系统阈值配置
-func (s *ThresholdService) GetThreshold(systemID string) (*models.SystemThreshold, error) {
-	storage := database.GetStorage()
-	
-	threshold, err := storage.GetThreshold(systemID)
+// GetThreshold 获取系统生效的阈值配置，sources 标明每个字段来自哪一层
+func (s *ThresholdService) GetThreshold(ctx context.Context, systemID string) (*models.SystemThreshold, error) {
+	storage := database.GetStorageContext(ctx)
+	stored, err := storage.GetThreshold(systemID)
 	if err != nil {
 		return nil, fmt.Errorf("获取阈值配置失败: %w", err)
 	}
-	
-	// 如果没有找到，创建默认配置
-	if threshold == nil {
-		threshold = &models.SystemThreshold{
-			SystemID:        systemID,
-			CPUAlertLimit:   90.0,
-			MemAlertLimit:   90.0,
-			NetUpMax:        0,
-			NetDownMax:      0,
-			NetUpAlert:      80.0,
-			NetDownAlert:    80.0,
-		}
-		
-		// 保存默认配置
-		if createErr := storage.CreateOrUpdateThreshold(threshold); createErr != nil {
-			return nil, fmt.Errorf("创建默认阈值配置失败: %w", createErr)
+	return s.resolve(storage, systemID, stored)
+}
+
+// resolve 按 系统覆盖 → 模板 → 全局默认 → 配置文件 的优先级计算生效阈值
+func (s *ThresholdService) resolve(storage database.ConfigTx, systemID string, stored *models.SystemThreshold) (*models.SystemThreshold, error) {
+	result := &models.SystemThreshold{SystemID: systemID, Sources: make(map[string]string)}
+	if stored != nil {
+		result.ID = stored.ID
+		result.ProfileID = stored.ProfileID
+		result.Overrides = systemOverrides(stored)
+		result.NetUpMax = stored.NetUpMax
+		result.NetDownMax = stored.NetDownMax
+		result.Version = stored.Version
+		result.CreatedAt = stored.CreatedAt
+		result.UpdatedAt = stored.UpdatedAt
+	}
+
+	applyThresholdLayer(result, configThresholdValues(), SourceConfig)
+
+	global, err := storage.GetThresholdProfile(DefaultProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取全局默认阈值失败: %w", err)
+	}
+	if global != nil {
+		applyThresholdLayer(result, &global.Values, SourceGlobal)
+	}
+
+	if result.ProfileID != "" {
+		profile, err := storage.GetThresholdProfile(result.ProfileID)
+		if err != nil {
+			return nil, fmt.Errorf("获取阈值模板失败: %w", err)
+		}
+		if profile != nil {
+			applyThresholdLayer(result, &profile.Values, SourceProfile)
 		}
 	}
-	
-	return threshold, nil
+
+	applyThresholdLayer(result, result.Overrides, SourceSystem)
+	return result, nil
 }
 
-// UpdateThreshold 更新系统阈值配置
-func (s *ThresholdService) UpdateThreshold(systemID string, threshold *models.SystemThreshold) error {
-	storage := database.GetStorage()
-	
-	// 设置SystemID
-	threshold.SystemID = systemID
-	
-	// 创建或更新阈值
-	if err := storage.CreateOrUpdateThreshold(threshold); err != nil {
-		return fmt.Errorf("更新阈值配置失败: %w", err)
+// thresholdPutFields PUT 请求体中的阈值字段：不带 overrides 时必须全部提交，带 overrides 时全部提交或都不提交
+var thresholdPutFields = []string{
+	"cpu_alert_limit", "mem_alert_limit", "net_up_alert", "net_down_alert",
+	"disk_alert_limit", "swap_alert_limit", "online_users_limit",
+}
+
+// decodeThresholdPut 解析并校验PUT请求体，缺少阈值字段时返回 FieldErrors，不会把未提交的字段当作0保存
+func decodeThresholdPut(body map[string]json.RawMessage) (*models.SystemThreshold, error) {
+	var threshold models.SystemThreshold
+	data, err := json.Marshal(body)
+	if err == nil {
+		err = json.Unmarshal(data, &threshold)
 	}
-	
-	return nil
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, FieldErrors{typeErr.Field: "类型错误"}
+		}
+		return nil, fmt.Errorf("请求参数格式错误: %w", err)
+	}
+
+	errs := FieldErrors{}
+	var missing []string
+	for _, field := range thresholdPutFields {
+		if _, ok := body[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	_, hasOverrides := body["overrides"]
+	if len(missing) > 0 && (!hasOverrides || len(missing) < len(thresholdPutFields)) {
+		for _, field := range missing {
+			errs[field] = "PUT必须提交该字段，只修改部分字段请使用PATCH"
+		}
+	}
+	var fieldErrs FieldErrors
+	if errors.As(ValidateThreshold(&threshold), &fieldErrs) {
+		for field, msg := range fieldErrs {
+			if _, ok := errs[field]; !ok {
+				errs[field] = msg
+			}
+		}
+	}
+	return &threshold, errs.orNil()
 }
 
-// UpdateNetworkMax 更新网络最大值（用于动态更新历史极限值）
-func (s *ThresholdService) UpdateNetworkMax(systemID string, netUpMbps, netDownMbps float64) error {
+// UpdateThreshold 整体替换系统阈值配置，body 为PUT请求体。
+// 只带 overrides 时按其设置系统级覆盖；旧版客户端只提交完整字段时，
+// 与模板/全局默认不同的字段视为系统级覆盖，相同的字段继续跟随上层变化；
+// 同时提交完整字段和 overrides 时（如把GET的响应修改后提交），与按 overrides 计算出的生效值不同的字段成为系统级覆盖。
+// 缺少阈值字段时返回 FieldErrors，部分更新应使用 PatchThreshold；
+// net_up_max/net_down_max 未提交时保留已学习到的历史极限值，提交0时重置。
+func (s *ThresholdService) UpdateThreshold(systemID string, body map[string]json.RawMessage, change Change) (*models.SystemThreshold, error) {
+	threshold, err := decodeThresholdPut(body)
+	if err != nil {
+		return nil, err
+	}
 	storage := database.GetStorage()
-	
-	// 获取现有阈值配置
-	threshold, err := s.GetThreshold(systemID)
+
+	profileID := threshold.ProfileID
+	if profileID == DefaultProfileID {
+		profileID = ""
+	}
+
+	// 模板检查、覆盖计算和写入都在锁内，期间模板不会被删除或修改，If-Match检查的是即将覆盖的记录
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	if profileID != "" {
+		profile, err := storage.GetThresholdProfile(profileID)
+		if err != nil {
+			return nil, fmt.Errorf("获取阈值模板失败: %w", err)
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, profileID)
+		}
+	}
+
+	stored, err := storage.GetThreshold(systemID)
+	if err != nil {
+		return nil, fmt.Errorf("获取阈值配置失败: %w", err)
+	}
+	if err := checkIfMatch(change.IfMatch, thresholdVersion(stored)); err != nil {
+		return nil, err
+	}
+
+	overrides := threshold.Overrides
+	if overrides == nil {
+		overrides = &models.ThresholdValues{}
+	}
+	if _, ok := body[thresholdPutFields[0]]; ok {
+		// decodeThresholdPut 保证顶层阈值字段此时全部提交
+		resolved, err := s.resolve(storage, systemID, &models.SystemThreshold{ProfileID: profileID, Overrides: overrides})
+		if err != nil {
+			return nil, err
+		}
+		overrides = overlayThresholdValues(overrides, diffThresholdValues(threshold, resolved))
+	}
+
+	// id、版本和时间戳以存储为准
+	record := &models.SystemThreshold{
+		SystemID:   systemID,
+		ProfileID:  profileID,
+		Overrides:  overrides,
+		NetUpMax:   threshold.NetUpMax,
+		NetDownMax: threshold.NetDownMax,
+		Version:    thresholdVersion(stored) + 1,
+	}
+	if stored != nil {
+		if _, ok := body["net_up_max"]; !ok {
+			record.NetUpMax = stored.NetUpMax
+		}
+		if _, ok := body["net_down_max"]; !ok {
+			record.NetDownMax = stored.NetDownMax
+		}
+	}
+	if err := s.saveAudited(record, stored, change); err != nil {
+		return nil, err
+	}
+	return s.GetThreshold(context.Background(), systemID)
+}
+
+// thresholdVersion 存储中的版本，尚未保存过时为0
+func thresholdVersion(stored *models.SystemThreshold) int64 {
+	if stored == nil {
+		return 0
+	}
+	return stored.Version
+}
+
+// save 保存系统阈值记录，顶层字段保存为当前生效值，sources 不落盘
+func (s *ThresholdService) save(record *models.SystemThreshold) (*models.SystemThreshold, error) {
+	return s.saveTx(database.GetStorage(), record)
+}
+
+// saveTx 在事务tx中保存阈值，模板从同一事务中读取
+func (s *ThresholdService) saveTx(tx database.ConfigTx, record *models.SystemThreshold) (*models.SystemThreshold, error) {
+	resolved, err := s.resolve(tx, record.SystemID, record)
+	if err != nil {
+		return nil, err
+	}
+	resolved.Sources = nil
+
+	if err := tx.CreateOrUpdateThreshold(resolved); err != nil {
+		return nil, fmt.Errorf("更新阈值配置失败: %w", err)
+	}
+	return resolved, nil
+}
+
+// saveAudited 保存用户发起的修改并写入审计日志
+func (s *ThresholdService) saveAudited(record, before *models.SystemThreshold, change Change) error {
+	saved, err := s.save(record)
 	if err != nil {
 		return err
 	}
+	action := "update"
+	if before == nil {
+		action = "create"
+	}
+	recordAudit(change, action, AuditThreshold, record.SystemID, saved.Version, before, saved)
+	return nil
+}
+
+// UpdateNetworkMax 更新网络最大值（用于动态更新历史极限值）。
+// 这是采集过程自动产生的修改，不改变版本，也不写审计日志。
+func (s *ThresholdService) UpdateNetworkMax(ctx context.Context, systemID string, netUpMbps, netDownMbps float64) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	stored, err := database.GetStorageContext(ctx).GetThreshold(systemID)
+	if err != nil {
+		return fmt.Errorf("获取阈值配置失败: %w", err)
+	}
+	if stored == nil {
+		// 只记录历史极限值，不产生任何系统级覆盖
+		stored = &models.SystemThreshold{SystemID: systemID, Overrides: &models.ThresholdValues{}}
+	}
 	
 	// 更新最大值（只有当新值更大时才更新）
 	updated := false
-	if netUpMbps > threshold.NetUpMax {
-		threshold.NetUpMax = netUpMbps
+	if netUpMbps > stored.NetUpMax {
+		stored.NetUpMax = netUpMbps
 		updated = true
 	}
-	if netDownMbps > threshold.NetDownMax {
-		threshold.NetDownMax = netDownMbps
+	if netDownMbps > stored.NetDownMax {
+		stored.NetDownMax = netDownMbps
 		updated = true
 	}
 	
 	if updated {
-		return storage.CreateOrUpdateThreshold(threshold)
+		// 同时把旧格式记录迁移为显式的overrides
+		stored.Overrides = systemOverrides(stored)
+		_, err := s.save(stored)
+		return err
 	}
 	
 	return nil
 }
 
-// GetAllThresholds 获取所有系统的阈值配置
+// GetAllThresholds 获取所有已保存阈值配置的系统的生效阈值
 func (s *ThresholdService) GetAllThresholds() ([]*models.SystemThreshold, error) {
-	storage := database.GetStorage()
-	return storage.ListThresholds()
+	stored, err := database.GetStorage().ListThresholds()
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds := make([]*models.SystemThreshold, 0, len(stored))
+	for _, record := range stored {
+		resolved, err := s.resolve(database.GetStorage(), record.SystemID, record)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, resolved)
+	}
+	return thresholds, nil
 }
 
-// DeleteThreshold 删除系统阈值配置
-func (s *ThresholdService) DeleteThreshold(systemID string) error {
+// DeleteThreshold 删除系统阈值配置，恢复为完全继承模板和全局默认
+func (s *ThresholdService) DeleteThreshold(systemID string, change Change) error {
 	storage := database.GetStorage()
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	stored, err := storage.GetThreshold(systemID)
+	if err != nil {
+		return fmt.Errorf("获取阈值配置失败: %w", err)
+	}
+	if err := checkIfMatch(change.IfMatch, thresholdVersion(stored)); err != nil {
+		return err
+	}
+	if stored == nil {
+		return nil
+	}
 	
 	if err := storage.DeleteThreshold(systemID); err != nil {
 		return fmt.Errorf("删除阈值配置失败: %w", err)
 	}
 	
+	recordAudit(change, "delete", AuditThreshold, systemID, 0, stored, nil)
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/pkg/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func setupThresholdStorage(t *testing.T) *ThresholdService {
+	t.Helper()
+	if err := database.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	SetThresholdDefaults(config.Default().Thresholds)
+	return NewThresholdService()
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+// putBody 把测试中的请求体转换为PUT请求体
+func putBody(t *testing.T, v any) map[string]json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestThresholdResolutionOrder(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	// 全局默认模板覆盖CPU和内存
+	if err := s.UpdateThresholdProfile(DefaultProfileID, &models.ThresholdProfile{
+		Values: models.ThresholdValues{CPUAlertLimit: floatPtr(85), MemAlertLimit: floatPtr(85)},
+	}, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	// 模板再覆盖CPU
+	relay := &models.ThresholdProfile{Name: "IEPL relay", Values: models.ThresholdValues{CPUAlertLimit: floatPtr(60)}}
+	if err := s.CreateThresholdProfile(relay, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if relay.ID != "iepl-relay" {
+		t.Fatalf("模板ID = %q, 期望 iepl-relay", relay.ID)
+	}
+	// 系统只覆盖磁盘
+	if _, err := s.UpdateThreshold("sys1", putBody(t, map[string]any{
+		"profile_id": relay.ID,
+		"overrides":  &models.ThresholdValues{DiskAlertLimit: floatPtr(70)},
+	}), Change{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetThreshold(context.Background(), "sys1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		field  string
+		value  float64
+		want   float64
+		source string
+	}{
+		{"cpu_alert_limit", got.CPUAlertLimit, 60, SourceProfile},
+		{"mem_alert_limit", got.MemAlertLimit, 85, SourceGlobal},
+		{"disk_alert_limit", got.DiskAlertLimit, 70, SourceSystem},
+		{"net_up_alert", got.NetUpAlert, 80, SourceConfig},
+	}
+	for _, c := range checks {
+		if c.value != c.want || got.Sources[c.field] != c.source {
+			t.Errorf("%s = %v (%s), 期望 %v (%s)", c.field, c.value, got.Sources[c.field], c.want, c.source)
+		}
+	}
+
+	// 修改模板后，未覆盖的字段跟随变化
+	relay.Values.CPUAlertLimit = floatPtr(50)
+	if err := s.UpdateThresholdProfile(relay.ID, relay, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.GetThreshold(context.Background(), "sys1")
+	if got.CPUAlertLimit != 50 {
+		t.Errorf("CPU阈值 = %v, 期望跟随模板变为50", got.CPUAlertLimit)
+	}
+
+	// 仍被使用的模板不能删除
+	if err := s.DeleteThresholdProfile(relay.ID, Change{}); !errors.Is(err, ErrProfileInUse) {
+		t.Errorf("删除使用中的模板: err = %v, 期望 ErrProfileInUse", err)
+	}
+}
+
+func TestDeleteProfileWhileAssigning(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	// 反复创建、分配和删除模板，删除成功时不能有系统仍引用该模板
+	for i := 0; i < 20; i++ {
+		profile := &models.ThresholdProfile{ID: "relay", Name: "relay"}
+		if err := s.CreateThresholdProfile(profile, Change{}); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func(systemID string) {
+				defer wg.Done()
+				s.UpdateThreshold(systemID, putBody(t, map[string]any{"profile_id": "relay", "overrides": map[string]any{}}), Change{})
+			}(fmt.Sprintf("sys%d", j))
+		}
+		deleted := s.DeleteThresholdProfile("relay", Change{}) == nil
+		wg.Wait()
+
+		thresholds, err := database.GetStorage().ListThresholds()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, threshold := range thresholds {
+			if deleted && threshold.ProfileID == "relay" {
+				t.Fatalf("模板已删除，系统 %s 仍引用该模板", threshold.SystemID)
+			}
+		}
+		// 下一轮前让所有系统改回全局默认并删除模板
+		for _, threshold := range thresholds {
+			if _, err := s.UpdateThreshold(threshold.SystemID, putBody(t, map[string]any{"overrides": map[string]any{}}), Change{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !deleted {
+			if err := s.DeleteThresholdProfile("relay", Change{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestUpdateThresholdLegacyBody(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	if err := s.UpdateThresholdProfile(DefaultProfileID, &models.ThresholdProfile{
+		Values: models.ThresholdValues{MemAlertLimit: floatPtr(75)},
+	}, Change{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版客户端提交完整字段，只有与继承值不同的CPU成为覆盖
+	body := DefaultThreshold("sys1")
+	body.MemAlertLimit = 75
+	body.CPUAlertLimit = 95
+	if _, err := s.UpdateThreshold("sys1", putBody(t, body), Change{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetThreshold(context.Background(), "sys1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Sources["cpu_alert_limit"] != SourceSystem || got.Sources["mem_alert_limit"] != SourceGlobal {
+		t.Errorf("sources = %v", got.Sources)
+	}
+	if got.Overrides == nil || got.Overrides.MemAlertLimit != nil || got.Overrides.CPUAlertLimit == nil {
+		t.Errorf("overrides = %+v", got.Overrides)
+	}
+}
+
+func TestUpdateThresholdRoundTrip(t *testing.T) {
+	s := setupThresholdStorage(t)
+	ctx := context.Background()
+
+	// 采集过程为系统创建了带空 overrides 的记录
+	if err := s.UpdateNetworkMax(ctx, "sys1", 100, 200); err != nil {
+		t.Fatal(err)
+	}
+
+	// 前端把GET的响应修改后整体提交
+	edit := func(field string, value float64) *models.SystemThreshold {
+		t.Helper()
+		current, err := s.GetThreshold(ctx, "sys1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := putBody(t, current)
+		body[field] = json.RawMessage(fmt.Sprint(value))
+		got, err := s.UpdateThreshold("sys1", body, Change{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := edit("cpu_alert_limit", 50)
+	if got.CPUAlertLimit != 50 || got.Sources["cpu_alert_limit"] != SourceSystem {
+		t.Errorf("CPU阈值 = %v (%s), 期望系统覆盖50", got.CPUAlertLimit, got.Sources["cpu_alert_limit"])
+	}
+	got = edit("mem_alert_limit", 60)
+	if got.CPUAlertLimit != 50 || got.MemAlertLimit != 60 {
+		t.Errorf("第二次修改后 CPU = %v, 内存 = %v, 期望 50 和 60", got.CPUAlertLimit, got.MemAlertLimit)
+	}
+	if got.Sources["net_up_alert"] != SourceConfig || got.NetUpMax != 100 {
+		t.Errorf("未修改的字段应继续继承: sources = %v, net_up_max = %v", got.Sources, got.NetUpMax)
+	}
+}
+
+func TestUpdateThresholdRequiresAllFields(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	// 只提交一个字段时不能把其余字段保存为0
+	_, err := s.UpdateThreshold("sys1", putBody(t, map[string]any{"cpu_alert_limit": 70}), Change{})
+	var fieldErrs FieldErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("err = %v, 期望 FieldErrors", err)
+	}
+	for _, field := range []string{"mem_alert_limit", "net_up_alert", "online_users_limit"} {
+		if fieldErrs[field] == "" {
+			t.Errorf("缺少 %s 时应报错: %v", field, fieldErrs)
+		}
+	}
+	if _, ok := fieldErrs["cpu_alert_limit"]; ok {
+		t.Errorf("已提交的字段不应报错: %v", fieldErrs)
+	}
+
+	// 带 overrides 时顶层字段要么全部提交，要么都不提交
+	_, err = s.UpdateThreshold("sys1", putBody(t, map[string]any{"cpu_alert_limit": 70, "overrides": map[string]any{}}), Change{})
+	if !errors.As(err, &fieldErrs) || fieldErrs["mem_alert_limit"] == "" {
+		t.Errorf("err = %v, 期望 mem_alert_limit 的 FieldErrors", err)
+	}
+	if got, _ := s.GetThreshold(context.Background(), "sys1"); got.Version != 0 {
+		t.Fatalf("校验失败时不应写入, version = %d", got.Version)
+	}
+
+	// 未提交 net_up_max/net_down_max 时保留学习到的值，提交0时重置
+	if err := s.UpdateNetworkMax(context.Background(), "sys1", 100, 200); err != nil {
+		t.Fatal(err)
+	}
+	body := putBody(t, DefaultThreshold("sys1"))
+	delete(body, "net_up_max")
+	got, err := s.UpdateThreshold("sys1", body, Change{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.NetUpMax != 100 || got.NetDownMax != 0 {
+		t.Errorf("net_up_max = %v, net_down_max = %v, 期望 100 和 0", got.NetUpMax, got.NetDownMax)
+	}
+}
+
+func TestLegacyThresholdRecord(t *testing.T) {
+	s := setupThresholdStorage(t)
+	SetThresholdDefaults(config.ThresholdConfig{CPUAlertLimit: 70, MemAlertLimit: 70, NetUpAlert: 80, NetDownAlert: 80, DiskAlertLimit: 90})
+
+	// 引入模板之前保存的记录：除内存外均为旧默认值
+	legacy := &models.SystemThreshold{
+		SystemID: "old", CPUAlertLimit: 90, MemAlertLimit: 50,
+		NetUpAlert: 80, NetDownAlert: 80, DiskAlertLimit: 90,
+	}
+	if err := database.GetStorage().CreateOrUpdateThreshold(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetThreshold(context.Background(), "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CPUAlertLimit != 70 || got.Sources["cpu_alert_limit"] != SourceConfig {
+		t.Errorf("CPU阈值 = %v (%s), 期望跟随配置为70", got.CPUAlertLimit, got.Sources["cpu_alert_limit"])
+	}
+	if got.MemAlertLimit != 50 || got.Sources["mem_alert_limit"] != SourceSystem {
+		t.Errorf("内存阈值 = %v (%s), 期望保留系统覆盖50", got.MemAlertLimit, got.Sources["mem_alert_limit"])
+	}
+}
+
+func TestPatchThreshold(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	limit := 300
+	if _, err := s.UpdateThreshold("sys1", putBody(t, map[string]any{
+		"overrides": &models.ThresholdValues{MemAlertLimit: floatPtr(70), OnlineUsersLimit: &limit},
+	}), Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateNetworkMax(context.Background(), "sys1", 100, 200); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := s.GetThreshold(context.Background(), "sys1")
+
+	patch := map[string]json.RawMessage{
+		"cpu_alert_limit": json.RawMessage(`75`),
+		"mem_alert_limit": json.RawMessage(`null`),
+		"id":              json.RawMessage(`1`),
+		"created_at":      json.RawMessage(`"2000-01-01T00:00:00Z"`),
+	}
+	got, err := s.PatchThreshold("sys1", patch, Change{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CPUAlertLimit != 75 || got.Sources["cpu_alert_limit"] != SourceSystem {
+		t.Errorf("CPU阈值 = %v (%s), 期望系统覆盖75", got.CPUAlertLimit, got.Sources["cpu_alert_limit"])
+	}
+	if got.MemAlertLimit != 90 || got.Sources["mem_alert_limit"] != SourceConfig {
+		t.Errorf("内存阈值 = %v (%s), 期望null恢复继承值90", got.MemAlertLimit, got.Sources["mem_alert_limit"])
+	}
+	// 未出现的字段和学习到的网络最大值保持不变
+	if got.OnlineUsersLimit != 300 || got.NetUpMax != 100 || got.NetDownMax != 200 {
+		t.Errorf("未修改的字段被改变: %+v", got)
+	}
+	if got.ID != before.ID || !got.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("id/created_at 被客户端覆盖: %v %v", got.ID, got.CreatedAt)
+	}
+}
+
+func TestPatchThresholdFieldErrors(t *testing.T) {
+	s := setupThresholdStorage(t)
+
+	_, err := s.PatchThreshold("sys1", map[string]json.RawMessage{
+		"cpu_alert_limit":    json.RawMessage(`150`),
+		"online_users_limit": json.RawMessage(`1.5`),
+		"net_up_max":         json.RawMessage(`-1`),
+		"profile_id":         json.RawMessage(`"missing"`),
+		"disk_alert_limit":   json.RawMessage(`50`),
+		"bogus":              json.RawMessage(`1`),
+	}, Change{})
+	var fieldErrs FieldErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("err = %v, 期望 FieldErrors", err)
+	}
+	for _, field := range []string{"cpu_alert_limit", "online_users_limit", "net_up_max", "profile_id", "bogus"} {
+		if fieldErrs[field] == "" {
+			t.Errorf("缺少字段 %s 的错误: %v", field, fieldErrs)
+		}
+	}
+	if _, ok := fieldErrs["disk_alert_limit"]; ok {
+		t.Errorf("合法字段不应报错: %v", fieldErrs)
+	}
+
+	// 校验失败时不写入任何字段
+	if stored, _ := database.GetStorage().GetThreshold("sys1"); stored != nil {
+		t.Errorf("校验失败后仍写入了阈值: %+v", stored)
+	}
+}
+
+func TestValidateThreshold(t *testing.T) {
+	threshold := DefaultThreshold("sys1")
+	threshold.OnlineUsersLimit = -1
+	threshold.NetDownMax = -5
+	threshold.Overrides = &models.ThresholdValues{SwapAlertLimit: floatPtr(101)}
+
+	var fieldErrs FieldErrors
+	if err := ValidateThreshold(threshold); !errors.As(err, &fieldErrs) || len(fieldErrs) != 3 {
+		t.Fatalf("err = %v, 期望3个字段错误", err)
+	}
+	if fieldErrs["overrides.swap_alert_limit"] == "" {
+		t.Errorf("缺少 overrides.swap_alert_limit 错误: %v", fieldErrs)
+	}
+	if err := ValidateThreshold(DefaultThreshold("sys1")); err != nil {
+		t.Errorf("默认阈值校验失败: %v", err)
+	}
+}
@@ -0,0 +1,584 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/internal/logging"
+	"backend/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// 导入模式
+const (
+	ImportMerge   = "merge"   // 只新增和更新导入文件中出现的配置
+	ImportReplace = "replace" // 使本地配置与导入文件完全一致，多余的配置会被删除
+)
+
+// 系统匹配方式
+const (
+	MatchAuto = "auto" // 先按ID匹配，找不到再按名称匹配
+	MatchID   = "id"
+	MatchName = "name"
+)
+
+// 导入变更动作
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+	ActionSkip      = "skip"
+)
+
+// exportVersion 导出格式版本
+const exportVersion = 1
+
+// ErrInvalidImport 导入数据或选项不合法
+var ErrInvalidImport = errors.New("导入数据无效")
+
+// TransferService 本地配置的批量导入导出
+type TransferService struct {
+	thresholds  *ThresholdService
+	listSystems func(context.Context) ([]*models.System, error)
+}
+
+// NewTransferService 创建导入导出服务，系统列表用于按名称匹配和导出系统名称
+func NewTransferService(systemService *SystemService) *TransferService {
+	return &TransferService{
+		thresholds:  NewThresholdService(),
+		listSystems: systemService.GetSystems,
+	}
+}
+
+// localState 本地存储中的全部配置
+type localState struct {
+	profiles   map[string]*models.ThresholdProfile
+	thresholds map[string]*models.SystemThreshold
+	aliases    map[string]*models.SystemAlias
+	tags       map[string][]*models.NodeTag
+}
+
+func loadLocalState() (*localState, error) {
+	storage := database.GetStorage()
+	state := &localState{
+		profiles:   make(map[string]*models.ThresholdProfile),
+		thresholds: make(map[string]*models.SystemThreshold),
+		aliases:    make(map[string]*models.SystemAlias),
+		tags:       make(map[string][]*models.NodeTag),
+	}
+
+	profiles, err := storage.ListThresholdProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("获取阈值模板失败: %w", err)
+	}
+	for _, p := range profiles {
+		state.profiles[p.ID] = p
+	}
+	thresholds, err := storage.ListThresholds()
+	if err != nil {
+		return nil, fmt.Errorf("获取阈值配置失败: %w", err)
+	}
+	for _, t := range thresholds {
+		state.thresholds[t.SystemID] = t
+	}
+	aliases, err := storage.GetAllSystemAliases()
+	if err != nil {
+		return nil, fmt.Errorf("获取别名失败: %w", err)
+	}
+	for _, a := range aliases {
+		state.aliases[a.SystemID] = a
+	}
+	tags, err := storage.ListNodeTags()
+	if err != nil {
+		return nil, fmt.Errorf("获取节点标签失败: %w", err)
+	}
+	for _, t := range tags {
+		state.tags[t.SystemID] = append(state.tags[t.SystemID], t)
+	}
+	return state, nil
+}
+
+// Export 导出所有本地配置，能获取到系统列表时附带系统名称，以便在重建的Hub上按名称导入
+func (s *TransferService) Export(ctx context.Context) (*models.ExportBundle, error) {
+	state, err := loadLocalState()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	if systems, err := s.listSystems(ctx); err != nil {
+		slog.WarnContext(ctx, "导出时获取系统列表失败，导出文件将不含系统名称", "op", "config.export", logging.Err(err))
+	} else {
+		for _, system := range systems {
+			names[system.ID] = system.Name
+		}
+	}
+
+	entries := make(map[string]*models.SystemExport)
+	entry := func(systemID string) *models.SystemExport {
+		e, ok := entries[systemID]
+		if !ok {
+			e = &models.SystemExport{SystemID: systemID, SystemName: names[systemID]}
+			entries[systemID] = e
+		}
+		return e
+	}
+	for systemID, t := range state.thresholds {
+		entry(systemID).Threshold = &models.ThresholdExport{
+			ProfileID:  t.ProfileID,
+			Overrides:  systemOverrides(t),
+			NetUpMax:   t.NetUpMax,
+			NetDownMax: t.NetDownMax,
+		}
+	}
+	for systemID, a := range state.aliases {
+		entry(systemID).Alias = a.Alias
+	}
+	for systemID, tags := range state.tags {
+		e := entry(systemID)
+		for _, t := range tags {
+			e.NodeTags = append(e.NodeTags, models.NodeTagRef{Type: t.TagType, ID: t.TagID})
+		}
+		sortNodeTagRefs(e.NodeTags)
+	}
+
+	bundle := &models.ExportBundle{
+		Version:    exportVersion,
+		ExportedAt: time.Now(),
+		Profiles:   make([]*models.ThresholdProfile, 0, len(state.profiles)),
+		Systems:    make([]*models.SystemExport, 0, len(entries)),
+	}
+	for _, p := range state.profiles {
+		bundle.Profiles = append(bundle.Profiles, p)
+	}
+	sort.Slice(bundle.Profiles, func(i, j int) bool { return bundle.Profiles[i].ID < bundle.Profiles[j].ID })
+	for _, e := range entries {
+		bundle.Systems = append(bundle.Systems, e)
+	}
+	sort.Slice(bundle.Systems, func(i, j int) bool { return bundle.Systems[i].SystemID < bundle.Systems[j].SystemID })
+	return bundle, nil
+}
+
+// importOp 一项导入变更及其写入操作，audited 的变更写入后记录修改前后的值
+type importOp struct {
+	change        models.ImportChange
+	apply         func(tx database.ConfigTx) error
+	audited       bool
+	before, after interface{}
+}
+
+// importPlan 导入计划，按写入顺序排列
+type importPlan struct {
+	ops []importOp
+}
+
+func (p *importPlan) add(change models.ImportChange, apply func(tx database.ConfigTx) error) {
+	p.ops = append(p.ops, importOp{change: change, apply: apply})
+}
+
+// addAudited 添加需要审计的变更，after 可以是apply写入时更新的指针
+func (p *importPlan) addAudited(change models.ImportChange, before, after interface{}, apply func(tx database.ConfigTx) error) {
+	p.ops = append(p.ops, importOp{change: change, apply: apply, audited: true, before: before, after: after})
+}
+
+// importAuditResources 导入变更类型对应的审计资源类型
+var importAuditResources = map[string]string{
+	"profile":   AuditThresholdProfile,
+	"threshold": AuditThreshold,
+	"alias":     AuditAlias,
+	"node_tag":  AuditNodeTag,
+}
+
+// Import 导入配置。先校验并生成完整的变更计划，dry-run时只返回计划；
+// 校验失败时不写入任何数据，计划在一个事务中写入，写入失败时同样不留下部分修改。
+func (s *TransferService) Import(ctx context.Context, bundle *models.ExportBundle, opts models.ImportOptions) (*models.ImportResult, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportMerge
+	}
+	if opts.Match == "" {
+		opts.Match = MatchAuto
+	}
+	if opts.Mode != ImportMerge && opts.Mode != ImportReplace {
+		return nil, fmt.Errorf("%w: 不支持的导入模式 %q", ErrInvalidImport, opts.Mode)
+	}
+	if opts.Match != MatchAuto && opts.Match != MatchID && opts.Match != MatchName {
+		return nil, fmt.Errorf("%w: 不支持的匹配方式 %q", ErrInvalidImport, opts.Match)
+	}
+
+	result := &models.ImportResult{
+		Mode:    opts.Mode,
+		Match:   opts.Match,
+		DryRun:  opts.DryRun,
+		Summary: make(map[string]int),
+		Changes: []models.ImportChange{},
+	}
+
+	systems, err := s.listSystems(ctx)
+	if err != nil {
+		if opts.Match != MatchID {
+			return nil, fmt.Errorf("获取系统列表失败，无法按名称匹配: %w", err)
+		}
+		// 按ID匹配时允许离线导入，ID原样使用
+		systems = nil
+		result.Warnings = append(result.Warnings, fmt.Sprintf("获取系统列表失败，系统ID未经校验: %v", err))
+	}
+
+	// 从读取本地配置到写入完成期间不允许其他修改，保证变更计划与实际写入一致
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	state, err := loadLocalState()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateBundle(bundle, state, opts.Mode); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	plan := &importPlan{}
+	s.planProfiles(plan, bundle, state)
+	targets := s.planSystems(plan, bundle, state, systems, opts)
+	if opts.Mode == ImportReplace {
+		s.planReplaceDeletes(plan, bundle, state, targets)
+	}
+
+	for _, op := range plan.ops {
+		result.Changes = append(result.Changes, op.change)
+		result.Summary[op.change.Action]++
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// 整个计划在一个事务中写入，任一项失败时不写入任何修改
+	err = database.GetStorageContext(ctx).UpdateConfig(func(tx database.ConfigTx) error {
+		for _, op := range plan.ops {
+			if op.apply == nil {
+				continue
+			}
+			if err := op.apply(tx); err != nil {
+				return fmt.Errorf("导入%s %s 失败: %w", op.change.Kind, op.change.Target, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("导入失败，未写入任何修改: %w", err)
+	}
+
+	actor := Change{Actor: opts.Actor}
+	for _, op := range plan.ops {
+		if op.audited {
+			recordAudit(actor, op.change.Action, importAuditResources[op.change.Kind], op.change.Target,
+				auditVersion(op.after), op.before, op.after)
+		}
+	}
+	recordImportAudit(actor, result)
+	return result, nil
+}
+
+// validateBundle 校验导入文件，所有问题一次性返回
+func validateBundle(bundle *models.ExportBundle, state *localState, mode string) error {
+	var errs []error
+	if bundle.Version != exportVersion {
+		errs = append(errs, fmt.Errorf("不支持的导出格式版本: %d", bundle.Version))
+	}
+
+	profileIDs := make(map[string]bool)
+	for i, p := range bundle.Profiles {
+		if p == nil {
+			errs = append(errs, fmt.Errorf("threshold_profiles[%d]: 不能为空", i))
+			continue
+		}
+		if !profileIDPattern.MatchString(p.ID) {
+			errs = append(errs, fmt.Errorf("threshold_profiles[%d]: 非法的模板ID %q", i, p.ID))
+		}
+		if profileIDs[p.ID] {
+			errs = append(errs, fmt.Errorf("threshold_profiles[%d]: 模板ID %q 重复", i, p.ID))
+		}
+		profileIDs[p.ID] = true
+		if err := ValidateThresholdValues(&p.Values); err != nil {
+			errs = append(errs, fmt.Errorf("threshold_profiles[%d]: %w", i, err))
+		}
+	}
+
+	for i, e := range bundle.Systems {
+		if e == nil {
+			errs = append(errs, fmt.Errorf("systems[%d]: 不能为空", i))
+			continue
+		}
+		if e.SystemID == "" && e.SystemName == "" {
+			errs = append(errs, fmt.Errorf("systems[%d]: system_id 和 system_name 不能同时为空", i))
+		}
+		if t := e.Threshold; t != nil {
+			if err := ValidateThresholdValues(t.Overrides); err != nil {
+				errs = append(errs, fmt.Errorf("systems[%d]: %w", i, err))
+			}
+			if t.NetUpMax < 0 || t.NetDownMax < 0 {
+				errs = append(errs, fmt.Errorf("systems[%d]: 网络最大值不能为负数", i))
+			}
+			// replace模式下不在导入文件中的模板会被删除，引用必须指向导入文件中的模板
+			if id := t.ProfileID; id != "" && id != DefaultProfileID && !profileIDs[id] &&
+				(mode == ImportReplace || state.profiles[id] == nil) {
+				errs = append(errs, fmt.Errorf("systems[%d]: 阈值模板 %q 不存在", i, id))
+			}
+		}
+		for _, tag := range e.NodeTags {
+			if tag.Type == "" {
+				errs = append(errs, fmt.Errorf("systems[%d]: 节点类型不能为空", i))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// planProfiles 生成模板的新增和更新
+func (s *TransferService) planProfiles(plan *importPlan, bundle *models.ExportBundle, state *localState) {
+	for _, p := range bundle.Profiles {
+		change := models.ImportChange{Kind: "profile", Target: p.ID, Name: p.Name}
+		existing := state.profiles[p.ID]
+		switch {
+		case existing == nil:
+			change.Action = ActionCreate
+		case existing.Name == p.Name && existing.Description == p.Description && reflect.DeepEqual(existing.Values, p.Values):
+			change.Action = ActionUnchanged
+			plan.add(change, nil)
+			continue
+		default:
+			change.Action = ActionUpdate
+		}
+
+		profile := &models.ThresholdProfile{ID: p.ID, Name: p.Name, Description: p.Description, Values: p.Values}
+		plan.addAudited(change, existing, profile, func(tx database.ConfigTx) error { return tx.CreateOrUpdateThresholdProfile(profile) })
+	}
+}
+
+// planSystems 匹配系统并生成阈值、别名、节点标签的变更，返回已匹配的本地系统ID
+func (s *TransferService) planSystems(plan *importPlan, bundle *models.ExportBundle, state *localState, systems []*models.System, opts models.ImportOptions) map[string]bool {
+	byID := make(map[string]*models.System)
+	byName := make(map[string][]*models.System)
+	for _, system := range systems {
+		byID[system.ID] = system
+		byName[system.Name] = append(byName[system.Name], system)
+	}
+
+	targets := make(map[string]bool)
+	for _, e := range bundle.Systems {
+		target, detail := matchImportSystem(e, byID, byName, systems != nil, opts.Match)
+		if target != "" && targets[target] {
+			target, detail = "", "与前面的条目匹配到同一系统"
+		}
+		if target == "" {
+			plan.add(models.ImportChange{
+				Kind: "system", Action: ActionSkip, SourceID: e.SystemID, Name: e.SystemName, Detail: detail,
+			}, nil)
+			continue
+		}
+		targets[target] = true
+
+		s.planThreshold(plan, e, target, state, opts.Mode)
+		planAlias(plan, e, target, state, opts.Mode)
+		planNodeTags(plan, e, target, state, opts.Mode)
+	}
+	return targets
+}
+
+// matchImportSystem 按匹配方式查找导入条目对应的本地系统，找不到时返回原因
+func matchImportSystem(e *models.SystemExport, byID map[string]*models.System, byName map[string][]*models.System, known bool, match string) (string, string) {
+	if match != MatchName && e.SystemID != "" {
+		if !known || byID[e.SystemID] != nil {
+			return e.SystemID, ""
+		}
+	}
+	if match != MatchID && e.SystemName != "" {
+		switch candidates := byName[e.SystemName]; len(candidates) {
+		case 0:
+		case 1:
+			return candidates[0].ID, ""
+		default:
+			return "", fmt.Sprintf("名称 %q 对应多个系统", e.SystemName)
+		}
+	}
+	return "", "未找到匹配的系统"
+}
+
+func (s *TransferService) planThreshold(plan *importPlan, e *models.SystemExport, target string, state *localState, mode string) {
+	existing := state.thresholds[target]
+	change := models.ImportChange{Kind: "threshold", Target: target, SourceID: e.SystemID, Name: e.SystemName}
+
+	if e.Threshold == nil {
+		if mode == ImportReplace && existing != nil {
+			change.Action = ActionDelete
+			plan.addAudited(change, existing, nil, func(tx database.ConfigTx) error { return tx.DeleteThreshold(target) })
+		}
+		return
+	}
+
+	record := &models.SystemThreshold{
+		SystemID:   target,
+		ProfileID:  e.Threshold.ProfileID,
+		Overrides:  e.Threshold.Overrides,
+		NetUpMax:   e.Threshold.NetUpMax,
+		NetDownMax: e.Threshold.NetDownMax,
+	}
+	if record.ProfileID == DefaultProfileID {
+		record.ProfileID = ""
+	}
+	if record.Overrides == nil {
+		record.Overrides = &models.ThresholdValues{}
+	}
+	record.Version = thresholdVersion(existing) + 1
+
+	switch {
+	case existing == nil:
+		change.Action = ActionCreate
+	case existing.ProfileID == record.ProfileID && reflect.DeepEqual(systemOverrides(existing), record.Overrides) &&
+		existing.NetUpMax == record.NetUpMax && existing.NetDownMax == record.NetDownMax:
+		change.Action = ActionUnchanged
+		plan.add(change, nil)
+		return
+	default:
+		change.Action = ActionUpdate
+	}
+	plan.addAudited(change, existing, record, func(tx database.ConfigTx) error {
+		saved, err := s.thresholds.saveTx(tx, record)
+		if err == nil {
+			*record = *saved
+		}
+		return err
+	})
+}
+
+func planAlias(plan *importPlan, e *models.SystemExport, target string, state *localState, mode string) {
+	existing := state.aliases[target]
+	change := models.ImportChange{Kind: "alias", Target: target, SourceID: e.SystemID, Name: e.Alias}
+
+	if e.Alias == "" {
+		if mode == ImportReplace && existing != nil {
+			change.Action = ActionDelete
+			change.Name = existing.Alias
+			plan.addAudited(change, existing, nil, func(tx database.ConfigTx) error { return tx.DeleteSystemAlias(target) })
+		}
+		return
+	}
+
+	switch {
+	case existing == nil:
+		change.Action = ActionCreate
+	case existing.Alias == e.Alias:
+		change.Action = ActionUnchanged
+		plan.add(change, nil)
+		return
+	default:
+		change.Action = ActionUpdate
+		change.Detail = "原别名: " + existing.Alias
+	}
+	alias := &models.SystemAlias{SystemID: target, Alias: e.Alias, Version: aliasVersion(existing) + 1}
+	plan.addAudited(change, existing, alias, func(tx database.ConfigTx) error { return tx.SetSystemAlias(alias) })
+}
+
+func planNodeTags(plan *importPlan, e *models.SystemExport, target string, state *localState, mode string) {
+	existing := make(map[models.NodeTagRef]bool)
+	for _, t := range state.tags[target] {
+		existing[models.NodeTagRef{Type: t.TagType, ID: t.TagID}] = true
+	}
+
+	wanted := make(map[models.NodeTagRef]bool)
+	for _, ref := range e.NodeTags {
+		if wanted[ref] {
+			continue
+		}
+		wanted[ref] = true
+		change := models.ImportChange{Kind: "node_tag", Target: target, SourceID: e.SystemID, Name: nodeTagName(ref)}
+		if existing[ref] {
+			change.Action = ActionUnchanged
+			plan.add(change, nil)
+			continue
+		}
+		change.Action = ActionCreate
+		tag := &models.NodeTag{SystemID: target, TagType: ref.Type, TagID: ref.ID}
+		plan.addAudited(change, nil, tag, func(tx database.ConfigTx) error { return tx.CreateNodeTag(tag) })
+	}
+
+	if mode != ImportReplace {
+		return
+	}
+	for _, t := range state.tags[target] {
+		ref := models.NodeTagRef{Type: t.TagType, ID: t.TagID}
+		if wanted[ref] {
+			continue
+		}
+		tag := t
+		plan.addAudited(models.ImportChange{Kind: "node_tag", Action: ActionDelete, Target: target, SourceID: e.SystemID, Name: nodeTagName(ref)},
+			tag, nil, func(tx database.ConfigTx) error { return tx.DeleteNodeTag(tag.SystemID, tag.TagType, tag.TagID) })
+	}
+}
+
+// planReplaceDeletes replace模式下删除导入文件中没有的系统配置和模板
+func (s *TransferService) planReplaceDeletes(plan *importPlan, bundle *models.ExportBundle, state *localState, targets map[string]bool) {
+	for _, systemID := range sortedKeys(state.thresholds) {
+		if !targets[systemID] {
+			id := systemID
+			plan.addAudited(models.ImportChange{Kind: "threshold", Action: ActionDelete, Target: id},
+				state.thresholds[id], nil, func(tx database.ConfigTx) error { return tx.DeleteThreshold(id) })
+		}
+	}
+	for _, systemID := range sortedKeys(state.aliases) {
+		if !targets[systemID] {
+			id := systemID
+			plan.addAudited(models.ImportChange{Kind: "alias", Action: ActionDelete, Target: id, Name: state.aliases[id].Alias},
+				state.aliases[id], nil, func(tx database.ConfigTx) error { return tx.DeleteSystemAlias(id) })
+		}
+	}
+	for _, systemID := range sortedKeys(state.tags) {
+		if targets[systemID] {
+			continue
+		}
+		for _, t := range state.tags[systemID] {
+			tag := t
+			plan.addAudited(models.ImportChange{Kind: "node_tag", Action: ActionDelete, Target: tag.SystemID,
+				Name: nodeTagName(models.NodeTagRef{Type: tag.TagType, ID: tag.TagID})},
+				tag, nil, func(tx database.ConfigTx) error { return tx.DeleteNodeTag(tag.SystemID, tag.TagType, tag.TagID) })
+		}
+	}
+
+	// 模板最后删除，此时引用它们的系统阈值已经更新或删除
+	keep := make(map[string]bool)
+	for _, p := range bundle.Profiles {
+		keep[p.ID] = true
+	}
+	for _, profileID := range sortedKeys(state.profiles) {
+		if !keep[profileID] {
+			id := profileID
+			plan.addAudited(models.ImportChange{Kind: "profile", Action: ActionDelete, Target: id, Name: state.profiles[id].Name},
+				state.profiles[id], nil, func(tx database.ConfigTx) error { return tx.DeleteThresholdProfile(id) })
+		}
+	}
+}
+
+func nodeTagName(ref models.NodeTagRef) string {
+	return fmt.Sprintf("%s:%d", ref.Type, ref.ID)
+}
+
+func sortNodeTagRefs(refs []models.NodeTagRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Type != refs[j].Type {
+			return refs[i].Type < refs[j].Type
+		}
+		return refs[i].ID < refs[j].ID
+	})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"backend/pkg/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSV导出格式：每行一个模板（kind=profile）或一个系统（kind=system）。
+// 模板行的 id/name 为模板ID和名称，系统行为系统ID和名称；
+// 阈值列留空表示不覆盖，node_tags 形如 "v2ray:12;ss:3"。
+var csvColumns = []string{
+	"kind", "id", "name", "alias", "profile_id", "description",
+	"cpu_alert_limit", "mem_alert_limit", "net_up_alert", "net_down_alert",
+	"disk_alert_limit", "swap_alert_limit", "online_users_limit",
+	"net_up_max", "net_down_max", "node_tags",
+}
+
+// csvValueColumns 阈值列及其对应的字段
+var csvValueColumns = []struct {
+	name string
+	get  func(v *models.ThresholdValues) **float64
+}{
+	{"cpu_alert_limit", func(v *models.ThresholdValues) **float64 { return &v.CPUAlertLimit }},
+	{"mem_alert_limit", func(v *models.ThresholdValues) **float64 { return &v.MemAlertLimit }},
+	{"net_up_alert", func(v *models.ThresholdValues) **float64 { return &v.NetUpAlert }},
+	{"net_down_alert", func(v *models.ThresholdValues) **float64 { return &v.NetDownAlert }},
+	{"disk_alert_limit", func(v *models.ThresholdValues) **float64 { return &v.DiskAlertLimit }},
+	{"swap_alert_limit", func(v *models.ThresholdValues) **float64 { return &v.SwapAlertLimit }},
+}
+
+// EncodeCSV 将导出包写为CSV
+func EncodeCSV(w io.Writer, bundle *models.ExportBundle) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+
+	for _, p := range bundle.Profiles {
+		row := map[string]string{"kind": "profile", "id": p.ID, "name": p.Name, "description": p.Description}
+		writeCSVValues(row, &p.Values)
+		if err := cw.Write(csvRow(row)); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range bundle.Systems {
+		row := map[string]string{"kind": "system", "id": e.SystemID, "name": e.SystemName, "alias": e.Alias}
+		if t := e.Threshold; t != nil {
+			row["profile_id"] = t.ProfileID
+			writeCSVValues(row, t.Overrides)
+			row["net_up_max"] = formatCSVFloat(t.NetUpMax)
+			row["net_down_max"] = formatCSVFloat(t.NetDownMax)
+		}
+		var tags []string
+		for _, ref := range e.NodeTags {
+			tags = append(tags, nodeTagName(ref))
+		}
+		row["node_tags"] = strings.Join(tags, ";")
+		if err := cw.Write(csvRow(row)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// DecodeCSV 解析CSV导入文件，列按表头名称识别，顺序和缺省的列不影响解析
+func DecodeCSV(r io.Reader) (*models.ExportBundle, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: CSV文件为空", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"kind", "id"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("%w: CSV缺少 %s 列", ErrInvalidImport, required)
+		}
+	}
+
+	bundle := &models.ExportBundle{Version: exportVersion}
+	var errs []error
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		get := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch kind := get("kind"); kind {
+		case "profile":
+			p := &models.ThresholdProfile{ID: get("id"), Name: get("name"), Description: get("description")}
+			if err := readCSVValues(get, &p.Values); err != nil {
+				errs = append(errs, fmt.Errorf("第%d行: %w", line, err))
+			}
+			bundle.Profiles = append(bundle.Profiles, p)
+		case "system":
+			e, err := readCSVSystem(get)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("第%d行: %w", line, err))
+			}
+			bundle.Systems = append(bundle.Systems, e)
+		default:
+			errs = append(errs, fmt.Errorf("第%d行: 未知的kind %q", line, kind))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	return bundle, nil
+}
+
+func readCSVSystem(get func(string) string) (*models.SystemExport, error) {
+	e := &models.SystemExport{SystemID: get("id"), SystemName: get("name"), Alias: get("alias")}
+
+	overrides := &models.ThresholdValues{}
+	if err := readCSVValues(get, overrides); err != nil {
+		return e, err
+	}
+	netUpMax, err := parseCSVFloat(get("net_up_max"))
+	if err != nil {
+		return e, fmt.Errorf("net_up_max: %w", err)
+	}
+	netDownMax, err := parseCSVFloat(get("net_down_max"))
+	if err != nil {
+		return e, fmt.Errorf("net_down_max: %w", err)
+	}
+	// 模板、覆盖和网络最大值都为空时视为没有阈值配置
+	if get("profile_id") != "" || !isEmptyValues(overrides) || netUpMax != 0 || netDownMax != 0 {
+		e.Threshold = &models.ThresholdExport{
+			ProfileID:  get("profile_id"),
+			Overrides:  overrides,
+			NetUpMax:   netUpMax,
+			NetDownMax: netDownMax,
+		}
+	}
+
+	if tags := get("node_tags"); tags != "" {
+		for _, item := range strings.Split(tags, ";") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			tagType, rawID, ok := strings.Cut(item, ":")
+			id, err := strconv.Atoi(rawID)
+			if !ok || err != nil {
+				return e, fmt.Errorf("非法的节点标签 %q，应为 类型:ID", item)
+			}
+			e.NodeTags = append(e.NodeTags, models.NodeTagRef{Type: tagType, ID: id})
+		}
+	}
+	return e, nil
+}
+
+// isEmptyValues 阈值字段是否全部未设置
+func isEmptyValues(v *models.ThresholdValues) bool {
+	return *v == models.ThresholdValues{}
+}
+
+func writeCSVValues(row map[string]string, values *models.ThresholdValues) {
+	if values == nil {
+		return
+	}
+	for _, col := range csvValueColumns {
+		if v := *col.get(values); v != nil {
+			row[col.name] = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	}
+	if values.OnlineUsersLimit != nil {
+		row["online_users_limit"] = strconv.Itoa(*values.OnlineUsersLimit)
+	}
+}
+
+func readCSVValues(get func(string) string, values *models.ThresholdValues) error {
+	for _, col := range csvValueColumns {
+		raw := get(col.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s: 非法的数值 %q", col.name, raw)
+		}
+		*col.get(values) = &v
+	}
+	if raw := get("online_users_limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("online_users_limit: 非法的整数 %q", raw)
+		}
+		values.OnlineUsersLimit = &v
+	}
+	return nil
+}
+
+func csvRow(values map[string]string) []string {
+	row := make([]string, len(csvColumns))
+	for i, name := range csvColumns {
+		row[i] = values[name]
+	}
+	return row
+}
+
+// formatCSVFloat 网络最大值为0表示尚未记录，输出为空
+func formatCSVFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func parseCSVFloat(raw string) (float64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
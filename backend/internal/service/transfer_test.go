@@ -0,0 +1,192 @@
+package service
+
+import (
+	"backend/internal/database"
+	"backend/pkg/models"
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestTransferService(systems ...*models.System) *TransferService {
+	return &TransferService{
+		thresholds:  NewThresholdService(),
+		listSystems: func(context.Context) ([]*models.System, error) { return systems, nil },
+	}
+}
+
+// seedTransferState 写入一套本地配置：一个模板、一个系统的阈值、别名和节点标签
+func seedTransferState(t *testing.T, s *TransferService, systemID string) {
+	t.Helper()
+	storage := database.GetStorage()
+	if err := s.thresholds.CreateThresholdProfile(&models.ThresholdProfile{
+		ID: "budget-vps", Name: "budget VPS", Values: models.ThresholdValues{CPUAlertLimit: floatPtr(95)},
+	}, Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.thresholds.UpdateThreshold(systemID, putBody(t, map[string]any{
+		"profile_id": "budget-vps",
+		"overrides":  &models.ThresholdValues{DiskAlertLimit: floatPtr(0)},
+		"net_up_max": 120,
+	}), Change{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetSystemAlias(&models.SystemAlias{SystemID: systemID, Alias: "东京1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreateNodeTag(&models.NodeTag{SystemID: systemID, TagType: "v2ray", TagID: 12}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportAcrossHubRebuild(t *testing.T) {
+	setupThresholdStorage(t)
+	old := newTestTransferService(&models.System{ID: "old-id", Name: "tokyo-1"})
+	seedTransferState(t, old, "old-id")
+
+	bundle, err := old.Export(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Systems) != 1 || bundle.Systems[0].SystemName != "tokyo-1" {
+		t.Fatalf("导出的系统 = %+v", bundle.Systems)
+	}
+
+	// Hub重建后系统ID变化，同名系统应匹配到新ID
+	setupThresholdStorage(t)
+	rebuilt := newTestTransferService(&models.System{ID: "new-id", Name: "tokyo-1"})
+
+	dry, err := rebuilt.Import(context.Background(), bundle, models.ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.Summary[ActionCreate] != 4 {
+		t.Errorf("dry-run summary = %v, 期望4项create", dry.Summary)
+	}
+	if got, _ := database.GetStorage().GetSystemAlias("new-id"); got != nil {
+		t.Fatal("dry-run 不应写入数据")
+	}
+
+	if _, err := rebuilt.Import(context.Background(), bundle, models.ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	threshold, err := rebuilt.thresholds.GetThreshold(context.Background(), "new-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threshold.ProfileID != "budget-vps" || threshold.CPUAlertLimit != 95 || threshold.DiskAlertLimit != 0 ||
+		threshold.Sources["disk_alert_limit"] != SourceSystem || threshold.NetUpMax != 120 {
+		t.Errorf("导入后的阈值 = %+v", threshold)
+	}
+	if alias, _ := database.GetStorage().GetSystemAlias("new-id"); alias == nil || alias.Alias != "东京1" {
+		t.Errorf("导入后的别名 = %+v", alias)
+	}
+
+	// 再次导入没有任何变化
+	again, err := rebuilt.Import(context.Background(), bundle, models.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Summary[ActionUnchanged] != 4 || len(again.Summary) != 1 {
+		t.Errorf("重复导入 summary = %v", again.Summary)
+	}
+
+	// 只按ID匹配时找不到系统
+	byID, err := rebuilt.Import(context.Background(), bundle, models.ImportOptions{Match: MatchID, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byID.Summary[ActionSkip] != 1 {
+		t.Errorf("按ID匹配 summary = %v, 期望跳过", byID.Summary)
+	}
+}
+
+func TestImportReplaceRemovesUnlisted(t *testing.T) {
+	setupThresholdStorage(t)
+	s := newTestTransferService(&models.System{ID: "a", Name: "a"}, &models.System{ID: "b", Name: "b"})
+	seedTransferState(t, s, "a")
+	if err := database.GetStorage().SetSystemAlias(&models.SystemAlias{SystemID: "b", Alias: "旧别名"}); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := &models.ExportBundle{
+		Version: exportVersion,
+		Systems: []*models.SystemExport{{SystemID: "a", Alias: "新别名"}},
+	}
+	result, err := s.Import(context.Background(), bundle, models.ImportOptions{Mode: ImportReplace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 删除a的阈值和节点标签、b的别名以及不再使用的模板
+	if result.Summary[ActionDelete] != 4 || result.Summary[ActionUpdate] != 1 {
+		t.Errorf("summary = %v", result.Summary)
+	}
+
+	state, err := loadLocalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.profiles) != 0 || len(state.thresholds) != 0 || len(state.tags) != 0 || len(state.aliases) != 1 {
+		t.Errorf("replace 后剩余配置: %d模板 %d阈值 %d标签 %d别名",
+			len(state.profiles), len(state.thresholds), len(state.tags), len(state.aliases))
+	}
+}
+
+func TestImportRejectsInvalidBundle(t *testing.T) {
+	setupThresholdStorage(t)
+	s := newTestTransferService(&models.System{ID: "a", Name: "a"})
+
+	bundle := &models.ExportBundle{
+		Version: exportVersion,
+		Systems: []*models.SystemExport{
+			{SystemID: "a", Alias: "x", Threshold: &models.ThresholdExport{ProfileID: "missing"}},
+			{SystemID: "a", Threshold: &models.ThresholdExport{Overrides: &models.ThresholdValues{CPUAlertLimit: floatPtr(120)}}},
+		},
+	}
+	_, err := s.Import(context.Background(), bundle, models.ImportOptions{})
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("err = %v, 期望 ErrInvalidImport", err)
+	}
+	if alias, _ := database.GetStorage().GetSystemAlias("a"); alias != nil {
+		t.Error("校验失败时不应写入任何数据")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	bundle := &models.ExportBundle{
+		Version: exportVersion,
+		Profiles: []*models.ThresholdProfile{
+			{ID: "iepl-relay", Name: "IEPL relay", Values: models.ThresholdValues{NetUpAlert: floatPtr(60)}},
+		},
+		Systems: []*models.SystemExport{
+			{
+				SystemID: "a", SystemName: "tokyo, 1", Alias: "东京1",
+				Threshold: &models.ThresholdExport{
+					ProfileID: "iepl-relay",
+					Overrides: &models.ThresholdValues{DiskAlertLimit: floatPtr(0)},
+					NetUpMax:  12.5,
+				},
+				NodeTags: []models.NodeTagRef{{Type: "ss", ID: 3}, {Type: "v2ray", ID: 12}},
+			},
+			{SystemID: "b", Alias: "只有别名"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeCSV(&buf, bundle); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, bundle) {
+		t.Errorf("CSV往返结果不一致:\n%+v\n%+v", decoded.Systems[0], bundle.Systems[0])
+	}
+
+	if _, err := DecodeCSV(bytes.NewBufferString("kind,id,cpu_alert_limit\nsystem,a,abc\n")); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("非法数值: err = %v", err)
+	}
+}
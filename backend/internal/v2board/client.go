@@ -0,0 +1,270 @@
+// Package v2board 调用v2board/Xboard管理接口，修改节点的显示状态和倍率
+package v2board
+
+import (
+	"backend/internal/tracing"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 面板类型，两者的管理接口路径和认证方式不同
+const (
+	FlavorV2board = "v2board"
+	FlavorXboard  = "xboard"
+)
+
+// ErrNodeNotFound 面板中不存在该节点
+var ErrNodeNotFound = errors.New("面板中不存在该节点")
+
+// APIError 面板返回的非2xx响应
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("面板返回状态码 %d", e.Status)
+	}
+	return fmt.Sprintf("面板返回状态码 %d: %s", e.Status, e.Message)
+}
+
+// Node 面板中的节点。Raw 保留面板返回的全部字段，修改倍率时需要整体提交
+type Node struct {
+	ID   int
+	Type string
+	Name string
+	Show bool
+	Rate float64
+	Raw  map[string]interface{}
+}
+
+// Client v2board/Xboard 管理接口客户端
+type Client struct {
+	BaseURL    string
+	SecurePath string // 管理后台路径（secure_path）
+	Flavor     string
+	// Token v2board为登录返回的 auth_data，Xboard为管理员的API token
+	Token      string
+	HTTPClient *http.Client
+	// OnRequest 每次HTTP请求结束后调用，用于记录耗时和错误；status为0表示没有收到响应
+	OnRequest func(method, endpoint string, status int, duration time.Duration, err error)
+}
+
+// NewClient 创建管理接口客户端
+func NewClient(baseURL, securePath, flavor, token string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		SecurePath: strings.Trim(securePath, "/"),
+		Flavor:     flavor,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// GetNodes 获取所有节点
+func (c *Client) GetNodes(ctx context.Context) ([]*Node, error) {
+	var raw []map[string]interface{}
+	if err := c.call(ctx, http.MethodGet, "/server/manage/getNodes", nil, &raw); err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+
+	nodes := make([]*Node, 0, len(raw))
+	for _, fields := range raw {
+		node, err := parseNode(fields)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// GetNode 获取单个节点，不存在时返回 ErrNodeNotFound
+func (c *Client) GetNode(ctx context.Context, nodeType string, nodeID int) (*Node, error) {
+	nodes, err := c.GetNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.Type == nodeType && node.ID == nodeID {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s:%d", ErrNodeNotFound, nodeType, nodeID)
+}
+
+// SetShow 显示或隐藏节点，隐藏后用户的订阅中不再包含该节点
+func (c *Client) SetShow(ctx context.Context, nodeType string, nodeID int, show bool) error {
+	endpoint := "/server/" + nodeType + "/update"
+	if c.Flavor == FlavorXboard {
+		endpoint = "/server/manage/update"
+	}
+	body := map[string]interface{}{"id": nodeID, "show": boolToInt(show)}
+	if err := c.call(ctx, http.MethodPost, endpoint, body, nil); err != nil {
+		return fmt.Errorf("修改节点 %s:%d 显示状态失败: %w", nodeType, nodeID, err)
+	}
+	return nil
+}
+
+// SetRate 修改节点倍率。面板只支持整体保存节点，因此提交 GetNodes 返回的全部字段
+func (c *Client) SetRate(ctx context.Context, node *Node, rate float64) error {
+	fields := make(map[string]interface{}, len(node.Raw))
+	for key, value := range node.Raw {
+		fields[key] = value
+	}
+	fields["rate"] = strconv.FormatFloat(rate, 'f', -1, 64)
+
+	endpoint := "/server/" + node.Type + "/save"
+	if c.Flavor == FlavorXboard {
+		endpoint = "/server/manage/save"
+	}
+	if err := c.call(ctx, http.MethodPost, endpoint, fields, nil); err != nil {
+		return fmt.Errorf("修改节点 %s:%d 倍率失败: %w", node.Type, node.ID, err)
+	}
+	return nil
+}
+
+// prefix 管理接口的路径前缀
+func (c *Client) prefix() string {
+	if c.Flavor == FlavorXboard {
+		return "/api/v2/" + c.SecurePath
+	}
+	return "/api/v1/" + c.SecurePath
+}
+
+// call 发送一次请求并把响应中的 data 解码到out。写操作不重试，避免重复修改
+func (c *Client) call(ctx context.Context, method, endpoint string, body, out interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "Panel "+method+" "+endpoint, attribute.String("panel.flavor", c.Flavor))
+	status := 0
+	start := time.Now()
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		tracing.End(span, err)
+		if c.OnRequest != nil {
+			c.OnRequest(method, endpoint, status, time.Since(start), err)
+		}
+	}()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+c.prefix()+endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", c.authorization())
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求面板失败: %w", err)
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取面板响应失败: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &failure)
+		return &APIError{Status: status, Message: failure.Message}
+	}
+	if out == nil {
+		return nil
+	}
+
+	// 保留数字的原始形式，整体保存节点时原样提交
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("解析面板响应失败: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(envelope.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("解析面板响应失败: %w", err)
+	}
+	return nil
+}
+
+// authorization v2board直接使用auth_data，Xboard使用Bearer token
+func (c *Client) authorization() string {
+	if c.Flavor == FlavorXboard && !strings.HasPrefix(c.Token, "Bearer ") {
+		return "Bearer " + c.Token
+	}
+	return c.Token
+}
+
+// parseNode 解析节点字段。v2board的倍率是字符串、显示状态是0/1，Xboard可能返回数字和布尔值
+func parseNode(fields map[string]interface{}) (*Node, error) {
+	node := &Node{Raw: fields}
+	id, err := toFloat(fields["id"])
+	if err != nil {
+		return nil, fmt.Errorf("节点ID无效: %w", err)
+	}
+	node.ID = int(id)
+	node.Type, _ = fields["type"].(string)
+	node.Name, _ = fields["name"].(string)
+	if node.Type == "" {
+		return nil, fmt.Errorf("节点 %d 缺少type字段", node.ID)
+	}
+
+	switch show := fields["show"].(type) {
+	case bool:
+		node.Show = show
+	default:
+		value, err := toFloat(show)
+		if err != nil {
+			return nil, fmt.Errorf("节点 %s:%d 的show无效: %w", node.Type, node.ID, err)
+		}
+		node.Show = value != 0
+	}
+	if node.Rate, err = toFloat(fields["rate"]); err != nil {
+		return nil, fmt.Errorf("节点 %s:%d 的rate无效: %w", node.Type, node.ID, err)
+	}
+	return node, nil
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("%v 不是数字", value)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package v2board
+
+import (
+	"backend/internal/v2board/v2boardtest"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newClient(panel *v2boardtest.Panel, token string) *Client {
+	return NewClient(panel.URL+"/", "/"+v2boardtest.SecurePath, panel.Flavor, token, 5*time.Second)
+}
+
+func TestClient(t *testing.T) {
+	for _, flavor := range []string{FlavorV2board, FlavorXboard} {
+		t.Run(flavor, func(t *testing.T) {
+			panel := v2boardtest.NewPanel(flavor)
+			defer panel.Close()
+			panel.AddNode("trojan", 383, "hk-01", 1)
+			panel.AddNode("vmess", 12, "jp-01", 1.5)
+
+			ctx := context.Background()
+			client := newClient(panel, v2boardtest.Token)
+
+			nodes, err := client.GetNodes(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes) != 2 || nodes[1].Type != "vmess" || nodes[1].ID != 12 || nodes[1].Rate != 1.5 || !nodes[1].Show {
+				t.Fatalf("节点列表: %+v", nodes[1])
+			}
+
+			if err := client.SetShow(ctx, "trojan", 383, false); err != nil {
+				t.Fatal(err)
+			}
+			node, err := client.GetNode(ctx, "trojan", 383)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node.Show {
+				t.Error("节点应已隐藏")
+			}
+
+			// 修改倍率时提交节点的全部字段
+			if err := client.SetRate(ctx, node, 0.5); err != nil {
+				t.Fatal(err)
+			}
+			if node, err = client.GetNode(ctx, "trojan", 383); err != nil {
+				t.Fatal(err)
+			}
+			if node.Rate != 0.5 || node.Show {
+				t.Errorf("修改倍率后的节点: %+v", node)
+			}
+			writes := panel.Writes()
+			if save := writes[len(writes)-1].Body; save["host"] != "hk-01.example.com" || fmt.Sprint(save["port"]) != "443" {
+				t.Errorf("保存请求缺少节点字段: %+v", save)
+			}
+
+			if _, err := client.GetNode(ctx, "trojan", 1); !errors.Is(err, ErrNodeNotFound) {
+				t.Errorf("期望 ErrNodeNotFound, 得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	panel := v2boardtest.NewPanel(FlavorV2board)
+	defer panel.Close()
+	panel.AddNode("trojan", 383, "hk-01", 1)
+	ctx := context.Background()
+
+	var apiErr *APIError
+	if _, err := newClient(panel, "wrong").GetNodes(ctx); !errors.As(err, &apiErr) || apiErr.Status != 403 || apiErr.Message == "" {
+		t.Errorf("期望403, 得到 %v", err)
+	}
+
+	panel.FailWrites(1)
+	if err := newClient(panel, v2boardtest.Token).SetShow(ctx, "trojan", 383, false); !errors.As(err, &apiErr) || apiErr.Status != 500 {
+		t.Errorf("期望500, 得到 %v", err)
+	}
+}
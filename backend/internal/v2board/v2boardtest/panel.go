@@ -0,0 +1,199 @@
+// Package v2boardtest 提供模拟v2board/Xboard管理接口的本地测试服务器
+package v2boardtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// SecurePath 模拟面板的管理后台路径
+const SecurePath = "admin"
+
+// Token 模拟面板接受的认证信息
+const Token = "panel-token"
+
+// Request 模拟面板收到的写请求
+type Request struct {
+	Path string
+	Body map[string]interface{}
+}
+
+// Panel 模拟面板，只实现 getNodes、update 和 save。
+// v2board的节点 show 为0/1、rate 为字符串；Xboard的 show 为布尔值、rate 为数字
+type Panel struct {
+	*httptest.Server
+	Flavor string
+
+	mu       sync.Mutex
+	nodes    map[string]map[string]interface{}
+	order    []string
+	writes   []Request
+	failNext int // 接下来多少次写请求返回500
+}
+
+// NewPanel 启动模拟面板，测试结束时调用 Close
+func NewPanel(flavor string) *Panel {
+	p := &Panel{Flavor: flavor, nodes: make(map[string]map[string]interface{})}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serve))
+	return p
+}
+
+// AddNode 添加一个显示中的节点
+func (p *Panel) AddNode(nodeType string, id int, name string, rate float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	node := map[string]interface{}{"id": id, "type": nodeType, "name": name, "host": name + ".example.com", "port": 443}
+	if p.Flavor == "xboard" {
+		node["show"], node["rate"] = true, rate
+	} else {
+		node["show"], node["rate"] = 1, fmt.Sprintf("%.2f", rate)
+	}
+	key := fmt.Sprintf("%s:%d", nodeType, id)
+	p.nodes[key] = node
+	p.order = append(p.order, key)
+}
+
+// Node 返回节点当前的字段
+func (p *Panel) Node(nodeType string, id int) map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	node := p.nodes[fmt.Sprintf("%s:%d", nodeType, id)]
+	copied := make(map[string]interface{}, len(node))
+	for key, value := range node {
+		copied[key] = value
+	}
+	return copied
+}
+
+// Writes 返回收到的写请求
+func (p *Panel) Writes() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Request(nil), p.writes...)
+}
+
+// FailWrites 让接下来的n次写请求返回500
+func (p *Panel) FailWrites(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failNext = n
+}
+
+func (p *Panel) serve(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if p.Flavor == "xboard" {
+		auth = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if auth != Token {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"message": "鉴权失败"})
+		return
+	}
+
+	prefix := "/api/v1/" + SecurePath
+	if p.Flavor == "xboard" {
+		prefix = "/api/v2/" + SecurePath
+	}
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	if path == r.URL.Path {
+		http.NotFound(w, r)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r.Method == http.MethodGet && path == "/server/manage/getNodes" {
+		nodes := make([]map[string]interface{}, 0, len(p.order))
+		for _, key := range p.order {
+			nodes = append(nodes, p.nodes[key])
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": nodes})
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"message": "参数错误"})
+		return
+	}
+	p.writes = append(p.writes, Request{Path: path, Body: body})
+	if p.failNext > 0 {
+		p.failNext--
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"message": "面板内部错误"})
+		return
+	}
+
+	nodeType, action, ok := p.route(path, body)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	node := p.nodes[fmt.Sprintf("%s:%v", nodeType, body["id"])]
+	if node == nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"message": "节点不存在"})
+		return
+	}
+
+	switch action {
+	case "update":
+		show := body["show"] != float64(0)
+		if p.Flavor == "xboard" {
+			node["show"] = show
+		} else if show {
+			node["show"] = 1
+		} else {
+			node["show"] = 0
+		}
+	case "save":
+		// 面板按校验规则要求完整字段
+		for _, field := range []string{"name", "host", "port", "rate"} {
+			if _, ok := body[field]; !ok {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"message": field + " 不能为空"})
+				return
+			}
+		}
+		node["rate"] = body["rate"]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": true})
+}
+
+// route 解析写请求的节点类型和操作：v2board为 /server/{type}/update|save，Xboard为 /server/manage/update|save
+func (p *Panel) route(path string, body map[string]interface{}) (nodeType, action string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "server" || (parts[2] != "update" && parts[2] != "save") {
+		return "", "", false
+	}
+	nodeType, action = parts[1], parts[2]
+	if p.Flavor == "xboard" {
+		if nodeType != "manage" {
+			return "", "", false
+		}
+		switch {
+		case action == "save":
+			nodeType, _ = body["type"].(string)
+		default:
+			// Xboard的update只按ID查找，模拟面板中ID在所有类型间唯一
+			for _, node := range p.nodes {
+				if fmt.Sprint(node["id"]) == fmt.Sprint(body["id"]) {
+					nodeType = node["type"].(string)
+				}
+			}
+		}
+	}
+	return nodeType, action, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -6,13 +6,65 @@ import (
 
 // System 表示服务器/系统记录
 type System struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Host      string    `json:"host"`
-	Port      string    `json:"port"`
-	Status    string    `json:"status"` // up, down, unknown
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Host      string     `json:"host"`
+	Port      string     `json:"port"`
+	Status    string     `json:"status"` // up, down, unknown
+	Info      SystemInfo `json:"info"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	// Stale 为true时PocketBase不可用，系统信息或统计数据来自最近一次成功获取的快照
+	Stale bool `json:"stale,omitempty"`
+	// AsOf 快照的获取时间，只在 stale 为true时返回；没有可用的统计快照时为空
+	AsOf *time.Time `json:"as_of,omitempty"`
+}
+
+// SystemInfo 主机元数据（来自Beszel agent上报的info）
+type SystemInfo struct {
+	Hostname      string `json:"hostname"`
+	Kernel        string `json:"kernel"`
+	OS            string `json:"os"` // linux, darwin, windows, freebsd
+	CPUModel      string `json:"cpu_model"`
+	Cores         int    `json:"cores"`
+	Threads       int    `json:"threads"`
+	UptimeSeconds uint64 `json:"uptime_seconds"`
+	AgentVersion  string `json:"agent_version"`
+}
+
+// SystemFilter 系统列表的搜索条件
+type SystemFilter struct {
+	Keyword      string // 模糊匹配名称、地址、主机名、内核、CPU型号、agent版本、系统
+	Status       string
+	AgentVersion string
+	OS           string
+}
+
+// InventoryGroup 按某个属性分组的系统
+type InventoryGroup struct {
+	Key     string          `json:"key"`
+	Count   int             `json:"count"`
+	Systems []InventoryItem `json:"systems"`
+}
+
+// InventoryItem 资产清单中的系统条目
+type InventoryItem struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	AgentVersion string `json:"agent_version"`
+	OS           string `json:"os"`
+	CPUModel     string `json:"cpu_model"`
+	Cores        int    `json:"cores"`
+}
+
+// FleetInventory 服务器资产清单
+type FleetInventory struct {
+	Total              int              `json:"total"`
+	LatestAgentVersion string           `json:"latest_agent_version"`
+	OutdatedAgents     []InventoryItem  `json:"outdated_agents"` // agent版本低于集群中最新版本的系统
+	ByAgentVersion     []InventoryGroup `json:"by_agent_version"`
+	ByOS               []InventoryGroup `json:"by_os"`
+	ByCPUModel         []InventoryGroup `json:"by_cpu_model"`
 }
 
 // SystemStat 表示系统统计记录
@@ -27,6 +79,99 @@ type SystemStat struct {
 	NetSent   float64   `json:"net_sent"`  // 网络发送 MB/s
 	NetRecv   float64   `json:"net_recv"`  // 网络接收 MB/s
 	CreatedAt time.Time `json:"created_at"`
+
+	MemBuffCache float64 `json:"mem_buff_cache"` // 缓冲/缓存 GB
+	Swap         float64 `json:"swap"`           // 交换分区总量 GB
+	SwapUsed     float64 `json:"swap_used"`      // 交换分区使用量 GB
+	SwapPct      float64 `json:"swap_pct"`       // 交换分区使用百分比
+
+	DiskTotal float64 `json:"disk_total"` // 根分区总量 GB
+	DiskUsed  float64 `json:"disk_used"`  // 根分区使用量 GB
+	DiskPct   float64 `json:"disk_pct"`   // 根分区使用百分比
+	DiskRead  float64 `json:"disk_read"`  // 磁盘读 MB/s
+	DiskWrite float64 `json:"disk_write"` // 磁盘写 MB/s
+
+	LoadAvg1  float64 `json:"load_avg_1"`
+	LoadAvg5  float64 `json:"load_avg_5"`
+	LoadAvg15 float64 `json:"load_avg_15"`
+
+	Temperatures map[string]float64          `json:"temperatures,omitempty"` // 传感器温度 ℃
+	ExtraFs      map[string]FsStat           `json:"extra_fs,omitempty"`     // 额外挂载的文件系统
+	GPUs         map[string]GPUStat          `json:"gpus,omitempty"`
+	Interfaces   map[string]NetInterfaceStat `json:"interfaces,omitempty"` // 每个网卡的带宽
+}
+
+// StatsRangeQuery 统计数据时间范围查询
+type StatsRangeQuery struct {
+	SystemID  string
+	Type      string    // 1m, 10m, 20m, 120m, 480m；为空时按时间跨度自动选择
+	From      time.Time // 起始时间（含）
+	To        time.Time // 结束时间（含）
+	MaxPoints int       // 降采样后每个序列最多的点数，<=0 表示不降采样
+	Fields    []string  // 只获取这些字段（SystemStat的JSON名），为空时获取全部
+}
+
+// StatsRangeResult 时间范围查询结果
+type StatsRangeResult struct {
+	Type      string        `json:"type"`
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	RawCount  int           `json:"raw_count"` // 降采样前的记录数
+	Truncated bool          `json:"truncated"` // 记录数超过单次查询上限，只返回了最新的部分
+	Stats     []*SystemStat `json:"stats"`
+}
+
+// FsStat 额外文件系统统计
+type FsStat struct {
+	DiskTotal float64 `json:"disk_total"` // GB
+	DiskUsed  float64 `json:"disk_used"`  // GB
+	DiskPct   float64 `json:"disk_pct"`
+	DiskRead  float64 `json:"disk_read"`  // MB/s
+	DiskWrite float64 `json:"disk_write"` // MB/s
+}
+
+// GPUStat GPU统计
+type GPUStat struct {
+	Name        string  `json:"name"`
+	Usage       float64 `json:"usage"`        // 使用率 %
+	MemoryUsed  float64 `json:"memory_used"`  // 显存使用 MB
+	MemoryTotal float64 `json:"memory_total"` // 显存总量 MB
+	Power       float64 `json:"power"`        // 功耗 W
+}
+
+// NetInterfaceStat 网卡带宽
+type NetInterfaceStat struct {
+	NetSent float64 `json:"net_sent"` // MB/s
+	NetRecv float64 `json:"net_recv"` // MB/s
+}
+
+// UpstreamHealth 上游PocketBase的健康状态
+type UpstreamHealth struct {
+	Circuit             string     `json:"circuit"` // closed, open, half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	SnapshotAt          *time.Time `json:"snapshot_at,omitempty"` // 最近一次成功获取数据的时间
+	SnapshotAgeSeconds  float64    `json:"snapshot_age_seconds,omitempty"`
+}
+
+// ReadinessReport 就绪检查结果
+type ReadinessReport struct {
+	Status    string                      `json:"status"` // ready, degraded, not_ready
+	CheckedAt time.Time                   `json:"checked_at"`
+	Checks    map[string]*DependencyCheck `json:"checks"`
+}
+
+// DependencyCheck 单个依赖的检查结果
+type DependencyCheck struct {
+	Status        string                 `json:"status"`   // up, degraded, down, disabled
+	Critical      bool                   `json:"critical"` // 为down时实例不就绪
+	LatencyMs     float64                `json:"latency_ms"`
+	Error         string                 `json:"error,omitempty"` // 本次检查的错误
+	LastError     string                 `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time             `json:"last_error_at,omitempty"`
+	LastSuccessAt *time.Time             `json:"last_success_at,omitempty"`
+	Details       map[string]interface{} `json:"details,omitempty"`
 }
 
 // SystemSummary 服务器摘要
@@ -44,6 +189,9 @@ type SystemWithAvgStats struct {
 	AvgMemPct   float64   `json:"avg_mem_pct"`
 	AvgNetSent  float64   `json:"avg_net_sent"`
 	AvgNetRecv  float64   `json:"avg_net_recv"`
+	AvgDiskPct  float64   `json:"avg_disk_pct"`     // 根分区使用百分比
+	AvgSwapPct  float64   `json:"avg_swap_pct"`     // 交换分区使用百分比
+	LoadAvg1    float64   `json:"load_avg_1"`       // 最新的1分钟负载均值
 	OnlineUsers int       `json:"online_users"`     // 在线人数
 	LastUpdate  time.Time `json:"last_update"`
 }
@@ -54,6 +202,9 @@ type AverageStats struct {
 	AvgMemPct  float64   `json:"avg_mem_pct"`
 	AvgNetSent float64   `json:"avg_net_sent"`
 	AvgNetRecv float64   `json:"avg_net_recv"`
+	AvgDiskPct float64   `json:"avg_disk_pct"`
+	AvgSwapPct float64   `json:"avg_swap_pct"`
+	LoadAvg1   float64   `json:"load_avg_1"`
 	LastUpdate time.Time `json:"last_update"`
 }
 
@@ -68,14 +219,256 @@ type SystemThreshold struct {
 	NetUpAlert        float64 `gorm:"default:80.0" json:"net_up_alert"`        // 上行告警阈值（百分比）
 	NetDownAlert      float64 `gorm:"default:80.0" json:"net_down_alert"`      // 下行告警阈值（百分比）
 	OnlineUsersLimit  int     `gorm:"default:300" json:"online_users_limit"`   // 在线人数告警阈值（默认300人）
+	DiskAlertLimit    float64 `gorm:"default:90.0" json:"disk_alert_limit"`    // 根分区使用率告警阈值（%），0表示不检查
+	SwapAlertLimit    float64 `gorm:"default:0" json:"swap_alert_limit"`       // 交换分区使用率告警阈值（%），0表示不检查
+	ProfileID         string           `json:"profile_id,omitempty"` // 所属阈值模板，为空时直接继承全局默认
+	Overrides         *ThresholdValues `json:"overrides,omitempty"`  // 系统级覆盖的字段，未设置的字段继承模板/全局默认
+	Sources           map[string]string `json:"sources,omitempty"`   // 每个字段的生效来源：system、profile、global、config（读取时计算）
+	Version           int64     `json:"version"`                    // 每次修改配置时递增，用作ETag
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
 
+// ThresholdValues 可分层继承的阈值字段，nil表示不设置、继承上一层
+type ThresholdValues struct {
+	CPUAlertLimit    *float64 `json:"cpu_alert_limit,omitempty"`
+	MemAlertLimit    *float64 `json:"mem_alert_limit,omitempty"`
+	NetUpAlert       *float64 `json:"net_up_alert,omitempty"`
+	NetDownAlert     *float64 `json:"net_down_alert,omitempty"`
+	DiskAlertLimit   *float64 `json:"disk_alert_limit,omitempty"`
+	SwapAlertLimit   *float64 `json:"swap_alert_limit,omitempty"`
+	OnlineUsersLimit *int     `json:"online_users_limit,omitempty"`
+}
+
+// ThresholdProfile 阈值模板（如"IEPL中转"、"廉价VPS"），ID为default的模板是全局默认
+type ThresholdProfile struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Values      ThresholdValues `json:"values"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
 // SystemWithLoadStatus 带负载状态的系统统计
 type SystemWithLoadStatus struct {
 	SystemWithAvgStats
-	LoadStatus string `json:"load_status"` // normal, high
+	LoadStatus string `json:"load_status"` // normal, high, maintenance
+	// Maintenance 当前生效的维护窗口或静默，load_status 为 maintenance 时返回
+	Maintenance *MaintenanceWindow `json:"maintenance,omitempty"`
+	// Drain 服务器被手动排空时返回，此时 load_status 固定为 high
+	Drain *Drain `json:"drain,omitempty"`
+	// AnomalyStatus 与历史基线比较的结果：normal、anomaly 或 unknown（基线不足），不影响 load_status。
+	// 维护中、已排空、离线或未开启异常检测时为空
+	AnomalyStatus string `json:"anomaly_status,omitempty"`
+	// Anomalies 判定为异常的指标
+	Anomalies []*MetricAnomaly `json:"anomalies,omitempty"`
+}
+
+// Drain 手动排空记录：服务器或单个v2board节点不论负载如何都按高负载上报
+type Drain struct {
+	ID        string     `json:"id"`
+	SystemID  string     `json:"system_id,omitempty"` // 与节点二选一
+	NodeType  string     `json:"node_type,omitempty"`
+	NodeID    int        `json:"node_id,omitempty"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 为空表示直到手动恢复
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// DrainRequest 排空服务器或节点的请求，可以用 expires_in_minutes 代替 expires_at
+type DrainRequest struct {
+	SystemID         string     `json:"system_id"`
+	NodeType         string     `json:"node_type"`
+	NodeID           int        `json:"node_id"`
+	Reason           string     `json:"reason"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	ExpiresInMinutes int        `json:"expires_in_minutes"`
+}
+
+// HighLoadNode 高负载节点列表中的一项
+type HighLoadNode struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	ID       int    `json:"id"`
+	Online   int    `json:"online"`
+	SystemID string `json:"system_id,omitempty"` // 单独排空的节点没有对应的服务器
+	Reason   string `json:"reason"`              // high, offline, maintenance, drained
+}
+
+// NodeLoadState 写回Redis的单个节点负载状态
+type NodeLoadState struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`           // normal 或 high
+	Reason    string    `json:"reason,omitempty"` // status 为 high 时同 HighLoadNode.Reason
+	SystemID  string    `json:"system_id,omitempty"`
+	Online    int       `json:"online"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Stale 为true时本次计算使用了PocketBase不可用时的快照数据，AsOf 为其中最早的快照时间
+	Stale bool       `json:"stale,omitempty"`
+	AsOf  *time.Time `json:"as_of,omitempty"`
+}
+
+// Actuation 执行器对面板节点的一次操作，同时是撤销日志：记录操作前的值，节点恢复或手动撤销时据此还原
+type Actuation struct {
+	ID       string `json:"id"`
+	NodeType string `json:"node_type"`
+	NodeID   int    `json:"node_id"`
+	NodeName string `json:"node_name"`
+	SystemID string `json:"system_id,omitempty"`
+	Action   string `json:"action"` // hide 或 rate
+	Reason   string `json:"reason"` // 触发的原因，同 HighLoadNode.Reason
+	// PreviousShow/PreviousRate 操作前节点的显示状态和倍率
+	PreviousShow bool    `json:"previous_show"`
+	PreviousRate float64 `json:"previous_rate"`
+	Rate         float64 `json:"rate,omitempty"` // action 为 rate 时调整后的倍率
+	DryRun       bool    `json:"dry_run"`        // 只记录，没有修改面板
+	Status       string  `json:"status"`         // active、reverted 或 failed
+	Error        string  `json:"error,omitempty"`
+	// RevertError 最近一次恢复失败的原因，恢复成功前记录保持 active
+	RevertError string     `json:"revert_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevertedAt  *time.Time `json:"reverted_at,omitempty"`
+	RevertedBy  string     `json:"reverted_by,omitempty"` // actuator（节点恢复）或手动撤销的操作者
+}
+
+// ActuatorStatus 执行器当前的配置和操作统计
+type ActuatorStatus struct {
+	Enabled           bool   `json:"enabled"`
+	DryRun            bool   `json:"dry_run"`
+	Flavor            string `json:"flavor"`
+	Action            string `json:"action"`
+	Active            int    `json:"active"`            // 尚未恢复的操作数
+	ActionsLastHour   int    `json:"actions_last_hour"` // 最近一小时的操作数，不含恢复
+	MaxActionsPerHour int    `json:"max_actions_per_hour"`
+}
+
+// HistoryBucket 系统一小时内的负载采样汇总（本地存储），Metrics 的键为指标名
+type HistoryBucket struct {
+	SystemID string                   `json:"system_id"`
+	Hour     time.Time                `json:"hour"` // 整点（UTC）
+	Metrics  map[string]*HistoryValue `json:"metrics"`
+}
+
+// HistoryValue 一小时内某个指标的采样次数、总和与最大值
+type HistoryValue struct {
+	Count int     `json:"count"`
+	Sum   float64 `json:"sum"`
+	Max   float64 `json:"max"`
+}
+
+// SystemForecast 单个系统的容量预测
+type SystemForecast struct {
+	SystemID    string    `json:"system_id"`
+	SystemName  string    `json:"system_name"`
+	GeneratedAt time.Time `json:"generated_at"`
+	HorizonDays int       `json:"horizon_days"`
+	// HistoryHours 有数据的小时数，少于 forecast.min_history_hours 时不预测
+	HistoryHours        int  `json:"history_hours"`
+	InsufficientHistory bool `json:"insufficient_history,omitempty"`
+	// Metric/ThresholdAt/DaysToThreshold 预测期内最早达到阈值的指标
+	Metric          string            `json:"metric,omitempty"`
+	ThresholdAt     *time.Time        `json:"threshold_at,omitempty"`
+	DaysToThreshold *float64          `json:"days_to_threshold,omitempty"`
+	Metrics         []*MetricForecast `json:"metrics"`
+}
+
+// MetricForecast 单个指标（online_users、net_up_mbps、net_down_mbps）的预测，按每小时峰值建模
+type MetricForecast struct {
+	Metric      string  `json:"metric"`
+	Seasonality string  `json:"seasonality"`   // weekly、daily 或 none
+	Current     float64 `json:"current"`       // 最近24小时的峰值
+	TrendPerDay float64 `json:"trend_per_day"` // 线性趋势，每天的变化量
+	// Threshold 判定高负载的值，与负载状态的判定一致；0表示未配置，不计算达到阈值的时间
+	Threshold       float64    `json:"threshold"`
+	ThresholdAt     *time.Time `json:"threshold_at,omitempty"`
+	DaysToThreshold *float64   `json:"days_to_threshold,omitempty"`
+	// EarliestThresholdAt 80%预测区间上界首次达到阈值的时间，可作为最早需要扩容的时间
+	EarliestThresholdAt *time.Time      `json:"earliest_threshold_at,omitempty"`
+	Daily               []ForecastPoint `json:"daily,omitempty"`
+}
+
+// ForecastPoint 预测期内某一天（UTC）的峰值
+type ForecastPoint struct {
+	Date  string  `json:"date"`
+	Peak  float64 `json:"peak"`
+	Upper float64 `json:"upper"` // 80%预测区间上界
+}
+
+// SystemAnomaly 系统当前指标与每周同一时段历史基线的比较
+type SystemAnomaly struct {
+	SystemID   string           `json:"system_id"`
+	SystemName string           `json:"system_name"`
+	Status     string           `json:"status"` // normal、anomaly 或 unknown（所有指标的基线都不足）
+	CheckedAt  time.Time        `json:"checked_at"`
+	Metrics    []*MetricAnomaly `json:"metrics"`
+}
+
+// MetricAnomaly 单个指标（cpu、memory、net_up_mbps、net_down_mbps、online_users）与基线的比较
+type MetricAnomaly struct {
+	Metric   string  `json:"metric"`
+	Value    float64 `json:"value"`
+	Baseline float64 `json:"baseline"` // 基线中心：mad为中位数，zscore为平均值
+	// Deviation 基线的标准差（mad为MAD×1.4826），不低于每个指标的下限
+	Deviation float64 `json:"deviation"`
+	Score     float64 `json:"score"`   // (value-baseline)/deviation
+	Samples   int     `json:"samples"` // 基线使用的小时数，不足 min_samples 时不判定
+	Anomalous bool    `json:"anomalous"`
+	Direction string  `json:"direction,omitempty"` // 异常时为 high 或 low
+}
+
+// CapacityReport 全部系统的容量报告，按预计达到阈值的时间排序，预测期内不会达到阈值的排在最后
+type CapacityReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	HorizonDays int       `json:"horizon_days"`
+	AtRisk      int       `json:"at_risk"` // 预测期内会达到阈值的系统数
+	// Systems 不含每日预测值，需要时查询单个系统的预测
+	Systems []*SystemForecast `json:"systems"`
+}
+
+// NodeLoadEvent 节点负载状态变化，通过Redis pub/sub发布
+type NodeLoadEvent struct {
+	Type     string    `json:"type"`
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Reason   string    `json:"reason,omitempty"`
+	SystemID string    `json:"system_id,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// MaintenanceWindow 计划维护窗口或临时静默，作用于单台服务器或某个节点标签所在的服务器
+type MaintenanceWindow struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`                // window（计划维护）或 silence（临时静默）
+	SystemID  string    `json:"system_id,omitempty"` // 与节点标签二选一
+	TagType   string    `json:"tag_type,omitempty"`
+	TagID     int       `json:"tag_id,omitempty"`
+	Reason    string    `json:"reason"`
+	Policy    string    `json:"policy"` // exclude：不出现在高负载节点列表；include：始终出现在高负载节点列表
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaintenanceRequest 创建或修改维护窗口的请求。
+// 静默从当前时间开始，可以用 duration_minutes 代替 ends_at
+type MaintenanceRequest struct {
+	Kind            string     `json:"kind"`
+	SystemID        string     `json:"system_id"`
+	TagType         string     `json:"tag_type"`
+	TagID           int        `json:"tag_id"`
+	Reason          string     `json:"reason"`
+	Policy          string     `json:"policy"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+	DurationMinutes int        `json:"duration_minutes"`
 }
 
 // SystemAlias 服务器别名（本地存储）
@@ -83,6 +476,7 @@ type SystemAlias struct {
 	ID       uint   `json:"id"`
 	SystemID string `json:"system_id"`  // 服务器ID，唯一索引
 	Alias    string `json:"alias"`      // 别名
+	Version  int64  `json:"version"`    // 每次修改时递增，用作ETag
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -98,6 +492,16 @@ type SystemAliasResponse struct {
 	Alias   *SystemAlias `json:"alias,omitempty"`
 }
 
+// NodeTag 服务器节点标签（本地存储），用于将v2board节点(类型+ID)关联到服务器
+type NodeTag struct {
+	ID        uint      `json:"id"`
+	SystemID  string    `json:"system_id"`
+	TagType   string    `json:"tag_type"` // 节点类型，如 ss、v2ray、trojan
+	TagID     int       `json:"tag_id"`   // 节点ID
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // V2boardNode V2board节点信息
 type V2boardNode struct {
 	Name       string `json:"name"`
@@ -116,3 +520,168 @@ type SystemNodeInfo struct {
 	TotalOnline int           `json:"total_online"`
 }
 
+// ReloadResult 配置重载结果
+type ReloadResult struct {
+	Trigger         string    `json:"trigger"` // signal, file, api
+	Success         bool      `json:"success"`
+	Error           string    `json:"error,omitempty"`
+	Changed         []string  `json:"changed"`                    // 已生效的变更配置段
+	RestartRequired []string  `json:"restart_required,omitempty"` // 已变更但需要重启才能生效的配置段
+	Warnings        []string  `json:"warnings,omitempty"`         // 不影响重载结果的问题，如Redis仍无法重连
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+}
+// ExportBundle 本地配置导出包（阈值模板以及每个系统的阈值、别名、节点标签）
+type ExportBundle struct {
+	Version    int                 `json:"version"`
+	ExportedAt time.Time           `json:"exported_at"`
+	Profiles   []*ThresholdProfile `json:"threshold_profiles"`
+	Systems    []*SystemExport     `json:"systems"`
+}
+
+// SystemExport 单个系统的本地配置，导入时按 system_id 或 system_name 匹配
+type SystemExport struct {
+	SystemID   string           `json:"system_id"`
+	SystemName string           `json:"system_name,omitempty"`
+	Alias      string           `json:"alias,omitempty"`
+	Threshold  *ThresholdExport `json:"threshold,omitempty"`
+	NodeTags   []NodeTagRef     `json:"node_tags,omitempty"`
+}
+
+// ThresholdExport 系统阈值的存储内容（模板和系统级覆盖，不含继承得到的值）
+type ThresholdExport struct {
+	ProfileID  string           `json:"profile_id,omitempty"`
+	Overrides  *ThresholdValues `json:"overrides,omitempty"`
+	NetUpMax   float64          `json:"net_up_max,omitempty"`
+	NetDownMax float64          `json:"net_down_max,omitempty"`
+}
+
+// NodeTagRequest 添加或删除节点标签的请求结构
+type NodeTagRequest struct {
+	Type string `json:"type" binding:"required"` // 节点类型，如 ss、v2ray、trojan
+	ID   int    `json:"id" binding:"required"`   // 节点ID
+}
+
+// NodeTagRef v2board节点引用
+type NodeTagRef struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Mode   string `json:"mode"`    // merge, replace
+	Match  string `json:"match"`   // auto, id, name
+	DryRun bool   `json:"dry_run"` // 只生成变更计划，不写入
+	Actor  string `json:"-"`       // 操作者，写入审计日志
+}
+
+// ImportChange 导入产生的一项变更
+type ImportChange struct {
+	Kind     string `json:"kind"`                // profile, threshold, alias, node_tag, system
+	Action   string `json:"action"`              // create, update, delete, unchanged, skip
+	Target   string `json:"target"`              // 本地系统ID或模板ID
+	SourceID string `json:"source_id,omitempty"` // 导入文件中的系统ID
+	Name     string `json:"name,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Mode     string         `json:"mode"`
+	Match    string         `json:"match"`
+	DryRun   bool           `json:"dry_run"`
+	Summary  map[string]int `json:"summary"` // 按action统计
+	Changes  []ImportChange `json:"changes"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+// AuditEntry 配置修改审计记录
+type AuditEntry struct {
+	ID         string             `json:"id"`
+	Time       time.Time          `json:"time"`
+	Actor      string             `json:"actor"`
+	Action     string             `json:"action"`   // create, update, delete, import
+	Resource   string             `json:"resource"` // threshold, alias, threshold_profile, config
+	ResourceID string             `json:"resource_id,omitempty"`
+	Version    int64              `json:"version,omitempty"` // 修改后的版本
+	Changes    []AuditFieldChange `json:"changes,omitempty"`
+	Detail     string             `json:"detail,omitempty"`
+}
+
+// AuditFieldChange 单个字段的修改前后值
+type AuditFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditQuery 审计记录查询条件，零值字段不过滤
+type AuditQuery struct {
+	Resource   string
+	ResourceID string
+	Actor      string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// User 本地用户
+type User struct {
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"password_hash,omitempty"` // bcrypt哈希，接口响应中不返回
+	Role         string     `json:"role"`                    // viewer, operator, admin
+	Disabled     bool       `json:"disabled"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+}
+
+// Session 登录会话，以token的SHA-256为键保存，过期后由存储自动清理
+type Session struct {
+	TokenHash string    `json:"token_hash"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// APIKey 供面板、脚本等程序调用的密钥，明文只在创建时返回一次
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	SecretHash string     `json:"secret_hash,omitempty"` // 密钥的SHA-256，接口响应中不返回
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes,omitempty"` // 为空时可访问角色允许的所有接口
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// PasswordChangeRequest 修改自己密码的请求
+type PasswordChangeRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// UserRequest 创建或修改用户的请求，修改时空字段保持不变
+type UserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+	Disabled *bool  `json:"disabled"`
+}
+
+// APIKeyRequest 创建API密钥的请求
+type APIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Role          string   `json:"role"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0表示不过期
+}
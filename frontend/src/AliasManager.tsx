@@ -5,6 +5,7 @@ interface SystemAlias {
   id: number;
   system_id: string;
   alias: string;
+  version: number;  // 别名版本，修改时作为 If-Match 提交
   created_at: string;
   updated_at: string;
 }
@@ -48,6 +49,15 @@ const AliasManager: React.FC<AliasManagerProps> = ({
     }
   };
 
+  // 当前别名的版本，尚未设置时为0
+  const ifMatch = () => `"${alias?.version ?? 0}"`;
+
+  // 别名已被其他人修改：重新加载最新的别名，不覆盖对方的修改
+  const handleConflict = async () => {
+    await fetchSystemAlias();
+    setError('别名已被其他人修改，已加载最新的别名，请确认后重试');
+  };
+
   const saveAlias = async () => {
     if (!newAlias.trim()) {
       setError('别名不能为空');
@@ -60,10 +70,16 @@ const AliasManager: React.FC<AliasManagerProps> = ({
         method: 'PUT',
         headers: {
           'Content-Type': 'application/json',
+          'If-Match': ifMatch(),
         },
         body: JSON.stringify({ alias: newAlias.trim() }),
       });
 
+      if (response.status === 412) {
+        await handleConflict();
+        return;
+      }
+
       if (!response.ok) {
         const errorData = await response.json();
         throw new Error(errorData.error || '设置别名失败');
@@ -83,8 +99,16 @@ const AliasManager: React.FC<AliasManagerProps> = ({
       setSaving(true);
       const response = await fetch(`${API_BASE}/systems/${systemId}/alias`, {
         method: 'DELETE',
+        headers: {
+          'If-Match': ifMatch(),
+        },
       });
 
+      if (response.status === 412) {
+        await handleConflict();
+        return;
+      }
+
       if (!response.ok) {
         const errorData = await response.json();
         throw new Error(errorData.error || '删除别名失败');
@@ -1,12 +1,46 @@
-import React, { useState } from 'react';
+import React, { useState, useEffect } from 'react';
 import ServerMonitor from './ServerMonitor';
 import NodeManager from './NodeManager';
 import HighLoadNodes from './HighLoadNodes';
 import LoadStatusTest from './LoadStatusTest';
+import Login from './Login';
+import { API_BASE } from './utils/api';
 import "./index.css";
 
 export function App() {
   const [currentView, setCurrentView] = useState<'monitor' | 'nodes' | 'highload' | 'test'>('monitor');
+  // undefined: 检查中；null: 需要登录；string: 当前用户（未启用认证时为空串）
+  const [user, setUser] = useState<string | null | undefined>(undefined);
+
+  const checkLogin = async () => {
+    try {
+      const response = await fetch(`${API_BASE}/auth/me`);
+      if (response.status === 401) {
+        setUser(null);
+        return;
+      }
+      const data = await response.json();
+      setUser(data.principal?.name || '');
+    } catch {
+      setUser(null);
+    }
+  };
+
+  const logout = async () => {
+    await fetch(`${API_BASE}/auth/logout`, { method: 'POST' }).catch(() => undefined);
+    setUser(null);
+  };
+
+  useEffect(() => {
+    checkLogin();
+  }, []);
+
+  if (user === undefined) {
+    return <div className="loading-container">加载中...</div>;
+  }
+  if (user === null) {
+    return <Login onLogin={checkLogin} />;
+  }
 
   return (
     <div className="app">
@@ -36,6 +70,11 @@ export function App() {
           >
             负载测试
           </button>
+          {user && (
+            <button className="nav-link nav-logout" onClick={logout}>
+              {user} · 退出
+            </button>
+          )}
         </div>
       </nav>
       
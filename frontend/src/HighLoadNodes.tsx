@@ -14,6 +14,15 @@ interface SystemStats {
   online_users: number;
   last_update: string;
   load_status: string;
+  maintenance?: {
+    reason: string;
+    policy: 'exclude' | 'include';
+    ends_at: string;
+  };
+  drain?: {
+    reason: string;
+    expires_at?: string;
+  };
 }
 
 interface HighLoadNode {
@@ -21,6 +30,8 @@ interface HighLoadNode {
   type: string;
   id: number;
   online: number;
+  system_id?: string;
+  reason: 'high' | 'offline' | 'maintenance' | 'drained';
 }
 
 interface SystemSummary {
@@ -50,9 +61,11 @@ const HighLoadNodes: React.FC = () => {
       }
       const statsData = await statsResponse.json();
 
-      // 过滤高负载服务器（load_status为'high'或离线的服务器）
-      const highLoadSystems = (statsData.systems || []).filter((system: SystemStats) => 
-        system.load_status === 'high' || system.status !== 'up'
+      // 过滤高负载服务器（load_status为'high'、'unknown'或离线的服务器），维护中的服务器按维护窗口的policy决定
+      const highLoadSystems = (statsData.systems || []).filter((system: SystemStats) =>
+        system.maintenance
+          ? system.maintenance.policy === 'include'
+          : system.load_status === 'high' || system.load_status === 'unknown' || system.status !== 'up'
       );
 
       setSystems(highLoadSystems);
@@ -132,6 +145,7 @@ const HighLoadNodes: React.FC = () => {
   };
 
   const getLoadStatusText = (loadStatus: string, systemStatus: string) => {
+    if (loadStatus === 'maintenance') return '维护中';
     if (systemStatus !== 'up') return '离线';
     switch (loadStatus) {
       case 'high': return '高负载';
@@ -141,6 +155,7 @@ const HighLoadNodes: React.FC = () => {
   };
 
   const getLoadStatusClass = (loadStatus: string, systemStatus: string) => {
+    if (loadStatus === 'maintenance') return 'load-maintenance';
     if (systemStatus !== 'up') return 'load-offline';
     switch (loadStatus) {
       case 'high': return 'load-high';
@@ -152,8 +167,14 @@ const HighLoadNodes: React.FC = () => {
   const getLoadReasonText = (system: SystemStats) => {
     const reasons = [];
     
-    if (system.status !== 'up') {
+    if (system.drain) {
+      reasons.push(`已排空: ${system.drain.reason}`);
+    } else if (system.maintenance) {
+      reasons.push(`维护中: ${system.maintenance.reason}`);
+    } else if (system.status !== 'up') {
       reasons.push('服务器离线');
+    } else if (system.load_status === 'unknown') {
+      reasons.push('没有统计数据');
     } else {
       if (system.avg_cpu > 90) reasons.push(`CPU: ${system.avg_cpu.toFixed(1)}%`);
       if (system.avg_mem_pct > 90) reasons.push(`内存: ${system.avg_mem_pct.toFixed(1)}%`);
@@ -271,7 +292,7 @@ const HighLoadNodes: React.FC = () => {
                       </td>
                       <td>
                         <small style={{ color: '#6b7280', fontSize: '0.875rem' }}>
-                          {system.last_update ? formatDateTime(system.last_update) : '无数据'}
+                          {system.last_update && new Date(system.last_update).getTime() > 0 ? formatDateTime(system.last_update) : '无数据'}
                         </small>
                       </td>
                     </tr>
@@ -314,6 +335,7 @@ const HighLoadNodes: React.FC = () => {
                             textTransform: 'uppercase'
                           }}>
                             {node.type} · ID: {node.id}
+                            {node.reason === 'drained' && ' · 已排空'}
                           </div>
                         </div>
                       </td>
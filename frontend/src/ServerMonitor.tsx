@@ -14,7 +14,8 @@ interface SystemStats {
   avg_net_recv: number;
   online_users: number;  // 在线人数
   last_update: string;
-  load_status: string; // 新增：负载状态 'normal' | 'high'
+  load_status: string; // 负载状态 'normal' | 'high' | 'maintenance' | 'unknown'（没有统计数据）
+  anomaly_status?: string; // 异常检测结果 'normal' | 'anomaly' | 'unknown'
 }
 
 interface SystemThreshold {
@@ -103,7 +104,8 @@ const ServerMonitor: React.FC = () => {
     switch (loadStatus) {
       case 'high': return 'load-status-high';
       case 'normal': return 'load-status-normal';
-      default: return 'load-status-normal';
+      case 'maintenance': return 'load-status-maintenance';
+      default: return 'load-status-unknown';
     }
   };
 
@@ -111,6 +113,7 @@ const ServerMonitor: React.FC = () => {
     switch (loadStatus) {
       case 'high': return '高负载';
       case 'normal': return '正常';
+      case 'maintenance': return '维护中';
       default: return '未知';
     }
   };
@@ -206,6 +209,9 @@ const ServerMonitor: React.FC = () => {
                       <span className={getLoadStatusClass(system.load_status)}>
                         {getLoadStatusText(system.load_status)}
                       </span>
+                      {system.anomaly_status === 'anomaly' && (
+                        <span className="load-status-anomaly">异常</span>
+                      )}
                     </td>
                     <td>
                       <div className="progress-container">
@@ -239,7 +245,7 @@ const ServerMonitor: React.FC = () => {
                       </div>
                     </td>
                     <td>
-                      {system.last_update ? formatDateTime(system.last_update) : '无数据'}
+                      {system.last_update && new Date(system.last_update).getTime() > 0 ? formatDateTime(system.last_update) : '无数据'}
                     </td>
                     <td>
                       <button 
@@ -11,6 +11,7 @@ interface SystemThreshold {
   net_up_alert: number;
   net_down_alert: number;
   online_users_limit: number;  // 在线人数阈值
+  version: number;             // 配置版本，保存时作为 If-Match 提交
   created_at: string;
   updated_at: string;
 }
@@ -85,10 +86,18 @@ const ThresholdConfig: React.FC<ThresholdConfigProps> = ({ system, onClose, onSa
         method: 'PUT',
         headers: {
           'Content-Type': 'application/json',
+          'If-Match': `"${threshold.version}"`,
         },
         body: JSON.stringify(processedThreshold),
       });
 
+      // 打开配置后已被其他人修改：重新加载最新配置，不覆盖对方的修改
+      if (response.status === 412) {
+        await fetchThreshold();
+        setError('阈值配置已被其他人修改，已加载最新配置，请确认后重新保存');
+        return;
+      }
+
       if (!response.ok) {
         const errorData = await response.json();
         throw new Error(errorData.error || '保存阈值配置失败');